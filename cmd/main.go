@@ -22,5 +22,6 @@ import (
 func main() {
 	opts.RootCmd.CompletionOptions.DisableDefaultCmd = true
 	opts.RootCmd.AddCommand(opts.ServeCmd)
+	opts.RootCmd.AddCommand(opts.ConfigCmd)
 	opts.RootCmd.Execute()
 }
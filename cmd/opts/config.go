@@ -0,0 +1,67 @@
+package opts
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/mrexmelle/connect-emp/internal/config"
+	"github.com/spf13/cobra"
+)
+
+func loadConfig() *config.Loader {
+	l, err := config.NewLoader(config.ProfileFromEnv(), config.DefaultPaths)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	return l
+}
+
+func ShowConfig(cmd *cobra.Command, args []string) {
+	l := loadConfig()
+
+	out := cmd.OutOrStdout()
+	fmt.Fprintf(out, "profile: %s\n", l.Profile)
+	fmt.Fprintf(out, "file:    %s\n\n", l.ConfigFile)
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "KEY\tVALUE\tSOURCE")
+	for _, s := range l.Settings() {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", s.Key, s.DisplayValue(), s.Source)
+	}
+	w.Flush()
+}
+
+func ValidateConfig(cmd *cobra.Command, args []string) {
+	l := loadConfig()
+
+	err := l.Validate()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "configuration of profile %s is valid (%s)\n", l.Profile, l.ConfigFile)
+}
+
+var ConfigCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Inspect the effective configuration",
+}
+
+var ConfigShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Print the effective configuration with the source of each key, secrets redacted",
+	Run:   ShowConfig,
+}
+
+var ConfigValidateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "Validate the effective configuration, exiting non-zero on problems",
+	Run:   ValidateConfig,
+}
+
+func init() {
+	ConfigCmd.AddCommand(ConfigShowCmd)
+	ConfigCmd.AddCommand(ConfigValidateCmd)
+}
@@ -1,17 +1,34 @@
 package opts
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"log"
 	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
 
 	"github.com/go-chi/chi"
 	"github.com/go-chi/cors"
 	"github.com/mrexmelle/connect-emp/internal/account"
+	"github.com/mrexmelle/connect-emp/internal/analytics"
+	"github.com/mrexmelle/connect-emp/internal/authxclient"
+	"github.com/mrexmelle/connect-emp/internal/cache"
 	"github.com/mrexmelle/connect-emp/internal/career"
 	"github.com/mrexmelle/connect-emp/internal/config"
 	"github.com/mrexmelle/connect-emp/internal/grading"
+	"github.com/mrexmelle/connect-emp/internal/health"
 	"github.com/mrexmelle/connect-emp/internal/localerror"
+	"github.com/mrexmelle/connect-emp/internal/metrics"
+	"github.com/mrexmelle/connect-emp/internal/orgclient"
+	"github.com/mrexmelle/connect-emp/internal/orgnode"
+	"github.com/mrexmelle/connect-emp/internal/outbox"
 	"github.com/mrexmelle/connect-emp/internal/titling"
+	"github.com/mrexmelle/connect-emp/internal/tracing"
+	"github.com/mrexmelle/connect-emp/internal/webhook"
 	"github.com/spf13/cobra"
 	httpSwagger "github.com/swaggo/http-swagger"
 	"go.uber.org/dig"
@@ -24,29 +41,77 @@ func EnableCors(w *http.ResponseWriter) {
 func Serve(cmd *cobra.Command, args []string) {
 	container := dig.New()
 
+	container.Provide(analytics.NewRepository)
 	container.Provide(config.NewRepository)
 	container.Provide(grading.NewRepository)
+	container.Provide(outbox.NewRepository)
 	container.Provide(titling.NewRepository)
+	container.Provide(webhook.NewRepository)
+
+	container.Provide(authxclient.NewClient)
+	container.Provide(orgclient.NewClient)
+	container.Provide(tracing.NewProvider)
 
 	container.Provide(account.NewService)
+	container.Provide(analytics.NewService)
+	container.Provide(cache.NewService)
 	container.Provide(career.NewService)
 	container.Provide(config.NewService)
 	container.Provide(grading.NewService)
+	container.Provide(health.NewService)
 	container.Provide(localerror.NewService)
+	container.Provide(orgnode.NewService)
+	container.Provide(outbox.NewRelay)
 	container.Provide(titling.NewService)
+	container.Provide(webhook.NewService)
+	container.Provide(webhook.NewSink)
+	container.Provide(webhook.NewDispatcher)
 
 	container.Provide(account.NewController)
+	container.Provide(analytics.NewController)
+	container.Provide(cache.NewController)
 	container.Provide(grading.NewController)
+	container.Provide(health.NewController)
+	container.Provide(orgnode.NewController)
 	container.Provide(titling.NewController)
+	container.Provide(webhook.NewController)
 
 	process := func(
 		configService *config.Service,
+		tracingProvider *tracing.Provider,
+		cacheService *cache.Service,
 		accountController *account.Controller,
+		analyticsController *analytics.Controller,
+		cacheController *cache.Controller,
 		gradingController *grading.Controller,
+		healthController *health.Controller,
+		orgNodeController *orgnode.Controller,
 		titlingController *titling.Controller,
+		webhookController *webhook.Controller,
+		outboxRelay *outbox.Relay,
+		webhookSink *webhook.Sink,
+		webhookDispatcher *webhook.Dispatcher,
 	) {
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		workers := sync.WaitGroup{}
+		outboxRelay.AddSink(webhookSink)
+		for _, run := range []func(context.Context){
+			outboxRelay.Run,
+			webhookDispatcher.Run,
+		} {
+			workers.Add(1)
+			go func(run func(context.Context)) {
+				defer workers.Done()
+				run(ctx)
+			}(run)
+		}
+
 		r := chi.NewRouter()
 
+		r.Use(metrics.Middleware)
+		r.Use(tracing.Middleware)
 		r.Use(cors.Handler(cors.Options{
 			AllowedOrigins:   []string{"https://*", "http://localhost:3000"},
 			AllowedMethods:   []string{"GET", "PATCH", "POST", "PUT", "DELETE", "OPTIONS"},
@@ -64,6 +129,10 @@ func Serve(cmd *cobra.Command, args []string) {
 			))
 		}
 
+		r.Handle("/metrics", metrics.Handler())
+		r.Get("/healthz", healthController.GetLiveness)
+		r.Get("/readyz", healthController.GetReadiness)
+
 		r.Route("/gradings", func(r chi.Router) {
 			r.Post("/", gradingController.Post)
 			r.Get("/{id}", gradingController.Get)
@@ -78,20 +147,91 @@ func Serve(cmd *cobra.Command, args []string) {
 			r.Delete("/{id}", titlingController.Delete)
 		})
 
+		r.Route("/webhooks", func(r chi.Router) {
+			r.Post("/", webhookController.Post)
+			r.Get("/", webhookController.GetAll)
+			r.Get("/{id}", webhookController.Get)
+			r.Patch("/{id}", webhookController.Patch)
+			r.Delete("/{id}", webhookController.Delete)
+			r.Get("/{id}/deliveries", webhookController.GetDeliveries)
+			r.Get("/{id}/deliveries/{deliveryId}/attempts", webhookController.GetAttempts)
+		})
+
+		r.Route("/cache", func(r chi.Router) {
+			r.Delete("/{source}", cacheController.DeleteBySource)
+			r.Delete("/{source}/{ehid}", cacheController.DeleteBySourceAndEhid)
+		})
+
+		r.Route("/analytics", func(r chi.Router) {
+			r.Get("/headcount", analyticsController.GetHeadcount)
+			r.Get("/promotion-velocity", analyticsController.GetPromotionVelocity)
+		})
+
+		r.Route("/org-nodes", func(r chi.Router) {
+			r.Get("/{nodeId}/members", orgNodeController.GetMembers)
+		})
+
+		r.Post("/accounts:batchGetProfiles", accountController.PostBatchGetProfiles)
+		r.Post("/accounts:batchGetCareers", accountController.PostBatchGetCareers)
 		r.Route("/accounts", func(r chi.Router) {
 			r.Get("/{ehid}/profile", accountController.GetProfile)
 			r.Get("/{ehid}/career", accountController.GetCareer)
+			r.Get("/{ehid}/career/changes", accountController.GetCareerChanges)
+			r.Get("/{ehid}/career/diff", accountController.GetCareerDiff)
+			r.Get("/{ehid}/tenure", accountController.GetTenure)
+			r.Get("/{ehid}/gradings", gradingController.GetByEhid)
+			r.Get("/{ehid}/titlings", titlingController.GetByEhid)
 		})
 
-		err := http.ListenAndServe(fmt.Sprintf(":%d", configService.GetPort()), r)
+		cr := configService.ConfigRepository
+		server := &http.Server{
+			Addr:              fmt.Sprintf(":%d", configService.GetPort()),
+			Handler:           http.MaxBytesHandler(r, cr.GetServerMaxBodyBytes()),
+			ReadTimeout:       cr.GetServerReadTimeout(),
+			ReadHeaderTimeout: cr.GetServerReadHeaderTimeout(),
+			WriteTimeout:      cr.GetServerWriteTimeout(),
+			IdleTimeout:       cr.GetServerIdleTimeout(),
+			MaxHeaderBytes:    cr.GetServerMaxHeaderBytes(),
+		}
+
+		serverErr := make(chan error, 1)
+		go func() {
+			serverErr <- server.ListenAndServe()
+		}()
+
+		select {
+		case err := <-serverErr:
+			if !errors.Is(err, http.ErrServerClosed) {
+				panic(err)
+			}
+		case <-ctx.Done():
+			log.Println("shutting down, draining in-flight requests")
+		}
+		stop()
+
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), cr.GetServerShutdownTimeout())
+		defer cancel()
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			log.Printf("drain deadline exceeded: %v", err)
+		}
+
+		workers.Wait()
+
+		if err := tracingProvider.Shutdown(shutdownCtx); err != nil {
+			log.Printf("flushing spans: %v", err)
+		}
+
+		if err := cacheService.Close(); err != nil {
+			log.Printf("closing cache: %v", err)
+		}
 
-		if err != nil {
-			panic(err)
+		if err := configService.Close(); err != nil {
+			log.Printf("closing database pools: %v", err)
 		}
 	}
 
 	if err := container.Invoke(process); err != nil {
-		panic(err)
+		log.Fatal(dig.RootCause(err))
 	}
 }
 
@@ -23,7 +23,7 @@ const docTemplate = `{
     "paths": {
         "/accounts/{ehid}/career": {
             "get": {
-                "description": "Get a career",
+                "description": "Get a career. Gradings and titlings can be read as known at a point in transaction time; organization memberships are always current.",
                 "produces": [
                     "application/json"
                 ],
@@ -37,6 +37,54 @@ const docTemplate = `{
                         "name": "ehid",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Transaction time in RFC3339, or YYYY-MM-DD for the end of that day in UTC",
+                        "name": "as_known_at",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Clip the career from this date in YYYY-MM-DD",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Clip the career up to this date in YYYY-MM-DD",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "month or year, holding the values held for the most days in each bucket",
+                        "name": "granularity",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Merge adjacent segments holding the same values",
+                        "name": "compact",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "sources, to add the gradings, titlings and memberships behind every segment, linking the gradings and titlings",
+                        "name": "include",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "org, to add the name, type and ancestors of the organization node of every segment",
+                        "name": "expand",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Leave out organization nodes instead of failing when connect-org is unavailable",
+                        "name": "degraded",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -49,15 +97,21 @@ const docTemplate = `{
                     "400": {
                         "description": "BadRequest"
                     },
+                    "404": {
+                        "description": "NotFound"
+                    },
                     "500": {
                         "description": "InternalServerError"
+                    },
+                    "502": {
+                        "description": "BadGateway"
                     }
                 }
             }
         },
-        "/accounts/{ehid}/profile": {
+        "/accounts/{ehid}/career/changes": {
             "get": {
-                "description": "Get a profile",
+                "description": "Get the hires, promotions, demotions, grade changes between unordered grades, lateral title changes, transfers and gap starts and ends of a career in chronological order, with the positions before and after each. Gradings and titlings can be read as known at a point in transaction time; organization memberships are always current.",
                 "produces": [
                     "application/json"
                 ],
@@ -71,86 +125,136 @@ const docTemplate = `{
                         "name": "ehid",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Transaction time in RFC3339, or YYYY-MM-DD for the end of that day in UTC",
+                        "name": "as_known_at",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Leave out transfers instead of failing when connect-org is unavailable",
+                        "name": "degraded",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "Success Response",
                         "schema": {
-                            "$ref": "#/definitions/internal_account.GetProfileResponseDto"
+                            "$ref": "#/definitions/internal_account.GetCareerChangesResponseDto"
                         }
                     },
                     "400": {
                         "description": "BadRequest"
                     },
+                    "404": {
+                        "description": "NotFound"
+                    },
                     "500": {
                         "description": "InternalServerError"
+                    },
+                    "502": {
+                        "description": "BadGateway"
                     }
                 }
             }
         },
-        "/gradings": {
-            "post": {
-                "description": "Post a new gradings",
-                "consumes": [
-                    "application/json"
-                ],
+        "/accounts/{ehid}/career/diff": {
+            "get": {
+                "description": "Compare the grade, title and organization node held on from and to, with the changes and the career segments in between",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Gradings"
+                    "Accounts"
                 ],
                 "parameters": [
                     {
-                        "description": "Grading Request",
-                        "name": "data",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/internal_grading.PostRequestDto"
-                        }
+                        "type": "string",
+                        "description": "EHID",
+                        "name": "ehid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Date in YYYY-MM-DD",
+                        "name": "from",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Date in YYYY-MM-DD, today by default",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Leave out organization nodes instead of failing when connect-org is unavailable",
+                        "name": "degraded",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "Success Response",
                         "schema": {
-                            "$ref": "#/definitions/internal_grading.PostResponseDto"
+                            "$ref": "#/definitions/internal_account.GetCareerDiffResponseDto"
                         }
                     },
                     "400": {
                         "description": "BadRequest"
                     },
+                    "404": {
+                        "description": "NotFound"
+                    },
                     "500": {
                         "description": "InternalServerError"
+                    },
+                    "502": {
+                        "description": "BadGateway"
                     }
                 }
             }
         },
-        "/gradings/{id}": {
+        "/accounts/{ehid}/gradings": {
             "get": {
-                "description": "Get a grading",
+                "description": "Get grading history of an account, optionally as known at a point in transaction time",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Gradings"
+                    "Accounts"
                 ],
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Grading ID",
-                        "name": "id",
+                        "description": "EHID",
+                        "name": "ehid",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Sort by start date (asc or desc)",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Transaction time in RFC3339, or YYYY-MM-DD for the end of that day in UTC",
+                        "name": "as_known_at",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "Success Response",
                         "schema": {
-                            "$ref": "#/definitions/internal_grading.GetResponseDto"
+                            "$ref": "#/definitions/internal_grading.GetByEhidResponseDto"
                         }
                     },
                     "400": {
@@ -160,73 +264,153 @@ const docTemplate = `{
                         "description": "InternalServerError"
                     }
                 }
-            },
-            "delete": {
-                "description": "Delete a grading",
+            }
+        },
+        "/accounts/{ehid}/profile": {
+            "get": {
+                "description": "Get a profile",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Gradings"
+                    "Accounts"
                 ],
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Grading ID",
-                        "name": "id",
+                        "description": "EHID",
+                        "name": "ehid",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "org, to add the name, type and ancestors of the organization node",
+                        "name": "expand",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "managers and/or direct_reports, comma-separated, to add the reporting line with the name, grade and title of everyone in it",
+                        "name": "include",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Leave out the fields of an unavailable connect-org or connect-authx instead of failing",
+                        "name": "degraded",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "Success Response",
                         "schema": {
-                            "$ref": "#/definitions/internal_grading.DeleteResponseDto"
+                            "$ref": "#/definitions/internal_account.GetProfileResponseDto"
                         }
                     },
                     "400": {
                         "description": "BadRequest"
                     },
+                    "404": {
+                        "description": "NotFound"
+                    },
                     "500": {
                         "description": "InternalServerError"
+                    },
+                    "502": {
+                        "description": "BadGateway"
                     }
                 }
-            },
-            "patch": {
-                "description": "Patch a grading",
-                "consumes": [
-                    "application/json"
-                ],
+            }
+        },
+        "/accounts/{ehid}/tenure": {
+            "get": {
+                "description": "Get the total tenure, counting graded days only, and the time in the grade, title and organization node held on as_of.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Gradings"
+                    "Accounts"
                 ],
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Grading ID",
-                        "name": "id",
+                        "description": "EHID",
+                        "name": "ehid",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "description": "Grading Patch Request",
-                        "name": "data",
-                        "in": "body",
-                        "required": true,
+                        "type": "string",
+                        "description": "Date in YYYY-MM-DD, today by default",
+                        "name": "as_of",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Leave out the organization node instead of failing when connect-org is unavailable",
+                        "name": "degraded",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Success Response",
                         "schema": {
-                            "$ref": "#/definitions/internal_grading.PatchRequestDto"
+                            "$ref": "#/definitions/internal_account.GetTenureResponseDto"
                         }
+                    },
+                    "400": {
+                        "description": "BadRequest"
+                    },
+                    "404": {
+                        "description": "NotFound"
+                    },
+                    "500": {
+                        "description": "InternalServerError"
+                    },
+                    "502": {
+                        "description": "BadGateway"
+                    }
+                }
+            }
+        },
+        "/accounts/{ehid}/titlings": {
+            "get": {
+                "description": "Get titling history of an account, optionally as known at a point in transaction time",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Accounts"
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "EHID",
+                        "name": "ehid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Sort by start date (asc or desc)",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Transaction time in RFC3339, or YYYY-MM-DD for the end of that day in UTC",
+                        "name": "as_known_at",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "Success Response",
                         "schema": {
-                            "$ref": "#/definitions/internal_grading.PatchResponseDto"
+                            "$ref": "#/definitions/internal_titling.GetByEhidResponseDto"
                         }
                     },
                     "400": {
@@ -238,9 +422,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/titlings": {
+        "/accounts:batchGetCareers": {
             "post": {
-                "description": "Post a new titlings",
+                "description": "Get the careers of up to app.batch.max-size accounts at once. Every EHID gets its own data and error.",
                 "consumes": [
                     "application/json"
                 ],
@@ -248,24 +432,30 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Titlings"
+                    "Accounts"
                 ],
                 "parameters": [
                     {
-                        "description": "Titling Request",
+                        "description": "EHIDs",
                         "name": "data",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/internal_titling.PostRequestDto"
+                            "$ref": "#/definitions/internal_account.BatchGetRequestDto"
                         }
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Leave out organization nodes instead of failing when connect-org is unavailable",
+                        "name": "degraded",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "Success Response",
                         "schema": {
-                            "$ref": "#/definitions/internal_titling.PostResponseDto"
+                            "$ref": "#/definitions/internal_account.BatchGetCareersResponseDto"
                         }
                     },
                     "400": {
@@ -277,29 +467,40 @@ const docTemplate = `{
                 }
             }
         },
-        "/titlings/{id}": {
-            "get": {
-                "description": "Get a titling",
+        "/accounts:batchGetProfiles": {
+            "post": {
+                "description": "Get the profiles of up to app.batch.max-size accounts at once. Every EHID gets its own data and error.",
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Titlings"
+                    "Accounts"
                 ],
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Titling ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "description": "EHIDs",
+                        "name": "data",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_account.BatchGetRequestDto"
+                        }
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Leave out the fields of an unavailable connect-org or connect-authx instead of failing",
+                        "name": "degraded",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "Success Response",
                         "schema": {
-                            "$ref": "#/definitions/internal_titling.GetResponseDto"
+                            "$ref": "#/definitions/internal_account.BatchGetProfilesResponseDto"
                         }
                     },
                     "400": {
@@ -309,139 +510,1703 @@ const docTemplate = `{
                         "description": "InternalServerError"
                     }
                 }
-            },
-            "delete": {
-                "description": "Delete a titling",
+            }
+        },
+        "/analytics/headcount": {
+            "get": {
+                "description": "Count employees on the first day of every interval, grouped by grade, title or organization node, optionally within the subtree of a node",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Titlings"
+                    "Analytics"
                 ],
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Titling ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "description": "grade (default), title or org_node",
+                        "name": "group_by",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Date in YYYY-MM-DD, a year before to by default",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Date in YYYY-MM-DD, today by default",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "month (default), quarter or year",
+                        "name": "interval",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only count the members of this node and its descendants",
+                        "name": "node_id",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "Success Response",
                         "schema": {
-                            "$ref": "#/definitions/internal_titling.DeleteResponseDto"
+                            "$ref": "#/definitions/internal_analytics.GetHeadcountResponseDto"
                         }
                     },
                     "400": {
                         "description": "BadRequest"
                     },
+                    "404": {
+                        "description": "NotFound"
+                    },
                     "500": {
                         "description": "InternalServerError"
+                    },
+                    "502": {
+                        "description": "BadGateway"
                     }
                 }
-            },
-            "patch": {
-                "description": "Patch a titling",
-                "consumes": [
-                    "application/json"
-                ],
+            }
+        },
+        "/analytics/promotion-velocity": {
+            "get": {
+                "description": "Describe, grade by grade, how many days employees held a grade before changing straight to another one from from to to, optionally within the subtree of a node",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Titlings"
+                    "Analytics"
                 ],
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Titling ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "description": "Date in YYYY-MM-DD, a year before to by default",
+                        "name": "from",
+                        "in": "query"
                     },
                     {
-                        "description": "Titling Patch Request",
-                        "name": "data",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/internal_titling.PatchRequestDto"
-                        }
+                        "type": "string",
+                        "description": "Date in YYYY-MM-DD, today by default",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only count the changes of members of this node and its descendants",
+                        "name": "node_id",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "Success Response",
                         "schema": {
-                            "$ref": "#/definitions/internal_titling.PatchResponseDto"
+                            "$ref": "#/definitions/internal_analytics.GetPromotionVelocityResponseDto"
                         }
                     },
                     "400": {
                         "description": "BadRequest"
                     },
+                    "404": {
+                        "description": "NotFound"
+                    },
                     "500": {
                         "description": "InternalServerError"
+                    },
+                    "502": {
+                        "description": "BadGateway"
                     }
                 }
             }
-        }
-    },
+        },
+        "/cache/{source}": {
+            "delete": {
+                "description": "Invalidate the cached lookups of connect-org (org) or connect-authx (authx)",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Cache"
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "org or authx",
+                        "name": "source",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Success Response",
+                        "schema": {
+                            "$ref": "#/definitions/internal_cache.DeleteResponseDto"
+                        }
+                    },
+                    "400": {
+                        "description": "BadRequest"
+                    },
+                    "500": {
+                        "description": "InternalServerError"
+                    }
+                }
+            }
+        },
+        "/cache/{source}/{ehid}": {
+            "delete": {
+                "description": "Invalidate the cached lookups of connect-org (org) or connect-authx (authx) for one EHID",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Cache"
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "org or authx",
+                        "name": "source",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "EHID",
+                        "name": "ehid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Success Response",
+                        "schema": {
+                            "$ref": "#/definitions/internal_cache.DeleteResponseDto"
+                        }
+                    },
+                    "400": {
+                        "description": "BadRequest"
+                    },
+                    "500": {
+                        "description": "InternalServerError"
+                    }
+                }
+            }
+        },
+        "/gradings": {
+            "post": {
+                "description": "Post a new gradings",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Gradings"
+                ],
+                "parameters": [
+                    {
+                        "description": "Grading Request",
+                        "name": "data",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_grading.PostRequestDto"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Success Response",
+                        "schema": {
+                            "$ref": "#/definitions/internal_grading.PostResponseDto"
+                        }
+                    },
+                    "400": {
+                        "description": "BadRequest"
+                    },
+                    "500": {
+                        "description": "InternalServerError"
+                    }
+                }
+            }
+        },
+        "/gradings/{id}": {
+            "get": {
+                "description": "Get a grading",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Gradings"
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Grading ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Success Response",
+                        "schema": {
+                            "$ref": "#/definitions/internal_grading.GetResponseDto"
+                        }
+                    },
+                    "400": {
+                        "description": "BadRequest"
+                    },
+                    "500": {
+                        "description": "InternalServerError"
+                    }
+                }
+            },
+            "delete": {
+                "description": "Delete a grading",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Gradings"
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Grading ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Success Response",
+                        "schema": {
+                            "$ref": "#/definitions/internal_grading.DeleteResponseDto"
+                        }
+                    },
+                    "400": {
+                        "description": "BadRequest"
+                    },
+                    "500": {
+                        "description": "InternalServerError"
+                    }
+                }
+            },
+            "patch": {
+                "description": "Patch a grading",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Gradings"
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Grading ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Grading Patch Request",
+                        "name": "data",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_grading.PatchRequestDto"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Success Response",
+                        "schema": {
+                            "$ref": "#/definitions/internal_grading.PatchResponseDto"
+                        }
+                    },
+                    "400": {
+                        "description": "BadRequest"
+                    },
+                    "500": {
+                        "description": "InternalServerError"
+                    }
+                }
+            }
+        },
+        "/healthz": {
+            "get": {
+                "description": "Get liveness of the process",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Health"
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Success Response",
+                        "schema": {
+                            "$ref": "#/definitions/internal_health.GetResponseDto"
+                        }
+                    }
+                }
+            }
+        },
+        "/org-nodes/{nodeId}/members": {
+            "get": {
+                "description": "Get the members of a node with their name, grade and title, ordered by EHID. Past members are found through the membership histories of the EHIDs graded on as_of, against the current node structure. Every page of as_of looks up the histories of all of them, through the cache, and fails with bad_query_param when they outnumber app.org-nodes.max-history-lookups.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "OrgNodes"
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Node ID",
+                        "name": "nodeId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Date in YYYY-MM-DD, today when absent",
+                        "name": "as_of",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Include the members of descendant nodes",
+                        "name": "recursive",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page number, starting at 1",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Members per page, 50 by default and 500 at most",
+                        "name": "page_size",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Leave out names instead of failing when connect-authx is unavailable",
+                        "name": "degraded",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Success Response",
+                        "schema": {
+                            "$ref": "#/definitions/internal_orgnode.GetMembersResponseDto"
+                        }
+                    },
+                    "400": {
+                        "description": "BadRequest"
+                    },
+                    "404": {
+                        "description": "NotFound"
+                    },
+                    "500": {
+                        "description": "InternalServerError"
+                    },
+                    "502": {
+                        "description": "BadGateway"
+                    }
+                }
+            }
+        },
+        "/readyz": {
+            "get": {
+                "description": "Get readiness along with the status and latency of each dependency",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Health"
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Up or degraded",
+                        "schema": {
+                            "$ref": "#/definitions/internal_health.GetResponseDto"
+                        }
+                    },
+                    "503": {
+                        "description": "Down",
+                        "schema": {
+                            "$ref": "#/definitions/internal_health.GetResponseDto"
+                        }
+                    }
+                }
+            }
+        },
+        "/titlings": {
+            "post": {
+                "description": "Post a new titlings",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Titlings"
+                ],
+                "parameters": [
+                    {
+                        "description": "Titling Request",
+                        "name": "data",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_titling.PostRequestDto"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Success Response",
+                        "schema": {
+                            "$ref": "#/definitions/internal_titling.PostResponseDto"
+                        }
+                    },
+                    "400": {
+                        "description": "BadRequest"
+                    },
+                    "500": {
+                        "description": "InternalServerError"
+                    }
+                }
+            }
+        },
+        "/titlings/{id}": {
+            "get": {
+                "description": "Get a titling",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Titlings"
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Titling ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Success Response",
+                        "schema": {
+                            "$ref": "#/definitions/internal_titling.GetResponseDto"
+                        }
+                    },
+                    "400": {
+                        "description": "BadRequest"
+                    },
+                    "500": {
+                        "description": "InternalServerError"
+                    }
+                }
+            },
+            "delete": {
+                "description": "Delete a titling",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Titlings"
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Titling ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Success Response",
+                        "schema": {
+                            "$ref": "#/definitions/internal_titling.DeleteResponseDto"
+                        }
+                    },
+                    "400": {
+                        "description": "BadRequest"
+                    },
+                    "500": {
+                        "description": "InternalServerError"
+                    }
+                }
+            },
+            "patch": {
+                "description": "Patch a titling",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Titlings"
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Titling ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Titling Patch Request",
+                        "name": "data",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_titling.PatchRequestDto"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Success Response",
+                        "schema": {
+                            "$ref": "#/definitions/internal_titling.PatchResponseDto"
+                        }
+                    },
+                    "400": {
+                        "description": "BadRequest"
+                    },
+                    "500": {
+                        "description": "InternalServerError"
+                    }
+                }
+            }
+        },
+        "/webhooks": {
+            "get": {
+                "description": "Get all webhook subscriptions",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Webhooks"
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Success Response",
+                        "schema": {
+                            "$ref": "#/definitions/internal_webhook.GetAllResponseDto"
+                        }
+                    },
+                    "500": {
+                        "description": "InternalServerError"
+                    }
+                }
+            },
+            "post": {
+                "description": "Register a webhook subscription. The signing secret is only returned here.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Webhooks"
+                ],
+                "parameters": [
+                    {
+                        "description": "Webhook Request",
+                        "name": "data",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_webhook.PostRequestDto"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Success Response",
+                        "schema": {
+                            "$ref": "#/definitions/internal_webhook.PostResponseDto"
+                        }
+                    },
+                    "400": {
+                        "description": "BadRequest"
+                    },
+                    "500": {
+                        "description": "InternalServerError"
+                    }
+                }
+            }
+        },
+        "/webhooks/{id}": {
+            "get": {
+                "description": "Get a webhook subscription",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Webhooks"
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Webhook ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Success Response",
+                        "schema": {
+                            "$ref": "#/definitions/internal_webhook.GetResponseDto"
+                        }
+                    },
+                    "400": {
+                        "description": "BadRequest"
+                    },
+                    "500": {
+                        "description": "InternalServerError"
+                    }
+                }
+            },
+            "delete": {
+                "description": "Delete a webhook subscription along with its deliveries",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Webhooks"
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Webhook ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Success Response",
+                        "schema": {
+                            "$ref": "#/definitions/internal_webhook.DeleteResponseDto"
+                        }
+                    },
+                    "400": {
+                        "description": "BadRequest"
+                    },
+                    "500": {
+                        "description": "InternalServerError"
+                    }
+                }
+            },
+            "patch": {
+                "description": "Patch a webhook subscription",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Webhooks"
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Webhook ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Webhook Patch Request",
+                        "name": "data",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_webhook.PatchRequestDto"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Success Response",
+                        "schema": {
+                            "$ref": "#/definitions/internal_webhook.PatchResponseDto"
+                        }
+                    },
+                    "400": {
+                        "description": "BadRequest"
+                    },
+                    "500": {
+                        "description": "InternalServerError"
+                    }
+                }
+            }
+        },
+        "/webhooks/{id}/deliveries": {
+            "get": {
+                "description": "Get deliveries of a webhook subscription, newest first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Webhooks"
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Webhook ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Delivery status (pending, delivered or dead)",
+                        "name": "status",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Success Response",
+                        "schema": {
+                            "$ref": "#/definitions/internal_webhook.GetDeliveriesResponseDto"
+                        }
+                    },
+                    "400": {
+                        "description": "BadRequest"
+                    },
+                    "500": {
+                        "description": "InternalServerError"
+                    }
+                }
+            }
+        },
+        "/webhooks/{id}/deliveries/{deliveryId}/attempts": {
+            "get": {
+                "description": "Get every attempt made for a webhook delivery",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Webhooks"
+                ],
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Webhook ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Delivery ID",
+                        "name": "deliveryId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Success Response",
+                        "schema": {
+                            "$ref": "#/definitions/internal_webhook.GetAttemptsResponseDto"
+                        }
+                    },
+                    "400": {
+                        "description": "BadRequest"
+                    },
+                    "500": {
+                        "description": "InternalServerError"
+                    }
+                }
+            }
+        }
+    },
     "definitions": {
         "github_com_mrexmelle_connect-emp_internal_career.Aggregate": {
             "type": "object",
             "properties": {
-                "end_date": {
-                    "type": "string"
+                "end_date": {
+                    "type": "string"
+                },
+                "grade": {
+                    "type": "string"
+                },
+                "org": {
+                    "$ref": "#/definitions/github_com_mrexmelle_connect-emp_internal_career.Node"
+                },
+                "organization_node": {
+                    "type": "string"
+                },
+                "sources": {
+                    "$ref": "#/definitions/github_com_mrexmelle_connect-emp_internal_career.Sources"
+                },
+                "start_date": {
+                    "type": "string"
+                },
+                "title": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_mrexmelle_connect-emp_internal_career.Change": {
+            "type": "object",
+            "properties": {
+                "after": {
+                    "$ref": "#/definitions/github_com_mrexmelle_connect-emp_internal_career.Position"
+                },
+                "before": {
+                    "$ref": "#/definitions/github_com_mrexmelle_connect-emp_internal_career.Position"
+                },
+                "date": {
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_mrexmelle_connect-emp_internal_career.Diff": {
+            "type": "object",
+            "properties": {
+                "changes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_mrexmelle_connect-emp_internal_career.Change"
+                    }
+                },
+                "from": {
+                    "type": "string"
+                },
+                "grade": {
+                    "$ref": "#/definitions/github_com_mrexmelle_connect-emp_internal_career.DimensionDiff"
+                },
+                "organization_node": {
+                    "$ref": "#/definitions/github_com_mrexmelle_connect-emp_internal_career.DimensionDiff"
+                },
+                "segments": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_mrexmelle_connect-emp_internal_career.Aggregate"
+                    }
+                },
+                "title": {
+                    "$ref": "#/definitions/github_com_mrexmelle_connect-emp_internal_career.DimensionDiff"
+                },
+                "to": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_mrexmelle_connect-emp_internal_career.DimensionDiff": {
+            "type": "object",
+            "properties": {
+                "after": {
+                    "type": "string"
+                },
+                "before": {
+                    "type": "string"
+                },
+                "changed": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "github_com_mrexmelle_connect-emp_internal_career.Node": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "path": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_mrexmelle_connect-emp_internal_career.NodeRef"
+                    }
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_mrexmelle_connect-emp_internal_career.NodeRef": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_mrexmelle_connect-emp_internal_career.Position": {
+            "type": "object",
+            "properties": {
+                "grade": {
+                    "type": "string"
+                },
+                "organization_node": {
+                    "type": "string"
+                },
+                "title": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_mrexmelle_connect-emp_internal_career.Source": {
+            "type": "object",
+            "properties": {
+                "href": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "github_com_mrexmelle_connect-emp_internal_career.Sources": {
+            "type": "object",
+            "properties": {
+                "gradings": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_mrexmelle_connect-emp_internal_career.Source"
+                    }
+                },
+                "memberships": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_mrexmelle_connect-emp_internal_career.Source"
+                    }
+                },
+                "titlings": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_mrexmelle_connect-emp_internal_career.Source"
+                    }
+                }
+            }
+        },
+        "github_com_mrexmelle_connect-emp_internal_career.Tenure": {
+            "type": "object",
+            "properties": {
+                "as_of": {
+                    "type": "string"
+                },
+                "ehid": {
+                    "type": "string"
+                },
+                "grade": {
+                    "$ref": "#/definitions/github_com_mrexmelle_connect-emp_internal_career.TenureSpan"
+                },
+                "organization_node": {
+                    "$ref": "#/definitions/github_com_mrexmelle_connect-emp_internal_career.TenureSpan"
+                },
+                "title": {
+                    "$ref": "#/definitions/github_com_mrexmelle_connect-emp_internal_career.TenureSpan"
+                },
+                "total": {
+                    "$ref": "#/definitions/github_com_mrexmelle_connect-emp_internal_career.TenureSpan"
+                }
+            }
+        },
+        "github_com_mrexmelle_connect-emp_internal_career.TenureSpan": {
+            "type": "object",
+            "properties": {
+                "days": {
+                    "type": "integer"
+                },
+                "since": {
+                    "type": "string"
+                },
+                "value": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_mrexmelle_connect-emp_internal_dto.ServiceError": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string"
+                },
+                "message": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_mrexmelle_connect-emp_internal_dto.Warning": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string"
+                },
+                "message": {
+                    "type": "string"
+                },
+                "source": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_mrexmelle_connect-emp_internal_orgnode.PersonViewEntity": {
+            "type": "object",
+            "properties": {
+                "ehid": {
+                    "type": "string"
+                },
+                "grade": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "node_id": {
+                    "type": "string"
+                },
+                "title": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_mrexmelle_connect-emp_internal_profile.Aggregate": {
+            "type": "object",
+            "properties": {
+                "direct_reports": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_mrexmelle_connect-emp_internal_orgnode.PersonViewEntity"
+                    }
+                },
+                "dob": {
+                    "type": "string"
+                },
+                "ehid": {
+                    "type": "string"
+                },
+                "email_address": {
+                    "type": "string"
+                },
+                "employee_id": {
+                    "type": "string"
+                },
+                "grade": {
+                    "type": "string"
+                },
+                "managers": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_mrexmelle_connect-emp_internal_orgnode.PersonViewEntity"
+                    }
+                },
+                "name": {
+                    "type": "string"
+                },
+                "org": {
+                    "$ref": "#/definitions/github_com_mrexmelle_connect-emp_internal_career.Node"
+                },
+                "organization_node": {
+                    "type": "string"
+                },
+                "title": {
+                    "type": "string"
+                }
+            }
+        },
+        "internal_account.BatchGetCareersResponseDto": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/internal_account.BatchItemDto-array_github_com_mrexmelle_connect-emp_internal_career_Aggregate"
+                    }
+                },
+                "error": {
+                    "$ref": "#/definitions/github_com_mrexmelle_connect-emp_internal_dto.ServiceError"
+                },
+                "warnings": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_mrexmelle_connect-emp_internal_dto.Warning"
+                    }
+                }
+            }
+        },
+        "internal_account.BatchGetProfilesResponseDto": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/internal_account.BatchItemDto-github_com_mrexmelle_connect-emp_internal_profile_Aggregate"
+                    }
+                },
+                "error": {
+                    "$ref": "#/definitions/github_com_mrexmelle_connect-emp_internal_dto.ServiceError"
+                },
+                "warnings": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_mrexmelle_connect-emp_internal_dto.Warning"
+                    }
+                }
+            }
+        },
+        "internal_account.BatchGetRequestDto": {
+            "type": "object",
+            "properties": {
+                "ehids": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "internal_account.BatchItemDto-array_github_com_mrexmelle_connect-emp_internal_career_Aggregate": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_mrexmelle_connect-emp_internal_career.Aggregate"
+                    }
+                },
+                "ehid": {
+                    "type": "string"
+                },
+                "error": {
+                    "$ref": "#/definitions/github_com_mrexmelle_connect-emp_internal_dto.ServiceError"
+                },
+                "warnings": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_mrexmelle_connect-emp_internal_dto.Warning"
+                    }
+                }
+            }
+        },
+        "internal_account.BatchItemDto-github_com_mrexmelle_connect-emp_internal_profile_Aggregate": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/github_com_mrexmelle_connect-emp_internal_profile.Aggregate"
+                },
+                "ehid": {
+                    "type": "string"
+                },
+                "error": {
+                    "$ref": "#/definitions/github_com_mrexmelle_connect-emp_internal_dto.ServiceError"
+                },
+                "warnings": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_mrexmelle_connect-emp_internal_dto.Warning"
+                    }
+                }
+            }
+        },
+        "internal_account.GetCareerChangesResponseDto": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_mrexmelle_connect-emp_internal_career.Change"
+                    }
+                },
+                "error": {
+                    "$ref": "#/definitions/github_com_mrexmelle_connect-emp_internal_dto.ServiceError"
+                },
+                "warnings": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_mrexmelle_connect-emp_internal_dto.Warning"
+                    }
+                }
+            }
+        },
+        "internal_account.GetCareerDiffResponseDto": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/github_com_mrexmelle_connect-emp_internal_career.Diff"
+                },
+                "error": {
+                    "$ref": "#/definitions/github_com_mrexmelle_connect-emp_internal_dto.ServiceError"
+                },
+                "warnings": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_mrexmelle_connect-emp_internal_dto.Warning"
+                    }
+                }
+            }
+        },
+        "internal_account.GetCareerResponseDto": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_mrexmelle_connect-emp_internal_career.Aggregate"
+                    }
+                },
+                "error": {
+                    "$ref": "#/definitions/github_com_mrexmelle_connect-emp_internal_dto.ServiceError"
+                },
+                "warnings": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_mrexmelle_connect-emp_internal_dto.Warning"
+                    }
+                }
+            }
+        },
+        "internal_account.GetProfileResponseDto": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/github_com_mrexmelle_connect-emp_internal_profile.Aggregate"
+                },
+                "error": {
+                    "$ref": "#/definitions/github_com_mrexmelle_connect-emp_internal_dto.ServiceError"
+                },
+                "warnings": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_mrexmelle_connect-emp_internal_dto.Warning"
+                    }
+                }
+            }
+        },
+        "internal_account.GetTenureResponseDto": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/github_com_mrexmelle_connect-emp_internal_career.Tenure"
+                },
+                "error": {
+                    "$ref": "#/definitions/github_com_mrexmelle_connect-emp_internal_dto.ServiceError"
+                },
+                "warnings": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_mrexmelle_connect-emp_internal_dto.Warning"
+                    }
+                }
+            }
+        },
+        "internal_analytics.GetHeadcountResponseDto": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/internal_analytics.HeadcountViewEntity"
+                },
+                "error": {
+                    "$ref": "#/definitions/github_com_mrexmelle_connect-emp_internal_dto.ServiceError"
+                },
+                "warnings": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_mrexmelle_connect-emp_internal_dto.Warning"
+                    }
+                }
+            }
+        },
+        "internal_analytics.GetPromotionVelocityResponseDto": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/internal_analytics.PromotionVelocityViewEntity"
+                },
+                "error": {
+                    "$ref": "#/definitions/github_com_mrexmelle_connect-emp_internal_dto.ServiceError"
+                },
+                "warnings": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_mrexmelle_connect-emp_internal_dto.Warning"
+                    }
+                }
+            }
+        },
+        "internal_analytics.GradeVelocityViewEntity": {
+            "type": "object",
+            "properties": {
+                "changes": {
+                    "type": "integer"
+                },
+                "grade": {
+                    "type": "string"
+                },
+                "max_days": {
+                    "type": "integer"
+                },
+                "mean_days": {
+                    "type": "number"
+                },
+                "median_days": {
+                    "type": "integer"
+                },
+                "min_days": {
+                    "type": "integer"
+                },
+                "p25_days": {
+                    "type": "integer"
+                },
+                "p75_days": {
+                    "type": "integer"
+                }
+            }
+        },
+        "internal_analytics.HeadcountPointViewEntity": {
+            "type": "object",
+            "properties": {
+                "headcount": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "integer"
+                    }
+                },
+                "period": {
+                    "type": "string"
+                }
+            }
+        },
+        "internal_analytics.HeadcountViewEntity": {
+            "type": "object",
+            "properties": {
+                "from": {
+                    "type": "string"
+                },
+                "group_by": {
+                    "type": "string"
+                },
+                "interval": {
+                    "type": "string"
+                },
+                "node_id": {
+                    "type": "string"
+                },
+                "series": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/internal_analytics.HeadcountPointViewEntity"
+                    }
+                },
+                "to": {
+                    "type": "string"
+                }
+            }
+        },
+        "internal_analytics.PromotionVelocityViewEntity": {
+            "type": "object",
+            "properties": {
+                "from": {
+                    "type": "string"
+                },
+                "grades": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/internal_analytics.GradeVelocityViewEntity"
+                    }
+                },
+                "node_id": {
+                    "type": "string"
+                },
+                "to": {
+                    "type": "string"
+                }
+            }
+        },
+        "internal_cache.DeleteResponseDto": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/internal_cache.InvalidationViewEntity"
+                },
+                "error": {
+                    "$ref": "#/definitions/github_com_mrexmelle_connect-emp_internal_dto.ServiceError"
+                },
+                "warnings": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_mrexmelle_connect-emp_internal_dto.Warning"
+                    }
+                }
+            }
+        },
+        "internal_cache.InvalidationViewEntity": {
+            "type": "object",
+            "properties": {
+                "deleted": {
+                    "type": "integer"
+                },
+                "ehid": {
+                    "type": "string"
+                },
+                "source": {
+                    "type": "string"
+                }
+            }
+        },
+        "internal_grading.DeleteResponseDto": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "$ref": "#/definitions/github_com_mrexmelle_connect-emp_internal_dto.ServiceError"
+                }
+            }
+        },
+        "internal_grading.GetByEhidResponseDto": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/internal_grading.ViewEntity"
+                    }
+                },
+                "error": {
+                    "$ref": "#/definitions/github_com_mrexmelle_connect-emp_internal_dto.ServiceError"
+                },
+                "warnings": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_mrexmelle_connect-emp_internal_dto.Warning"
+                    }
+                }
+            }
+        },
+        "internal_grading.GetResponseDto": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/internal_grading.ViewEntity"
+                },
+                "error": {
+                    "$ref": "#/definitions/github_com_mrexmelle_connect-emp_internal_dto.ServiceError"
+                },
+                "warnings": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_mrexmelle_connect-emp_internal_dto.Warning"
+                    }
+                }
+            }
+        },
+        "internal_grading.PatchRequestDto": {
+            "type": "object",
+            "properties": {
+                "fields": {
+                    "type": "object",
+                    "additionalProperties": true
+                }
+            }
+        },
+        "internal_grading.PatchResponseDto": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "$ref": "#/definitions/github_com_mrexmelle_connect-emp_internal_dto.ServiceError"
+                }
+            }
+        },
+        "internal_grading.PostRequestDto": {
+            "type": "object",
+            "properties": {
+                "ehid": {
+                    "type": "string"
+                },
+                "end_date": {
+                    "type": "string"
+                },
+                "grade": {
+                    "type": "string"
+                },
+                "start_date": {
+                    "type": "string"
+                }
+            }
+        },
+        "internal_grading.PostResponseDto": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/internal_grading.ViewEntity"
+                },
+                "error": {
+                    "$ref": "#/definitions/github_com_mrexmelle_connect-emp_internal_dto.ServiceError"
+                },
+                "warnings": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_mrexmelle_connect-emp_internal_dto.Warning"
+                    }
+                }
+            }
+        },
+        "internal_grading.ViewEntity": {
+            "type": "object",
+            "properties": {
+                "ehid": {
+                    "type": "string"
+                },
+                "end_date": {
+                    "type": "string"
+                },
+                "grade": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "recorded_from": {
+                    "type": "string"
+                },
+                "recorded_to": {
+                    "type": "string"
+                },
+                "start_date": {
+                    "type": "string"
+                }
+            }
+        },
+        "internal_health.CheckViewEntity": {
+            "type": "object",
+            "properties": {
+                "critical": {
+                    "type": "boolean"
                 },
-                "grade": {
+                "error": {
                     "type": "string"
                 },
-                "organization_node": {
-                    "type": "string"
+                "latency_ms": {
+                    "type": "integer"
                 },
-                "start_date": {
+                "name": {
                     "type": "string"
                 },
-                "title": {
+                "status": {
                     "type": "string"
                 }
             }
         },
-        "github_com_mrexmelle_connect-emp_internal_dto.ServiceError": {
+        "internal_health.GetResponseDto": {
             "type": "object",
             "properties": {
-                "code": {
-                    "type": "string"
+                "data": {
+                    "$ref": "#/definitions/internal_health.ViewEntity"
                 },
-                "message": {
-                    "type": "string"
+                "error": {
+                    "$ref": "#/definitions/github_com_mrexmelle_connect-emp_internal_dto.ServiceError"
+                },
+                "warnings": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_mrexmelle_connect-emp_internal_dto.Warning"
+                    }
                 }
             }
         },
-        "github_com_mrexmelle_connect-emp_internal_profile.Aggregate": {
+        "internal_health.ViewEntity": {
             "type": "object",
             "properties": {
-                "dob": {
-                    "type": "string"
+                "checks": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/internal_health.CheckViewEntity"
+                    }
                 },
-                "ehid": {
+                "status": {
                     "type": "string"
+                }
+            }
+        },
+        "internal_orgnode.GetMembersResponseDto": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/internal_orgnode.MembersViewEntity"
                 },
-                "email_address": {
-                    "type": "string"
+                "error": {
+                    "$ref": "#/definitions/github_com_mrexmelle_connect-emp_internal_dto.ServiceError"
                 },
-                "employee_id": {
+                "warnings": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_mrexmelle_connect-emp_internal_dto.Warning"
+                    }
+                }
+            }
+        },
+        "internal_orgnode.MemberViewEntity": {
+            "type": "object",
+            "properties": {
+                "ehid": {
                     "type": "string"
                 },
                 "grade": {
                     "type": "string"
                 },
+                "member_since": {
+                    "type": "string"
+                },
                 "name": {
                     "type": "string"
                 },
-                "organization_node": {
+                "node_id": {
                     "type": "string"
                 },
                 "title": {
@@ -449,51 +2214,81 @@ const docTemplate = `{
                 }
             }
         },
-        "internal_account.GetCareerResponseDto": {
+        "internal_orgnode.MembersViewEntity": {
             "type": "object",
             "properties": {
-                "data": {
+                "as_of": {
+                    "type": "string"
+                },
+                "members": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/github_com_mrexmelle_connect-emp_internal_career.Aggregate"
+                        "$ref": "#/definitions/internal_orgnode.MemberViewEntity"
                     }
                 },
-                "error": {
-                    "$ref": "#/definitions/github_com_mrexmelle_connect-emp_internal_dto.ServiceError"
+                "node_id": {
+                    "type": "string"
+                },
+                "page": {
+                    "type": "integer"
+                },
+                "page_size": {
+                    "type": "integer"
+                },
+                "recursive": {
+                    "type": "boolean"
+                },
+                "total": {
+                    "type": "integer"
                 }
             }
         },
-        "internal_account.GetProfileResponseDto": {
+        "internal_titling.DeleteResponseDto": {
             "type": "object",
             "properties": {
-                "data": {
-                    "$ref": "#/definitions/github_com_mrexmelle_connect-emp_internal_profile.Aggregate"
-                },
                 "error": {
                     "$ref": "#/definitions/github_com_mrexmelle_connect-emp_internal_dto.ServiceError"
                 }
             }
         },
-        "internal_grading.DeleteResponseDto": {
+        "internal_titling.GetByEhidResponseDto": {
             "type": "object",
             "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/internal_titling.ViewEntity"
+                    }
+                },
                 "error": {
                     "$ref": "#/definitions/github_com_mrexmelle_connect-emp_internal_dto.ServiceError"
+                },
+                "warnings": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_mrexmelle_connect-emp_internal_dto.Warning"
+                    }
                 }
             }
         },
-        "internal_grading.GetResponseDto": {
+        "internal_titling.GetResponseDto": {
             "type": "object",
             "properties": {
                 "data": {
-                    "$ref": "#/definitions/internal_grading.ViewEntity"
+                    "$ref": "#/definitions/internal_titling.ViewEntity"
                 },
                 "error": {
                     "$ref": "#/definitions/github_com_mrexmelle_connect-emp_internal_dto.ServiceError"
+                },
+                "warnings": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_mrexmelle_connect-emp_internal_dto.Warning"
+                    }
                 }
             }
         },
-        "internal_grading.PatchRequestDto": {
+        "internal_titling.PatchRequestDto": {
             "type": "object",
             "properties": {
                 "fields": {
@@ -502,7 +2297,7 @@ const docTemplate = `{
                 }
             }
         },
-        "internal_grading.PatchResponseDto": {
+        "internal_titling.PatchResponseDto": {
             "type": "object",
             "properties": {
                 "error": {
@@ -510,7 +2305,7 @@ const docTemplate = `{
                 }
             }
         },
-        "internal_grading.PostRequestDto": {
+        "internal_titling.PostRequestDto": {
             "type": "object",
             "properties": {
                 "ehid": {
@@ -519,26 +2314,32 @@ const docTemplate = `{
                 "end_date": {
                     "type": "string"
                 },
-                "grade": {
+                "start_date": {
                     "type": "string"
                 },
-                "start_date": {
+                "title": {
                     "type": "string"
                 }
             }
         },
-        "internal_grading.PostResponseDto": {
+        "internal_titling.PostResponseDto": {
             "type": "object",
             "properties": {
                 "data": {
-                    "$ref": "#/definitions/internal_grading.ViewEntity"
+                    "$ref": "#/definitions/internal_titling.ViewEntity"
                 },
                 "error": {
                     "$ref": "#/definitions/github_com_mrexmelle_connect-emp_internal_dto.ServiceError"
+                },
+                "warnings": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_mrexmelle_connect-emp_internal_dto.Warning"
+                    }
                 }
             }
         },
-        "internal_grading.ViewEntity": {
+        "internal_titling.ViewEntity": {
             "type": "object",
             "properties": {
                 "ehid": {
@@ -547,37 +2348,176 @@ const docTemplate = `{
                 "end_date": {
                     "type": "string"
                 },
-                "grade": {
+                "id": {
+                    "type": "integer"
+                },
+                "recorded_from": {
+                    "type": "string"
+                },
+                "recorded_to": {
+                    "type": "string"
+                },
+                "start_date": {
+                    "type": "string"
+                },
+                "title": {
+                    "type": "string"
+                }
+            }
+        },
+        "internal_webhook.AttemptViewEntity": {
+            "type": "object",
+            "properties": {
+                "attempted_at": {
+                    "type": "string"
+                },
+                "delivery_id": {
+                    "type": "integer"
+                },
+                "duration_ms": {
+                    "type": "integer"
+                },
+                "error": {
                     "type": "string"
                 },
                 "id": {
                     "type": "integer"
                 },
-                "start_date": {
+                "status_code": {
+                    "type": "integer"
+                }
+            }
+        },
+        "internal_webhook.DeleteResponseDto": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "$ref": "#/definitions/github_com_mrexmelle_connect-emp_internal_dto.ServiceError"
+                }
+            }
+        },
+        "internal_webhook.DeliveryViewEntity": {
+            "type": "object",
+            "properties": {
+                "attempts": {
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "event_id": {
+                    "type": "integer"
+                },
+                "event_type": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "last_error": {
+                    "type": "string"
+                },
+                "last_status_code": {
+                    "type": "integer"
+                },
+                "next_attempt_at": {
+                    "type": "string"
+                },
+                "payload": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "status": {
+                    "type": "string"
+                },
+                "updated_at": {
                     "type": "string"
+                },
+                "webhook_id": {
+                    "type": "integer"
                 }
             }
         },
-        "internal_titling.DeleteResponseDto": {
+        "internal_webhook.GetAllResponseDto": {
             "type": "object",
             "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/internal_webhook.ViewEntity"
+                    }
+                },
                 "error": {
                     "$ref": "#/definitions/github_com_mrexmelle_connect-emp_internal_dto.ServiceError"
+                },
+                "warnings": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_mrexmelle_connect-emp_internal_dto.Warning"
+                    }
                 }
             }
         },
-        "internal_titling.GetResponseDto": {
+        "internal_webhook.GetAttemptsResponseDto": {
             "type": "object",
             "properties": {
                 "data": {
-                    "$ref": "#/definitions/internal_titling.ViewEntity"
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/internal_webhook.AttemptViewEntity"
+                    }
                 },
                 "error": {
                     "$ref": "#/definitions/github_com_mrexmelle_connect-emp_internal_dto.ServiceError"
+                },
+                "warnings": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_mrexmelle_connect-emp_internal_dto.Warning"
+                    }
                 }
             }
         },
-        "internal_titling.PatchRequestDto": {
+        "internal_webhook.GetDeliveriesResponseDto": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/internal_webhook.DeliveryViewEntity"
+                    }
+                },
+                "error": {
+                    "$ref": "#/definitions/github_com_mrexmelle_connect-emp_internal_dto.ServiceError"
+                },
+                "warnings": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_mrexmelle_connect-emp_internal_dto.Warning"
+                    }
+                }
+            }
+        },
+        "internal_webhook.GetResponseDto": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/internal_webhook.ViewEntity"
+                },
+                "error": {
+                    "$ref": "#/definitions/github_com_mrexmelle_connect-emp_internal_dto.ServiceError"
+                },
+                "warnings": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_mrexmelle_connect-emp_internal_dto.Warning"
+                    }
+                }
+            }
+        },
+        "internal_webhook.PatchRequestDto": {
             "type": "object",
             "properties": {
                 "fields": {
@@ -586,7 +2526,7 @@ const docTemplate = `{
                 }
             }
         },
-        "internal_titling.PatchResponseDto": {
+        "internal_webhook.PatchResponseDto": {
             "type": "object",
             "properties": {
                 "error": {
@@ -594,50 +2534,65 @@ const docTemplate = `{
                 }
             }
         },
-        "internal_titling.PostRequestDto": {
+        "internal_webhook.PostRequestDto": {
             "type": "object",
             "properties": {
-                "ehid": {
-                    "type": "string"
-                },
-                "end_date": {
-                    "type": "string"
+                "event_types": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
                 },
-                "start_date": {
+                "secret": {
                     "type": "string"
                 },
-                "title": {
+                "url": {
                     "type": "string"
                 }
             }
         },
-        "internal_titling.PostResponseDto": {
+        "internal_webhook.PostResponseDto": {
             "type": "object",
             "properties": {
                 "data": {
-                    "$ref": "#/definitions/internal_titling.ViewEntity"
+                    "$ref": "#/definitions/internal_webhook.ViewEntity"
                 },
                 "error": {
                     "$ref": "#/definitions/github_com_mrexmelle_connect-emp_internal_dto.ServiceError"
+                },
+                "warnings": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_mrexmelle_connect-emp_internal_dto.Warning"
+                    }
                 }
             }
         },
-        "internal_titling.ViewEntity": {
+        "internal_webhook.ViewEntity": {
             "type": "object",
             "properties": {
-                "ehid": {
-                    "type": "string"
+                "active": {
+                    "type": "boolean"
                 },
-                "end_date": {
+                "created_at": {
                     "type": "string"
                 },
+                "event_types": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
                 "id": {
                     "type": "integer"
                 },
-                "start_date": {
+                "secret": {
                     "type": "string"
                 },
-                "title": {
+                "updated_at": {
+                    "type": "string"
+                },
+                "url": {
                     "type": "string"
                 }
             }
@@ -1,13 +1,24 @@
 package account
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/go-chi/chi"
+	"github.com/mrexmelle/connect-emp/internal/batch"
 	"github.com/mrexmelle/connect-emp/internal/career"
 	"github.com/mrexmelle/connect-emp/internal/config"
+	"github.com/mrexmelle/connect-emp/internal/datestr"
+	"github.com/mrexmelle/connect-emp/internal/dto"
 	"github.com/mrexmelle/connect-emp/internal/dto/dtorespwithdata"
 	"github.com/mrexmelle/connect-emp/internal/localerror"
+	"github.com/mrexmelle/connect-emp/internal/profile"
+	"github.com/mrexmelle/connect-emp/internal/txtime"
 )
 
 type Controller struct {
@@ -33,23 +44,192 @@ func NewController(
 
 // Get Career : HTTP endpoint to get the career of an account
 // @Tags Accounts
-// @Description Get a career
+// @Description Get a career. Gradings and titlings can be read as known at a point in transaction time; organization memberships are always current.
 // @Produce json
 // @Param ehid path string true "EHID"
+// @Param as_known_at query string false "Transaction time in RFC3339, or YYYY-MM-DD for the end of that day in UTC"
+// @Param from query string false "Clip the career from this date in YYYY-MM-DD"
+// @Param to query string false "Clip the career up to this date in YYYY-MM-DD"
+// @Param granularity query string false "month or year, holding the values held for the most days in each bucket"
+// @Param compact query bool false "Merge adjacent segments holding the same values"
+// @Param include query string false "sources, to add the gradings, titlings and memberships behind every segment, linking the gradings and titlings"
+// @Param expand query string false "org, to add the name, type and ancestors of the organization node of every segment"
+// @Param degraded query bool false "Leave out organization nodes instead of failing when connect-org is unavailable"
 // @Success 200 {object} GetCareerResponseDto "Success Response"
 // @Failure 400 "BadRequest"
+// @Failure 404 "NotFound"
 // @Failure 500 "InternalServerError"
+// @Failure 502 "BadGateway"
 // @Router /accounts/{ehid}/career [GET]
 func (c *Controller) GetCareer(w http.ResponseWriter, r *http.Request) {
 	ehid := chi.URLParam(r, "ehid")
+	knownAt, err := txtime.NewFromString(r.URL.Query().Get("as_known_at"))
+	if err != nil {
+		dtorespwithdata.NewError(
+			localerror.ErrBadQueryParam.Error(),
+			err.Error(),
+		).RenderTo(w, http.StatusBadRequest)
+		return
+	}
+
+	degraded, err := c.parseDegraded(r)
+	if err != nil {
+		dtorespwithdata.NewError(
+			localerror.ErrBadQueryParam.Error(),
+			err.Error(),
+		).RenderTo(w, http.StatusBadRequest)
+		return
+	}
+
+	values := r.URL.Query()
+	q := career.TimelineQuery{
+		From:        values.Get("from"),
+		To:          values.Get("to"),
+		Granularity: values.Get("granularity"),
+	}
+	if value := values.Get("compact"); value != "" {
+		q.Compact, err = strconv.ParseBool(value)
+		if err != nil {
+			dtorespwithdata.NewError(
+				localerror.ErrBadQueryParam.Error(),
+				err.Error(),
+			).RenderTo(w, http.StatusBadRequest)
+			return
+		}
+	}
+	for _, include := range strings.Split(values.Get("include"), ",") {
+		switch strings.TrimSpace(include) {
+		case "":
+		case career.IncludeSources:
+			q.IncludeSources = true
+		default:
+			dtorespwithdata.NewError(
+				localerror.ErrBadQueryParam.Error(),
+				fmt.Sprintf("unknown include %q", include),
+			).RenderTo(w, http.StatusBadRequest)
+			return
+		}
+	}
+	q.ExpandOrg, err = c.parseExpandOrg(r)
+	if err != nil {
+		dtorespwithdata.NewError(
+			localerror.ErrBadQueryParam.Error(),
+			err.Error(),
+		).RenderTo(w, http.StatusBadRequest)
+		return
+	}
 
-	data, err := c.CareerService.RetrieveByEhidOrderByStartDateDesc(ehid)
+	data, warnings, err := c.CareerService.RetrieveTimelineByEhidAsKnownAt(
+		r.Context(),
+		ehid,
+		knownAt,
+		q,
+		degraded,
+	)
 	info := c.LocalErrorService.Map(err)
 	dtorespwithdata.New(
 		&data,
 		info.ServiceErrorCode,
 		info.ServiceErrorMessage,
-	).RenderTo(w, http.StatusOK)
+	).WithWarnings(warnings).RenderTo(w, info.HttpStatusCode)
+}
+
+// Get Career Changes : HTTP endpoint to get the changes of the career of an account
+// @Tags Accounts
+// @Description Get the hires, promotions, demotions, grade changes between unordered grades, lateral title changes, transfers and gap starts and ends of a career in chronological order, with the positions before and after each. Gradings and titlings can be read as known at a point in transaction time; organization memberships are always current.
+// @Produce json
+// @Param ehid path string true "EHID"
+// @Param as_known_at query string false "Transaction time in RFC3339, or YYYY-MM-DD for the end of that day in UTC"
+// @Param degraded query bool false "Leave out transfers instead of failing when connect-org is unavailable"
+// @Success 200 {object} GetCareerChangesResponseDto "Success Response"
+// @Failure 400 "BadRequest"
+// @Failure 404 "NotFound"
+// @Failure 500 "InternalServerError"
+// @Failure 502 "BadGateway"
+// @Router /accounts/{ehid}/career/changes [GET]
+func (c *Controller) GetCareerChanges(w http.ResponseWriter, r *http.Request) {
+	ehid := chi.URLParam(r, "ehid")
+	knownAt, err := txtime.NewFromString(r.URL.Query().Get("as_known_at"))
+	if err != nil {
+		dtorespwithdata.NewError(
+			localerror.ErrBadQueryParam.Error(),
+			err.Error(),
+		).RenderTo(w, http.StatusBadRequest)
+		return
+	}
+
+	degraded, err := c.parseDegraded(r)
+	if err != nil {
+		dtorespwithdata.NewError(
+			localerror.ErrBadQueryParam.Error(),
+			err.Error(),
+		).RenderTo(w, http.StatusBadRequest)
+		return
+	}
+
+	data, warnings, err := c.CareerService.RetrieveChangesByEhidAsKnownAt(
+		r.Context(),
+		ehid,
+		knownAt,
+		degraded,
+	)
+	info := c.LocalErrorService.Map(err)
+	dtorespwithdata.New(
+		&data,
+		info.ServiceErrorCode,
+		info.ServiceErrorMessage,
+	).WithWarnings(warnings).RenderTo(w, info.HttpStatusCode)
+}
+
+// Get Career Diff : HTTP endpoint to compare the career of an account between two dates
+// @Tags Accounts
+// @Description Compare the grade, title and organization node held on from and to, with the changes and the career segments in between
+// @Produce json
+// @Param ehid path string true "EHID"
+// @Param from query string true "Date in YYYY-MM-DD"
+// @Param to query string false "Date in YYYY-MM-DD, today by default"
+// @Param degraded query bool false "Leave out organization nodes instead of failing when connect-org is unavailable"
+// @Success 200 {object} GetCareerDiffResponseDto "Success Response"
+// @Failure 400 "BadRequest"
+// @Failure 404 "NotFound"
+// @Failure 500 "InternalServerError"
+// @Failure 502 "BadGateway"
+// @Router /accounts/{ehid}/career/diff [GET]
+func (c *Controller) GetCareerDiff(w http.ResponseWriter, r *http.Request) {
+	values := r.URL.Query()
+	from, err := datestr.NewFromString(values.Get("from"))
+	if err == nil && from.IsIndeterminate() {
+		err = errors.New("from is required")
+	}
+	to := datestr.NewFromTime(time.Now())
+	if err == nil && values.Get("to") != "" {
+		to, err = datestr.NewFromString(values.Get("to"))
+	}
+	if err != nil {
+		dtorespwithdata.NewError(
+			localerror.ErrBadQueryParam.Error(),
+			err.Error(),
+		).RenderTo(w, http.StatusBadRequest)
+		return
+	}
+
+	degraded, err := c.parseDegraded(r)
+	if err != nil {
+		dtorespwithdata.NewError(
+			localerror.ErrBadQueryParam.Error(),
+			err.Error(),
+		).RenderTo(w, http.StatusBadRequest)
+		return
+	}
+
+	ehid := chi.URLParam(r, "ehid")
+	data, warnings, err := c.CareerService.RetrieveDiffByEhid(r.Context(), ehid, from, to, degraded)
+	info := c.LocalErrorService.Map(err)
+	dtorespwithdata.New(
+		data,
+		info.ServiceErrorCode,
+		info.ServiceErrorMessage,
+	).WithWarnings(warnings).RenderTo(w, info.HttpStatusCode)
 }
 
 // Get Profile : HTTP endpoint to get the profile of an account
@@ -57,17 +237,226 @@ func (c *Controller) GetCareer(w http.ResponseWriter, r *http.Request) {
 // @Description Get a profile
 // @Produce json
 // @Param ehid path string true "EHID"
+// @Param expand query string false "org, to add the name, type and ancestors of the organization node"
+// @Param include query string false "managers and/or direct_reports, comma-separated, to add the reporting line with the name, grade and title of everyone in it"
+// @Param degraded query bool false "Leave out the fields of an unavailable connect-org or connect-authx instead of failing"
 // @Success 200 {object} GetProfileResponseDto "Success Response"
 // @Failure 400 "BadRequest"
+// @Failure 404 "NotFound"
 // @Failure 500 "InternalServerError"
+// @Failure 502 "BadGateway"
 // @Router /accounts/{ehid}/profile [GET]
 func (c *Controller) GetProfile(w http.ResponseWriter, r *http.Request) {
+	degraded, err := c.parseDegraded(r)
+	if err != nil {
+		dtorespwithdata.NewError(
+			localerror.ErrBadQueryParam.Error(),
+			err.Error(),
+		).RenderTo(w, http.StatusBadRequest)
+		return
+	}
+
+	q := profile.Query{}
+	q.ExpandOrg, err = c.parseExpandOrg(r)
+	if err != nil {
+		dtorespwithdata.NewError(
+			localerror.ErrBadQueryParam.Error(),
+			err.Error(),
+		).RenderTo(w, http.StatusBadRequest)
+		return
+	}
+	for _, include := range strings.Split(r.URL.Query().Get("include"), ",") {
+		switch strings.TrimSpace(include) {
+		case "":
+		case profile.IncludeManagers:
+			q.IncludeManagers = true
+		case profile.IncludeDirectReports:
+			q.IncludeDirectReports = true
+		default:
+			dtorespwithdata.NewError(
+				localerror.ErrBadQueryParam.Error(),
+				fmt.Sprintf("unknown include %q", include),
+			).RenderTo(w, http.StatusBadRequest)
+			return
+		}
+	}
+
 	ehid := chi.URLParam(r, "ehid")
-	data, err := c.AccountService.RetrieveProfile(ehid)
+	data, warnings, err := c.AccountService.RetrieveProfile(r.Context(), ehid, q, degraded)
 	info := c.LocalErrorService.Map(err)
 	dtorespwithdata.New(
 		data,
 		info.ServiceErrorCode,
 		info.ServiceErrorMessage,
+	).WithWarnings(warnings).RenderTo(w, info.HttpStatusCode)
+}
+
+// Get Tenure : HTTP endpoint to get the tenure of an account
+// @Tags Accounts
+// @Description Get the total tenure, counting graded days only, and the time in the grade, title and organization node held on as_of.
+// @Produce json
+// @Param ehid path string true "EHID"
+// @Param as_of query string false "Date in YYYY-MM-DD, today by default"
+// @Param degraded query bool false "Leave out the organization node instead of failing when connect-org is unavailable"
+// @Success 200 {object} GetTenureResponseDto "Success Response"
+// @Failure 400 "BadRequest"
+// @Failure 404 "NotFound"
+// @Failure 500 "InternalServerError"
+// @Failure 502 "BadGateway"
+// @Router /accounts/{ehid}/tenure [GET]
+func (c *Controller) GetTenure(w http.ResponseWriter, r *http.Request) {
+	asOf := datestr.NewFromTime(time.Now())
+	if value := r.URL.Query().Get("as_of"); value != "" {
+		var err error
+		asOf, err = datestr.NewFromString(value)
+		if err != nil {
+			dtorespwithdata.NewError(
+				localerror.ErrBadQueryParam.Error(),
+				err.Error(),
+			).RenderTo(w, http.StatusBadRequest)
+			return
+		}
+	}
+
+	degraded, err := c.parseDegraded(r)
+	if err != nil {
+		dtorespwithdata.NewError(
+			localerror.ErrBadQueryParam.Error(),
+			err.Error(),
+		).RenderTo(w, http.StatusBadRequest)
+		return
+	}
+
+	ehid := chi.URLParam(r, "ehid")
+	data, warnings, err := c.CareerService.RetrieveTenureByEhid(r.Context(), ehid, asOf, degraded)
+	info := c.LocalErrorService.Map(err)
+	dtorespwithdata.New(
+		data,
+		info.ServiceErrorCode,
+		info.ServiceErrorMessage,
+	).WithWarnings(warnings).RenderTo(w, info.HttpStatusCode)
+}
+
+// Batch Get Profiles : HTTP endpoint to get the profiles of many accounts
+// @Tags Accounts
+// @Description Get the profiles of up to app.batch.max-size accounts at once. Every EHID gets its own data and error.
+// @Accept json
+// @Produce json
+// @Param data body BatchGetRequestDto true "EHIDs"
+// @Param degraded query bool false "Leave out the fields of an unavailable connect-org or connect-authx instead of failing"
+// @Success 200 {object} BatchGetProfilesResponseDto "Success Response"
+// @Failure 400 "BadRequest"
+// @Failure 500 "InternalServerError"
+// @Router /accounts:batchGetProfiles [POST]
+func (c *Controller) PostBatchGetProfiles(w http.ResponseWriter, r *http.Request) {
+	requestBody, degraded, ok := c.parseBatchRequest(w, r)
+	if !ok {
+		return
+	}
+
+	results, err := c.AccountService.RetrieveProfiles(r.Context(), requestBody.Ehids, degraded)
+	info := c.LocalErrorService.Map(err)
+	dtorespwithdata.New(
+		toBatchItems(c.LocalErrorService, results),
+		info.ServiceErrorCode,
+		info.ServiceErrorMessage,
 	).RenderTo(w, info.HttpStatusCode)
 }
+
+// Batch Get Careers : HTTP endpoint to get the careers of many accounts
+// @Tags Accounts
+// @Description Get the careers of up to app.batch.max-size accounts at once. Every EHID gets its own data and error.
+// @Accept json
+// @Produce json
+// @Param data body BatchGetRequestDto true "EHIDs"
+// @Param degraded query bool false "Leave out organization nodes instead of failing when connect-org is unavailable"
+// @Success 200 {object} BatchGetCareersResponseDto "Success Response"
+// @Failure 400 "BadRequest"
+// @Failure 500 "InternalServerError"
+// @Router /accounts:batchGetCareers [POST]
+func (c *Controller) PostBatchGetCareers(w http.ResponseWriter, r *http.Request) {
+	requestBody, degraded, ok := c.parseBatchRequest(w, r)
+	if !ok {
+		return
+	}
+
+	results, err := c.AccountService.RetrieveCareers(r.Context(), requestBody.Ehids, degraded)
+	info := c.LocalErrorService.Map(err)
+	dtorespwithdata.New(
+		toBatchItems(c.LocalErrorService, results),
+		info.ServiceErrorCode,
+		info.ServiceErrorMessage,
+	).RenderTo(w, info.HttpStatusCode)
+}
+
+// parseBatchRequest decodes the body and the degraded query parameter of a
+// batch request, rendering a bad request when either is malformed.
+func (c *Controller) parseBatchRequest(w http.ResponseWriter, r *http.Request) (*BatchGetRequestDto, bool, bool) {
+	var requestBody BatchGetRequestDto
+	err := json.NewDecoder(r.Body).Decode(&requestBody)
+	if err != nil {
+		dtorespwithdata.NewError(
+			localerror.ErrBadJson.Error(),
+			err.Error(),
+		).RenderTo(w, http.StatusBadRequest)
+		return nil, false, false
+	}
+
+	degraded, err := c.parseDegraded(r)
+	if err != nil {
+		dtorespwithdata.NewError(
+			localerror.ErrBadQueryParam.Error(),
+			err.Error(),
+		).RenderTo(w, http.StatusBadRequest)
+		return nil, false, false
+	}
+
+	return &requestBody, degraded, true
+}
+
+// toBatchItems maps the error of every EHID the way a single lookup would.
+func toBatchItems[T any](les *localerror.Service, results []batch.Result[T]) *[]BatchItemDto[T] {
+	if results == nil {
+		return nil
+	}
+	items := []BatchItemDto[T]{}
+	for _, result := range results {
+		info := les.Map(result.Err)
+		items = append(items, BatchItemDto[T]{
+			Ehid: result.Ehid,
+			Data: result.Data,
+			Error: dto.ServiceError{
+				Code:    info.ServiceErrorCode,
+				Message: info.ServiceErrorMessage,
+			},
+			Warnings: result.Warnings,
+		})
+	}
+	return &items
+}
+
+// parseDegraded reads the degraded query parameter, falling back to
+// app.degraded.enabled when it is absent.
+func (c *Controller) parseDegraded(r *http.Request) (bool, error) {
+	value := r.URL.Query().Get("degraded")
+	if value == "" {
+		return c.ConfigService.ConfigRepository.GetDegradedEnabled(), nil
+	}
+	return strconv.ParseBool(value)
+}
+
+// parseExpandOrg reads the comma-separated expand query parameter, which
+// only knows org.
+func (c *Controller) parseExpandOrg(r *http.Request) (bool, error) {
+	expandOrg := false
+	for _, expand := range strings.Split(r.URL.Query().Get("expand"), ",") {
+		switch strings.TrimSpace(expand) {
+		case "":
+		case career.ExpandOrg:
+			expandOrg = true
+		default:
+			return false, fmt.Errorf("unknown expand %q", expand)
+		}
+	}
+	return expandOrg, nil
+}
@@ -2,9 +2,29 @@ package account
 
 import (
 	"github.com/mrexmelle/connect-emp/internal/career"
+	"github.com/mrexmelle/connect-emp/internal/dto"
 	"github.com/mrexmelle/connect-emp/internal/dto/dtorespwithdata"
 	"github.com/mrexmelle/connect-emp/internal/profile"
 )
 
 type GetProfileResponseDto = dtorespwithdata.Class[profile.Aggregate]
 type GetCareerResponseDto = dtorespwithdata.Class[[]career.Aggregate]
+type GetCareerChangesResponseDto = dtorespwithdata.Class[[]career.Change]
+type GetCareerDiffResponseDto = dtorespwithdata.Class[career.Diff]
+type GetTenureResponseDto = dtorespwithdata.Class[career.Tenure]
+
+type BatchGetRequestDto struct {
+	Ehids []string `json:"ehids"`
+}
+
+// BatchItemDto is the result of one EHID of a batch. Error tells whether
+// that EHID succeeded, independently of the other ones.
+type BatchItemDto[T any] struct {
+	Ehid     string           `json:"ehid"`
+	Data     *T               `json:"data"`
+	Error    dto.ServiceError `json:"error"`
+	Warnings []dto.Warning    `json:"warnings,omitempty"`
+}
+
+type BatchGetProfilesResponseDto = dtorespwithdata.Class[[]BatchItemDto[profile.Aggregate]]
+type BatchGetCareersResponseDto = dtorespwithdata.Class[[]BatchItemDto[[]career.Aggregate]]
@@ -1,58 +1,253 @@
 package account
 
 import (
+	"context"
+	"fmt"
+
 	"github.com/mrexmelle/connect-authx/pkg/libauthxc"
+	"github.com/mrexmelle/connect-emp/internal/authxclient"
+	"github.com/mrexmelle/connect-emp/internal/batch"
 	"github.com/mrexmelle/connect-emp/internal/career"
 	"github.com/mrexmelle/connect-emp/internal/config"
+	"github.com/mrexmelle/connect-emp/internal/dto"
 	"github.com/mrexmelle/connect-emp/internal/localerror"
+	"github.com/mrexmelle/connect-emp/internal/orgnode"
 	"github.com/mrexmelle/connect-emp/internal/profile"
+	"golang.org/x/sync/errgroup"
 )
 
 type Service struct {
-	ConfigService *config.Service
-	CareerService *career.Service
-	AuthxClient   *libauthxc.Client
+	ConfigService  *config.Service
+	CareerService  *career.Service
+	OrgNodeService *orgnode.Service
+	AuthxClient    authxclient.Client
 }
 
 func NewService(
 	cfg *config.Service,
 	cs *career.Service,
+	ons *orgnode.Service,
+	ac authxclient.Client,
 ) *Service {
 	return &Service{
-		ConfigService: cfg,
-		CareerService: cs,
-		AuthxClient: libauthxc.NewClient(
-			cfg.ConfigRepository.GetAuthxHost(),
-			cfg.ConfigRepository.GetAuthxPort(),
-		),
+		ConfigService:  cfg,
+		CareerService:  cs,
+		OrgNodeService: ons,
+		AuthxClient:    ac,
 	}
 }
 
-func (s *Service) RetrieveCareer(ehid string) ([]career.Aggregate, error) {
-	return s.CareerService.RetrieveByEhidOrderByStartDateDesc(ehid)
+func (s *Service) RetrieveCareer(ctx context.Context, ehid string) ([]career.Aggregate, error) {
+	return s.CareerService.RetrieveByEhidOrderByStartDateDesc(ctx, ehid)
 }
 
-func (s *Service) RetrieveProfile(ehid string) (*profile.Aggregate, error) {
-	p, err := s.AuthxClient.GetProfileByEhid(ehid)
-	if err != nil || p.Error.Code != localerror.ErrSvcCodeNone {
+// RetrieveCareers builds the careers of many EHIDs, see RetrieveProfiles.
+func (s *Service) RetrieveCareers(
+	ctx context.Context,
+	ehids []string,
+	degraded bool,
+) ([]batch.Result[[]career.Aggregate], error) {
+	ehids, err := s.checkBatch(ehids)
+	if err != nil {
 		return nil, err
 	}
+	return s.CareerService.RetrieveByEhidsOrderByStartDateDesc(
+		ctx,
+		ehids,
+		s.ConfigService.ConfigRepository.GetBatchConcurrency(),
+		degraded,
+	)
+}
 
-	agg := &profile.Aggregate{
-		Ehid:         ehid,
-		EmployeeId:   p.Data.EmployeeId,
-		Name:         p.Data.Name,
-		EmailAddress: p.Data.EmailAddress,
-		Dob:          p.Data.Dob,
+// RetrieveProfile merges the authx profile with the current career, which
+// are looked up concurrently. The first failure cancels the other lookups.
+// When degraded is set, a failing connect-authx or connect-org leaves its
+// fields out and adds a warning instead of failing the whole profile. The
+// organization node is expanded, and the managers and direct reports looked
+// up, once the current career is known.
+func (s *Service) RetrieveProfile(
+	ctx context.Context,
+	ehid string,
+	q profile.Query,
+	degraded bool,
+) (*profile.Aggregate, []dto.Warning, error) {
+	var (
+		p              *libauthxc.GetProfileResponseDto
+		authxWarnings  []dto.Warning
+		career         *career.Aggregate
+		careerWarnings []dto.Warning
+	)
+	eg, egCtx := errgroup.WithContext(ctx)
+	eg.Go(func() (err error) {
+		p, authxWarnings, err = s.retrieveAuthxProfile(egCtx, ehid, degraded)
+		return err
+	})
+	eg.Go(func() (err error) {
+		career, careerWarnings, err = s.CareerService.RetrieveCurrentByEhid(egCtx, ehid, degraded)
+		return err
+	})
+	err := eg.Wait()
+	if err != nil {
+		return nil, nil, err
 	}
 
-	career, err := s.CareerService.RetrieveCurrentByEhid(ehid)
+	agg := newProfile(ehid, p, career)
+	warnings := append(authxWarnings, careerWarnings...)
+	if q.ExpandOrg && agg.OrganizationNode != "" {
+		nodes, nodeWarnings, err := s.CareerService.ExpandNodes(ctx, []string{agg.OrganizationNode}, degraded)
+		if err != nil {
+			return nil, nil, err
+		}
+		if node, exists := nodes[agg.OrganizationNode]; exists {
+			agg.Org = &node
+		}
+		warnings = append(warnings, nodeWarnings...)
+	}
+	if (q.IncludeManagers || q.IncludeDirectReports) && agg.OrganizationNode != "" {
+		lineWarnings, err := s.retrieveReportingLine(ctx, agg, q, degraded)
+		if err != nil {
+			return nil, nil, err
+		}
+		warnings = append(warnings, lineWarnings...)
+	}
+	return agg, warnings, nil
+}
+
+// retrieveReportingLine adds the managers and direct reports of agg, looked
+// up concurrently, then describes them all at once. When degraded is set
+// and connect-org cannot be reached, both are left out and a warning says so.
+func (s *Service) retrieveReportingLine(
+	ctx context.Context,
+	agg *profile.Aggregate,
+	q profile.Query,
+	degraded bool,
+) ([]dto.Warning, error) {
+	var managers, reports []orgnode.PersonViewEntity
+	eg, egCtx := errgroup.WithContext(ctx)
+	if q.IncludeManagers {
+		eg.Go(func() (err error) {
+			managers, err = s.OrgNodeService.RetrieveManagers(egCtx, agg.Ehid, agg.OrganizationNode)
+			return err
+		})
+	}
+	if q.IncludeDirectReports {
+		eg.Go(func() (err error) {
+			reports, err = s.OrgNodeService.RetrieveDirectReports(egCtx, agg.Ehid, agg.OrganizationNode)
+			return err
+		})
+	}
+	err := eg.Wait()
+	if degraded && localerror.IsRemoteFailure(err) {
+		return []dto.Warning{localerror.NewWarning(localerror.WarningSourceOrg, err)}, nil
+	}
 	if err != nil {
 		return nil, err
 	}
-	agg.Grade = career.Grade
-	agg.Title = career.Title
-	agg.OrganizationNode = career.OrganizationNode
 
-	return agg, nil
+	people := append(append([]orgnode.PersonViewEntity{}, managers...), reports...)
+	warnings, err := s.OrgNodeService.DescribePeople(ctx, people, degraded)
+	if err != nil {
+		return nil, err
+	}
+	agg.Managers = people[:len(managers)]
+	agg.DirectReports = people[len(managers):]
+	return warnings, nil
+}
+
+// RetrieveProfiles builds the profiles of many EHIDs. Blank and repeated
+// EHIDs are dropped. Gradings and titlings are read with one query each,
+// while connect-org and connect-authx are called with at most
+// app.batch.concurrency calls at once each. Every EHID gets its own result,
+// so that one unknown EHID does not fail the others.
+func (s *Service) RetrieveProfiles(
+	ctx context.Context,
+	ehids []string,
+	degraded bool,
+) ([]batch.Result[profile.Aggregate], error) {
+	ehids, err := s.checkBatch(ehids)
+	if err != nil {
+		return nil, err
+	}
+	concurrency := s.ConfigService.ConfigRepository.GetBatchConcurrency()
+
+	var (
+		profiles []batch.Result[libauthxc.GetProfileResponseDto]
+		careers  []batch.Result[career.Aggregate]
+	)
+	eg, ctx := errgroup.WithContext(ctx)
+	eg.Go(func() error {
+		profiles = batch.Map(ctx, ehids, concurrency, func(
+			ctx context.Context,
+			ehid string,
+		) (*libauthxc.GetProfileResponseDto, []dto.Warning, error) {
+			return s.retrieveAuthxProfile(ctx, ehid, degraded)
+		})
+		return nil
+	})
+	eg.Go(func() (err error) {
+		careers, err = s.CareerService.RetrieveCurrentByEhids(ctx, ehids, concurrency, degraded)
+		return err
+	})
+	err = eg.Wait()
+	if err != nil {
+		return nil, err
+	}
+
+	results := []batch.Result[profile.Aggregate]{}
+	for i, ehid := range ehids {
+		result := batch.Result[profile.Aggregate]{
+			Ehid: ehid,
+		}
+		if profiles[i].Err != nil {
+			result.Err = profiles[i].Err
+		} else if careers[i].Err != nil {
+			result.Err = careers[i].Err
+		} else {
+			result.Data = newProfile(ehid, profiles[i].Data, careers[i].Data)
+			result.Warnings = append(profiles[i].Warnings, careers[i].Warnings...)
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// retrieveAuthxProfile turns a failure of connect-authx into a warning when
+// degraded is set.
+func (s *Service) retrieveAuthxProfile(
+	ctx context.Context,
+	ehid string,
+	degraded bool,
+) (*libauthxc.GetProfileResponseDto, []dto.Warning, error) {
+	p, err := s.AuthxClient.GetProfileByEhid(ctx, ehid)
+	if degraded && localerror.IsRemoteFailure(err) {
+		return nil, []dto.Warning{localerror.NewWarning(localerror.WarningSourceAuthx, err)}, nil
+	}
+	return p, nil, err
+}
+
+// checkBatch drops blank and repeated EHIDs and makes sure that between one
+// and app.batch.max-size of them are left.
+func (s *Service) checkBatch(ehids []string) ([]string, error) {
+	ehids = batch.Distinct(ehids)
+	maxSize := s.ConfigService.ConfigRepository.GetBatchMaxSize()
+	if len(ehids) == 0 || len(ehids) > maxSize {
+		return nil, fmt.Errorf("%w: expected 1 to %d EHIDs, got %d", localerror.ErrBadBatchSize, maxSize, len(ehids))
+	}
+	return ehids, nil
+}
+
+func newProfile(ehid string, p *libauthxc.GetProfileResponseDto, c *career.Aggregate) *profile.Aggregate {
+	agg := &profile.Aggregate{
+		Ehid:             ehid,
+		Grade:            c.Grade,
+		Title:            c.Title,
+		OrganizationNode: c.OrganizationNode,
+	}
+	if p != nil {
+		agg.EmployeeId = p.Data.EmployeeId
+		agg.Name = p.Data.Name
+		agg.EmailAddress = p.Data.EmailAddress
+		agg.Dob = p.Data.Dob
+	}
+	return agg
 }
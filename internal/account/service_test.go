@@ -0,0 +1,380 @@
+package account
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/mrexmelle/connect-authx/pkg/libauthxc"
+	"github.com/mrexmelle/connect-emp/internal/career"
+	"github.com/mrexmelle/connect-emp/internal/config"
+	"github.com/mrexmelle/connect-emp/internal/grading"
+	"github.com/mrexmelle/connect-emp/internal/localerror"
+	"github.com/mrexmelle/connect-emp/internal/orgclient"
+	"github.com/mrexmelle/connect-emp/internal/orgnode"
+	"github.com/mrexmelle/connect-emp/internal/profile"
+	"github.com/mrexmelle/connect-emp/internal/titling"
+	"github.com/mrexmelle/connect-org/pkg/liborgc"
+)
+
+func latency(ctx context.Context, d time.Duration) error {
+	if d == 0 {
+		return nil
+	}
+	select {
+	case <-time.After(d):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+type gradingRepositoryStub struct {
+	grading.Repository
+	Delay time.Duration
+}
+
+func (r *gradingRepositoryStub) FindCurrentByEhid(ctx context.Context, ehid string) (*grading.Entity, error) {
+	if err := latency(ctx, r.Delay); err != nil {
+		return nil, err
+	}
+	return &grading.Entity{Id: 1, Ehid: ehid, StartDate: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC), Grade: "E5"}, nil
+}
+
+func (r *gradingRepositoryStub) FindCurrentByEhids(ctx context.Context, ehids []string) ([]grading.Entity, error) {
+	entities := []grading.Entity{}
+	for _, ehid := range ehids {
+		e, err := r.FindCurrentByEhid(ctx, ehid)
+		if err != nil {
+			return nil, err
+		}
+		entities = append(entities, *e)
+	}
+	return entities, nil
+}
+
+type titlingRepositoryStub struct {
+	titling.Repository
+	Delay time.Duration
+}
+
+func (r *titlingRepositoryStub) FindCurrentByEhid(ctx context.Context, ehid string) (*titling.Entity, error) {
+	if err := latency(ctx, r.Delay); err != nil {
+		return nil, err
+	}
+	return &titling.Entity{Id: 1, Ehid: ehid, StartDate: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC), Title: "Engineer"}, nil
+}
+
+func (r *titlingRepositoryStub) FindCurrentByEhids(ctx context.Context, ehids []string) ([]titling.Entity, error) {
+	entities := []titling.Entity{}
+	for _, ehid := range ehids {
+		e, err := r.FindCurrentByEhid(ctx, ehid)
+		if err != nil {
+			return nil, err
+		}
+		entities = append(entities, *e)
+	}
+	return entities, nil
+}
+
+type orgClientStub struct {
+	orgclient.Client
+	Delay       time.Duration
+	Canceled    bool
+	OfficersErr error
+}
+
+func (c *orgClientStub) GetMemberHistoryByEhidOrderByStartDateDesc(
+	ctx context.Context,
+	ehid string,
+) (*liborgc.GetMemberHistoryResponseDto, error) {
+	return c.GetMemberNodesByEhid(ctx, ehid)
+}
+
+func (c *orgClientStub) GetMemberNodesByEhid(
+	ctx context.Context,
+	ehid string,
+) (*liborgc.GetMemberNodesResponseDto, error) {
+	if err := latency(ctx, c.Delay); err != nil {
+		c.Canceled = true
+		return nil, err
+	}
+	return &liborgc.GetMemberNodesResponseDto{Data: &[]liborgc.MembershipViewEntity{
+		{Id: 1, Ehid: ehid, StartDate: "2021-01-01", NodeId: "ENG"},
+	}}, nil
+}
+
+// GetNodeLineageById knows ENG under ACME.
+func (c *orgClientStub) GetNodeLineageById(
+	ctx context.Context,
+	nodeId string,
+) (*orgclient.GetNodeLineageResponseDto, error) {
+	if nodeId != "ENG" {
+		return nil, localerror.NewRemoteError("org", localerror.ErrSvcCodeRecordNotFound, "no node "+nodeId)
+	}
+	return &orgclient.GetNodeLineageResponseDto{Data: &orgclient.NodeTreeViewEntity{
+		Data: &orgclient.NodeViewEntity{Id: "ACME", Hierarchy: "ACME", Name: "Acme"},
+		Children: []orgclient.NodeTreeViewEntity{
+			{Data: &orgclient.NodeViewEntity{Id: "ENG", Hierarchy: "ACME.ENG", Name: "Engineering"}},
+		},
+	}}, nil
+}
+
+// The tree is ACME > ENG > BE, FE. u900 heads ACME, u001 ENG and u003 BE,
+// while FE has no officers.
+var (
+	nodes = map[string]orgclient.NodeViewEntity{
+		"ACME": {Id: "ACME", Hierarchy: "ACME"},
+		"ENG":  {Id: "ENG", Hierarchy: "ACME.ENG"},
+		"BE":   {Id: "BE", Hierarchy: "ACME.ENG.BE"},
+		"FE":   {Id: "FE", Hierarchy: "ACME.ENG.FE"},
+	}
+	officers = map[string][]orgclient.DesignationViewEntity{
+		"ACME": {{NodeId: "ACME", RoleId: "head", Ehid: "u900"}},
+		"ENG":  {{NodeId: "ENG", RoleId: "deputy", Ehid: "u002"}, {NodeId: "ENG", RoleId: "head", Ehid: "u001"}},
+		"BE":   {{NodeId: "BE", RoleId: "head", Ehid: "u003"}},
+	}
+	members = map[string][]string{
+		"ACME": {"u900"},
+		"ENG":  {"u001", "u002"},
+		"BE":   {"u003", "u004"},
+		"FE":   {"u005"},
+	}
+)
+
+func (c *orgClientStub) GetNodeById(ctx context.Context, nodeId string) (*orgclient.GetNodeResponseDto, error) {
+	node, exists := nodes[nodeId]
+	if !exists {
+		return nil, localerror.NewRemoteError("org", localerror.ErrSvcCodeRecordNotFound, "no node "+nodeId)
+	}
+	return &orgclient.GetNodeResponseDto{Data: &node}, nil
+}
+
+func (c *orgClientStub) GetNodeChildrenById(ctx context.Context, nodeId string) (*orgclient.GetNodeChildrenResponseDto, error) {
+	children := []orgclient.NodeViewEntity{}
+	for _, n := range nodes {
+		if n.Hierarchy == nodes[nodeId].Hierarchy+"."+n.Id {
+			children = append(children, n)
+		}
+	}
+	return &orgclient.GetNodeChildrenResponseDto{Data: &children}, nil
+}
+
+func (c *orgClientStub) GetNodeMembersById(ctx context.Context, nodeId string) (*orgclient.GetNodeMembersResponseDto, error) {
+	memberships := []liborgc.MembershipViewEntity{}
+	for _, ehid := range members[nodeId] {
+		memberships = append(memberships, liborgc.MembershipViewEntity{Ehid: ehid, NodeId: nodeId, StartDate: "2021-01-01"})
+	}
+	return &orgclient.GetNodeMembersResponseDto{Data: &memberships}, nil
+}
+
+func (c *orgClientStub) GetNodeOfficersById(ctx context.Context, nodeId string) (*orgclient.GetNodeOfficersResponseDto, error) {
+	if c.OfficersErr != nil {
+		return nil, c.OfficersErr
+	}
+	designations := officers[nodeId]
+	return &orgclient.GetNodeOfficersResponseDto{Data: &designations}, nil
+}
+
+func (c *orgClientStub) GetRoleById(ctx context.Context, roleId string) (*orgclient.GetRoleResponseDto, error) {
+	ranks := map[string]int{"head": 1, "deputy": 2}
+	return &orgclient.GetRoleResponseDto{Data: &orgclient.RoleViewEntity{Id: roleId, Rank: ranks[roleId]}}, nil
+}
+
+type authxClientStub struct {
+	Delay   time.Duration
+	Err     error
+	Unknown string
+}
+
+func (c *authxClientStub) GetProfileByEhid(
+	ctx context.Context,
+	ehid string,
+) (*libauthxc.GetProfileResponseDto, error) {
+	if err := latency(ctx, c.Delay); err != nil {
+		return nil, err
+	}
+	if c.Err != nil {
+		return nil, c.Err
+	}
+	if ehid == c.Unknown {
+		return nil, localerror.NewRemoteError("authx", localerror.ErrSvcCodeRecordNotFound, "no such profile")
+	}
+	return &libauthxc.GetProfileResponseDto{
+		Data: &libauthxc.ProfileEntity{EmployeeId: "1001", Name: "Jane Doe"},
+	}, nil
+}
+
+func newTestService(delay time.Duration, org *orgClientStub, authx *authxClientStub) *Service {
+	cfg := &config.Service{ConfigRepository: &config.RepositoryImpl{
+		BatchMaxSize:     3,
+		BatchConcurrency: 2,
+		CareerNodeTypes:  []string{"company", "division"},
+	}}
+	gs := grading.NewService(nil, &gradingRepositoryStub{Delay: delay})
+	ts := titling.NewService(nil, &titlingRepositoryStub{Delay: delay})
+	return NewService(
+		cfg,
+		career.NewService(cfg, gs, ts, org),
+		orgnode.NewService(cfg, gs, ts, org, authx),
+		authx,
+	)
+}
+
+func TestRetrieveProfile(t *testing.T) {
+	s := newTestService(0, &orgClientStub{}, &authxClientStub{})
+
+	agg, warnings, err := s.RetrieveProfile(context.Background(), "u001", profile.Query{}, false)
+	if err != nil || len(warnings) != 0 {
+		t.Fatal(err, warnings)
+	}
+	if agg.Name != "Jane Doe" || agg.Grade != "E5" || agg.Title != "Engineer" || agg.OrganizationNode != "ENG" {
+		t.Errorf("unexpected profile %+v", agg)
+	}
+}
+
+func TestRetrieveProfileExpandingOrg(t *testing.T) {
+	s := newTestService(0, &orgClientStub{}, &authxClientStub{})
+
+	agg, warnings, err := s.RetrieveProfile(context.Background(), "u001", profile.Query{ExpandOrg: true}, false)
+	if err != nil || len(warnings) != 0 {
+		t.Fatal(err, warnings)
+	}
+	expected := career.Node{
+		NodeRef: career.NodeRef{Id: "ENG", Name: "Engineering", Type: "division"},
+		Path:    []career.NodeRef{{Id: "ACME", Name: "Acme", Type: "company"}},
+	}
+	if agg.Org == nil || agg.Org.NodeRef != expected.NodeRef || len(agg.Org.Path) != 1 || agg.Org.Path[0] != expected.Path[0] {
+		t.Errorf("expected %+v, got %+v", expected, agg.Org)
+	}
+}
+
+func TestRetrieveProfileWithReportingLine(t *testing.T) {
+	s := newTestService(0, &orgClientStub{}, &authxClientStub{})
+
+	q := profile.Query{IncludeManagers: true, IncludeDirectReports: true}
+	agg, warnings, err := s.RetrieveProfile(context.Background(), "u001", q, false)
+	if err != nil || len(warnings) != 0 {
+		t.Fatal(err, warnings)
+	}
+	expected := []orgnode.PersonViewEntity{{Ehid: "u900", Name: "Jane Doe", NodeId: "ACME", Grade: "E5", Title: "Engineer"}}
+	if !slices.Equal(agg.Managers, expected) {
+		t.Errorf("expected managers %+v, got %+v", expected, agg.Managers)
+	}
+	reports := []string{}
+	for _, p := range agg.DirectReports {
+		reports = append(reports, p.Ehid+"@"+p.NodeId)
+	}
+	if !slices.Equal(reports, []string{"u002@ENG", "u003@BE", "u005@FE"}) {
+		t.Errorf("unexpected direct reports %v", reports)
+	}
+
+	agg, _, err = s.RetrieveProfile(context.Background(), "u001", profile.Query{}, false)
+	if err != nil || agg.Managers != nil || agg.DirectReports != nil {
+		t.Errorf("expected no reporting line unless asked, got %+v, %v", agg, err)
+	}
+}
+
+func TestRetrieveProfileWithReportingLineDegraded(t *testing.T) {
+	org := &orgClientStub{OfficersErr: fmt.Errorf("%w: org down", localerror.ErrHttpClient)}
+	s := newTestService(0, org, &authxClientStub{})
+
+	q := profile.Query{IncludeManagers: true, IncludeDirectReports: true}
+	_, _, err := s.RetrieveProfile(context.Background(), "u001", q, false)
+	if !errors.Is(err, localerror.ErrHttpClient) {
+		t.Fatalf("expected strict mode to fail, got %v", err)
+	}
+
+	agg, warnings, err := s.RetrieveProfile(context.Background(), "u001", q, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if agg.Grade != "E5" || agg.Managers != nil || agg.DirectReports != nil {
+		t.Errorf("unexpected profile %+v", agg)
+	}
+	if len(warnings) != 1 || warnings[0].Source != localerror.WarningSourceOrg {
+		t.Errorf("expected one org warning, got %+v", warnings)
+	}
+}
+
+func TestRetrieveProfileDegraded(t *testing.T) {
+	s := newTestService(0, &orgClientStub{}, &authxClientStub{Err: fmt.Errorf("%w: authx down", localerror.ErrHttpClient)})
+
+	agg, warnings, err := s.RetrieveProfile(context.Background(), "u001", profile.Query{}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if agg.Name != "" || agg.Grade != "E5" {
+		t.Errorf("unexpected profile %+v", agg)
+	}
+	if len(warnings) != 1 || warnings[0].Source != localerror.WarningSourceAuthx {
+		t.Errorf("unexpected warnings %+v", warnings)
+	}
+}
+
+func TestRetrieveProfileCancelsOnFirstError(t *testing.T) {
+	failure := localerror.NewRemoteError("authx", localerror.ErrSvcCodeRecordNotFound, "no such profile")
+	org := &orgClientStub{Delay: time.Second}
+	s := newTestService(0, org, &authxClientStub{Err: failure})
+
+	start := time.Now()
+	_, _, err := s.RetrieveProfile(context.Background(), "u001", profile.Query{}, true)
+	if !errors.Is(err, localerror.ErrRemoteNotFound) {
+		t.Fatalf("expected %v, got %v", localerror.ErrRemoteNotFound, err)
+	}
+	if !org.Canceled || time.Since(start) >= time.Second {
+		t.Errorf("expected the org lookup to be canceled, took %s", time.Since(start))
+	}
+}
+
+func TestRetrieveProfiles(t *testing.T) {
+	s := newTestService(0, &orgClientStub{}, &authxClientStub{Unknown: "u002"})
+
+	results, err := s.RetrieveProfiles(context.Background(), []string{"u001", "u002", "u001", ""}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("expected one result per distinct EHID, got %+v", results)
+	}
+	if results[0].Ehid != "u001" || results[0].Err != nil || results[0].Data.Name != "Jane Doe" || results[0].Data.Grade != "E5" {
+		t.Errorf("unexpected result %+v", results[0])
+	}
+	if results[1].Ehid != "u002" || !errors.Is(results[1].Err, localerror.ErrRemoteNotFound) || results[1].Data != nil {
+		t.Errorf("expected u002 to fail alone, got %+v", results[1])
+	}
+}
+
+func TestRetrieveProfilesBatchSize(t *testing.T) {
+	s := newTestService(0, &orgClientStub{}, &authxClientStub{})
+
+	for _, ehids := range [][]string{
+		{},
+		{""},
+		{"u001", "u002", "u003", "u004"},
+	} {
+		_, err := s.RetrieveProfiles(context.Background(), ehids, false)
+		if !errors.Is(err, localerror.ErrBadBatchSize) {
+			t.Errorf("expected %v for %v, got %v", localerror.ErrBadBatchSize, ehids, err)
+		}
+	}
+}
+
+// BenchmarkRetrieveProfile gives every dependency the same latency. Looked up
+// one after another, the profile took four times that latency; concurrently
+// it takes about one.
+func BenchmarkRetrieveProfile(b *testing.B) {
+	delay := 5 * time.Millisecond
+	s := newTestService(delay, &orgClientStub{Delay: delay}, &authxClientStub{Delay: delay})
+	ctx := context.Background()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _, err := s.RetrieveProfile(ctx, "u001", profile.Query{}, false)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
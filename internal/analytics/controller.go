@@ -0,0 +1,110 @@
+package analytics
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/mrexmelle/connect-emp/internal/config"
+	"github.com/mrexmelle/connect-emp/internal/datestr"
+	"github.com/mrexmelle/connect-emp/internal/dto/dtorespwithdata"
+	"github.com/mrexmelle/connect-emp/internal/localerror"
+)
+
+type Controller struct {
+	ConfigService     *config.Service
+	LocalErrorService *localerror.Service
+	AnalyticsService  *Service
+}
+
+func NewController(cfg *config.Service, les *localerror.Service, svc *Service) *Controller {
+	return &Controller{
+		ConfigService:     cfg,
+		LocalErrorService: les,
+		AnalyticsService:  svc,
+	}
+}
+
+// Get Headcount : HTTP endpoint to get the headcount over time
+// @Tags Analytics
+// @Description Count employees on the first day of every interval, grouped by grade, title or organization node, optionally within the subtree of a node
+// @Produce json
+// @Param group_by query string false "grade (default), title or org_node"
+// @Param from query string false "Date in YYYY-MM-DD, a year before to by default"
+// @Param to query string false "Date in YYYY-MM-DD, today by default"
+// @Param interval query string false "month (default), quarter or year"
+// @Param node_id query string false "Only count the members of this node and its descendants"
+// @Success 200 {object} GetHeadcountResponseDto "Success Response"
+// @Failure 400 "BadRequest"
+// @Failure 404 "NotFound"
+// @Failure 500 "InternalServerError"
+// @Failure 502 "BadGateway"
+// @Router /analytics/headcount [GET]
+func (c *Controller) GetHeadcount(w http.ResponseWriter, r *http.Request) {
+	values := r.URL.Query()
+	q := HeadcountQuery{
+		GroupBy:  values.Get("group_by"),
+		From:     values.Get("from"),
+		To:       values.Get("to"),
+		Interval: values.Get("interval"),
+		NodeId:   values.Get("node_id"),
+	}
+	if q.GroupBy == "" {
+		q.GroupBy = GroupByGrade
+	}
+	if q.Interval == "" {
+		q.Interval = IntervalMonth
+	}
+	q.From, q.To = defaultPeriod(q.From, q.To)
+
+	data, err := c.AnalyticsService.RetrieveHeadcount(r.Context(), q)
+	info := c.LocalErrorService.Map(err)
+	dtorespwithdata.New(
+		data,
+		info.ServiceErrorCode,
+		info.ServiceErrorMessage,
+	).RenderTo(w, info.HttpStatusCode)
+}
+
+// Get Promotion Velocity : HTTP endpoint to get the time spent in each grade before a change
+// @Tags Analytics
+// @Description Describe, grade by grade, how many days employees held a grade before changing straight to another one from from to to, optionally within the subtree of a node
+// @Produce json
+// @Param from query string false "Date in YYYY-MM-DD, a year before to by default"
+// @Param to query string false "Date in YYYY-MM-DD, today by default"
+// @Param node_id query string false "Only count the changes of members of this node and its descendants"
+// @Success 200 {object} GetPromotionVelocityResponseDto "Success Response"
+// @Failure 400 "BadRequest"
+// @Failure 404 "NotFound"
+// @Failure 500 "InternalServerError"
+// @Failure 502 "BadGateway"
+// @Router /analytics/promotion-velocity [GET]
+func (c *Controller) GetPromotionVelocity(w http.ResponseWriter, r *http.Request) {
+	values := r.URL.Query()
+	q := PromotionVelocityQuery{
+		NodeId: values.Get("node_id"),
+	}
+	q.From, q.To = defaultPeriod(values.Get("from"), values.Get("to"))
+
+	data, err := c.AnalyticsService.RetrievePromotionVelocity(r.Context(), q)
+	info := c.LocalErrorService.Map(err)
+	dtorespwithdata.New(
+		data,
+		info.ServiceErrorCode,
+		info.ServiceErrorMessage,
+	).RenderTo(w, info.HttpStatusCode)
+}
+
+// defaultPeriod ends a missing to today and starts a missing from a year
+// before to.
+func defaultPeriod(from string, to string) (string, string) {
+	if to == "" {
+		to = time.Now().Format(datestr.FormatDefault)
+	}
+	if from == "" {
+		t, err := time.Parse(datestr.FormatDefault, to)
+		if err == nil {
+			from = t.AddDate(-1, 0, 0).Format(datestr.FormatDefault)
+		}
+	}
+	return from, to
+}
@@ -0,0 +1,8 @@
+package analytics
+
+import (
+	"github.com/mrexmelle/connect-emp/internal/dto/dtorespwithdata"
+)
+
+type GetHeadcountResponseDto = dtorespwithdata.Class[HeadcountViewEntity]
+type GetPromotionVelocityResponseDto = dtorespwithdata.Class[PromotionVelocityViewEntity]
@@ -0,0 +1,52 @@
+package analytics
+
+// HeadcountEntity is one row of a headcount time series.
+type HeadcountEntity struct {
+	Period    string
+	GroupKey  string
+	Headcount int
+}
+
+// MembershipEntity is a membership handed over to the database as JSON, so
+// that memberships owned by connect-org can be joined with gradings and
+// titlings. An open-ended membership has no end_date.
+type MembershipEntity struct {
+	Ehid      string `json:"ehid"`
+	NodeId    string `json:"node_id"`
+	StartDate string `json:"start_date"`
+	EndDate   string `json:"end_date,omitempty"`
+}
+
+type HeadcountPointViewEntity struct {
+	Period    string         `json:"period"`
+	Headcount map[string]int `json:"headcount"`
+}
+
+type HeadcountViewEntity struct {
+	GroupBy  string                     `json:"group_by"`
+	From     string                     `json:"from"`
+	To       string                     `json:"to"`
+	Interval string                     `json:"interval"`
+	NodeId   string                     `json:"node_id,omitempty"`
+	Series   []HeadcountPointViewEntity `json:"series"`
+}
+
+// GradeVelocityViewEntity describes how many days employees spent in a grade
+// before it changed. Percentiles are nearest-rank.
+type GradeVelocityViewEntity struct {
+	Grade      string  `json:"grade"`
+	Changes    int     `json:"changes"`
+	MinDays    int     `json:"min_days"`
+	P25Days    int     `json:"p25_days"`
+	MedianDays int     `json:"median_days"`
+	P75Days    int     `json:"p75_days"`
+	MaxDays    int     `json:"max_days"`
+	MeanDays   float64 `json:"mean_days"`
+}
+
+type PromotionVelocityViewEntity struct {
+	From   string                    `json:"from"`
+	To     string                    `json:"to"`
+	NodeId string                    `json:"node_id,omitempty"`
+	Grades []GradeVelocityViewEntity `json:"grades"`
+}
@@ -0,0 +1,48 @@
+package analytics
+
+const (
+	GroupByGrade   = "grade"
+	GroupByTitle   = "title"
+	GroupByOrgNode = "org_node"
+
+	IntervalMonth   = "month"
+	IntervalQuarter = "quarter"
+	IntervalYear    = "year"
+
+	// MaxPeriods bounds the length of a time series.
+	MaxPeriods = 240
+)
+
+var (
+	GroupBys = []string{
+		GroupByGrade,
+		GroupByTitle,
+		GroupByOrgNode,
+	}
+
+	// Intervals maps every interval to its PostgreSQL interval literal.
+	Intervals = map[string]string{
+		IntervalMonth:   "1 month",
+		IntervalQuarter: "3 months",
+		IntervalYear:    "1 year",
+	}
+)
+
+// HeadcountQuery selects a headcount time series. Employees are counted on
+// the first day of every interval from From to To. An empty NodeId counts
+// the whole organization.
+type HeadcountQuery struct {
+	GroupBy  string
+	From     string
+	To       string
+	Interval string
+	NodeId   string
+}
+
+// PromotionVelocityQuery selects the grade changes from From to To. An empty
+// NodeId covers the whole organization.
+type PromotionVelocityQuery struct {
+	From   string
+	To     string
+	NodeId string
+}
@@ -0,0 +1,96 @@
+package analytics
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/mrexmelle/connect-emp/internal/config"
+)
+
+const (
+	periodSeries = "generate_series(date_trunc(?, ?::timestamp), ?::timestamp, ?::interval) AS p(period)"
+
+	membershipRecords = "jsonb_to_recordset(?::jsonb) " +
+		"AS m(ehid text, node_id text, start_date date, end_date date)"
+
+	heldOnPeriod = "start_date <= p.period AND (%[1]s.end_date IS NULL OR %[1]s.end_date >= p.period)"
+)
+
+type Repository interface {
+	CountHeadcount(ctx context.Context, q HeadcountQuery, memberships []MembershipEntity) ([]HeadcountEntity, error)
+}
+
+type RepositoryImpl struct {
+	ConfigService *config.Service
+}
+
+func NewRepository(cfg *config.Service) Repository {
+	return &RepositoryImpl{
+		ConfigService: cfg,
+	}
+}
+
+// CountHeadcount counts distinct EHIDs per period and group in a single
+// statement. Periods come from generate_series, so that every period is
+// returned, with an empty group key when nobody is counted. Grades and
+// titles are read from their tables. Memberships are passed in as JSON:
+// they are the groups of GroupByOrgNode and otherwise, unless nil, restrict
+// the count to the EHIDs that hold one of them on each period.
+func (r *RepositoryImpl) CountHeadcount(
+	ctx context.Context,
+	q HeadcountQuery,
+	memberships []MembershipEntity,
+) ([]HeadcountEntity, error) {
+	args := []any{q.Interval, q.From, q.To, Intervals[q.Interval]}
+
+	var sql string
+	if q.GroupBy == GroupByOrgNode {
+		raw, err := json.Marshal(memberships)
+		if err != nil {
+			return []HeadcountEntity{}, err
+		}
+		sql = "SELECT to_char(p.period, 'YYYY-MM-DD') AS period, " +
+			"COALESCE(m.node_id, '') AS group_key, " +
+			"COUNT(DISTINCT m.ehid) AS headcount " +
+			"FROM " + periodSeries + " " +
+			"LEFT JOIN " + membershipRecords + " " +
+			"ON m." + held("m") + " " +
+			"GROUP BY p.period, m.node_id " +
+			"ORDER BY p.period, m.node_id"
+		args = append(args, string(raw))
+	} else {
+		table, column := "gradings", "grade"
+		if q.GroupBy == GroupByTitle {
+			table, column = "titlings", "title"
+		}
+		sql = "SELECT to_char(p.period, 'YYYY-MM-DD') AS period, " +
+			"COALESCE(r." + column + ", '') AS group_key, " +
+			"COUNT(DISTINCT r.ehid) AS headcount " +
+			"FROM " + periodSeries + " " +
+			"LEFT JOIN " + table + " r " +
+			"ON r." + held("r")
+		if memberships != nil {
+			raw, err := json.Marshal(memberships)
+			if err != nil {
+				return []HeadcountEntity{}, err
+			}
+			sql += " AND EXISTS (SELECT 1 FROM " + membershipRecords + " " +
+				"WHERE m.ehid = r.ehid AND m." + held("m") + ")"
+			args = append(args, string(raw))
+		}
+		sql += " GROUP BY p.period, r." + column +
+			" ORDER BY p.period, r." + column
+	}
+
+	response := []HeadcountEntity{}
+	result := r.ConfigService.ReadDb.WithContext(ctx).Raw(sql, args...).Scan(&response)
+	if result.Error != nil {
+		return []HeadcountEntity{}, result.Error
+	}
+	return response, nil
+}
+
+func held(alias string) string {
+	return fmt.Sprintf(heldOnPeriod, alias)
+}
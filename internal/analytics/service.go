@@ -0,0 +1,307 @@
+package analytics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/mrexmelle/connect-emp/internal/batch"
+	"github.com/mrexmelle/connect-emp/internal/config"
+	"github.com/mrexmelle/connect-emp/internal/dateinterval"
+	"github.com/mrexmelle/connect-emp/internal/datestr"
+	"github.com/mrexmelle/connect-emp/internal/dto"
+	"github.com/mrexmelle/connect-emp/internal/grading"
+	"github.com/mrexmelle/connect-emp/internal/localerror"
+	"github.com/mrexmelle/connect-emp/internal/orgclient"
+	"github.com/mrexmelle/connect-emp/internal/orgnode"
+	"github.com/mrexmelle/connect-org/pkg/liborgc"
+)
+
+type Service struct {
+	ConfigService       *config.Service
+	AnalyticsRepository Repository
+	GradingService      *grading.Service
+	OrgNodeService      *orgnode.Service
+	OrgClient           orgclient.Client
+}
+
+func NewService(
+	cfg *config.Service,
+	r Repository,
+	gs *grading.Service,
+	ons *orgnode.Service,
+	oc orgclient.Client,
+) *Service {
+	return &Service{
+		ConfigService:       cfg,
+		AnalyticsRepository: r,
+		GradingService:      gs,
+		OrgNodeService:      ons,
+		OrgClient:           oc,
+	}
+}
+
+// RetrieveHeadcount counts employees on the first day of every interval from
+// q.From to q.To, grouped by grade, title or organization node. Grouping by
+// node, or filtering on the subtree of q.NodeId, needs the memberships of
+// everyone graded in that time, which are looked up in connect-org.
+func (s *Service) RetrieveHeadcount(ctx context.Context, q HeadcountQuery) (*HeadcountViewEntity, error) {
+	err := s.checkHeadcountQuery(q)
+	if err != nil {
+		return nil, err
+	}
+
+	var memberships []MembershipEntity
+	if q.GroupBy == GroupByOrgNode || q.NodeId != "" {
+		memberships, err = s.retrieveMemberships(ctx, q.From, q.To, q.NodeId)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	rows, err := s.AnalyticsRepository.CountHeadcount(ctx, q, memberships)
+	if err != nil {
+		return nil, err
+	}
+
+	view := &HeadcountViewEntity{
+		GroupBy:  q.GroupBy,
+		From:     q.From,
+		To:       q.To,
+		Interval: q.Interval,
+		NodeId:   q.NodeId,
+		Series:   []HeadcountPointViewEntity{},
+	}
+	for _, row := range rows {
+		last := len(view.Series) - 1
+		if last < 0 || view.Series[last].Period != row.Period {
+			view.Series = append(view.Series, HeadcountPointViewEntity{
+				Period:    row.Period,
+				Headcount: map[string]int{},
+			})
+			last++
+		}
+		if row.GroupKey != "" {
+			view.Series[last].Headcount[row.GroupKey] = row.Headcount
+		}
+	}
+	return view, nil
+}
+
+// RetrievePromotionVelocity describes, grade by grade, how long employees
+// held a grade before moving straight to another one from q.From to q.To.
+// Adjacent gradings with the same grade count as one, and a gap between
+// gradings is not a change. When q.NodeId is set, only the changes of members
+// of its subtree on the day of the change count.
+func (s *Service) RetrievePromotionVelocity(
+	ctx context.Context,
+	q PromotionVelocityQuery,
+) (*PromotionVelocityViewEntity, error) {
+	_, _, err := s.checkPeriod(q.From, q.To)
+	if err != nil {
+		return nil, err
+	}
+	period, err := dateinterval.NewFromStrings(q.From, q.To)
+	if err != nil {
+		return nil, err
+	}
+
+	var memberships map[string][]*dateinterval.Class
+	if q.NodeId != "" {
+		entities, err := s.retrieveMemberships(ctx, q.From, q.To, q.NodeId)
+		if err != nil {
+			return nil, err
+		}
+		memberships = map[string][]*dateinterval.Class{}
+		for _, m := range entities {
+			interval, err := dateinterval.NewFromStrings(m.StartDate, m.EndDate)
+			if err != nil {
+				return nil, err
+			}
+			memberships[m.Ehid] = append(memberships[m.Ehid], interval)
+		}
+	}
+
+	ehids, err := s.GradingService.RetrieveEhidsActiveBetween(ctx, q.From, q.To)
+	if err != nil {
+		return nil, err
+	}
+	histories, err := s.GradingService.RetrieveByEhidsOrderByStartDate(ctx, ehids, grading.OrderAsc)
+	if err != nil {
+		return nil, err
+	}
+
+	days := map[string][]int{}
+	for ehid, history := range histories {
+		spans := []dateinterval.Span{}
+		for _, g := range history {
+			interval, err := dateinterval.NewFromStrings(g.StartDate, g.EndDate)
+			if err != nil {
+				return nil, err
+			}
+			spans = append(spans, dateinterval.Span{Class: interval, Value: g.Grade})
+		}
+		spans = dateinterval.MergeSpans(spans)
+
+		for i := 0; i+1 < len(spans); i++ {
+			changedOn := spans[i+1].StartDate
+			if !spans[i].IsFollowedBy(spans[i+1].Class) || !period.IsEncompassingDate(changedOn) {
+				continue
+			}
+			if memberships != nil && !slices.ContainsFunc(memberships[ehid], func(m *dateinterval.Class) bool {
+				return m.IsEncompassingDate(changedOn)
+			}) {
+				continue
+			}
+			days[spans[i].Value] = append(days[spans[i].Value], spans[i].DaysUntil(spans[i].EndDate))
+		}
+	}
+
+	view := &PromotionVelocityViewEntity{
+		From:   q.From,
+		To:     q.To,
+		NodeId: q.NodeId,
+		Grades: []GradeVelocityViewEntity{},
+	}
+	for grade, d := range days {
+		slices.Sort(d)
+		sum := 0
+		for _, n := range d {
+			sum += n
+		}
+		view.Grades = append(view.Grades, GradeVelocityViewEntity{
+			Grade:      grade,
+			Changes:    len(d),
+			MinDays:    d[0],
+			P25Days:    percentile(d, 25),
+			MedianDays: percentile(d, 50),
+			P75Days:    percentile(d, 75),
+			MaxDays:    d[len(d)-1],
+			MeanDays:   float64(sum) / float64(len(d)),
+		})
+	}
+	slices.SortFunc(view.Grades, func(a, b GradeVelocityViewEntity) int {
+		return strings.Compare(a.Grade, b.Grade)
+	})
+	return view, nil
+}
+
+func (s *Service) checkHeadcountQuery(q HeadcountQuery) error {
+	if !slices.Contains(GroupBys, q.GroupBy) {
+		return fmt.Errorf("%w: group_by must be one of %v, got %q", localerror.ErrBadQueryParam, GroupBys, q.GroupBy)
+	}
+	if _, ok := Intervals[q.Interval]; !ok {
+		return fmt.Errorf("%w: unknown interval %q", localerror.ErrBadQueryParam, q.Interval)
+	}
+
+	from, to, err := s.checkPeriod(q.From, q.To)
+	if err != nil {
+		return err
+	}
+
+	months := (to.Year()-from.Year())*12 + int(to.Month()-from.Month())
+	periods := map[string]int{
+		IntervalMonth:   months,
+		IntervalQuarter: months / 3,
+		IntervalYear:    months / 12,
+	}[q.Interval] + 1
+	if periods > MaxPeriods {
+		return fmt.Errorf("%w: %d periods requested, at most %d allowed", localerror.ErrBadQueryParam, periods, MaxPeriods)
+	}
+	return nil
+}
+
+func (s *Service) checkPeriod(from string, to string) (time.Time, time.Time, error) {
+	f, err := time.Parse(datestr.FormatDefault, from)
+	if err != nil {
+		return f, f, fmt.Errorf("%w: from: %w", localerror.ErrBadQueryParam, err)
+	}
+	t, err := time.Parse(datestr.FormatDefault, to)
+	if err != nil {
+		return f, t, fmt.Errorf("%w: to: %w", localerror.ErrBadQueryParam, err)
+	}
+	if f.After(t) {
+		return f, t, fmt.Errorf("%w: from is after to", localerror.ErrBadDateSequence)
+	}
+	return f, t, nil
+}
+
+// retrieveMemberships lists the memberships overlapping from and to of the
+// EHIDs graded in that time, restricted to the subtree of nodeId when it is
+// set.
+func (s *Service) retrieveMemberships(
+	ctx context.Context,
+	from string,
+	to string,
+	nodeId string,
+) ([]MembershipEntity, error) {
+	var nodeIds []string
+	if nodeId != "" {
+		var err error
+		nodeIds, err = s.OrgNodeService.RetrieveSubtree(ctx, nodeId)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	period, err := dateinterval.NewFromStrings(from, to)
+	if err != nil {
+		return nil, err
+	}
+	ehids, err := s.GradingService.RetrieveEhidsActiveBetween(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	histories := batch.Map(ctx, ehids, s.ConfigService.ConfigRepository.GetBatchConcurrency(), func(
+		ctx context.Context,
+		ehid string,
+	) (*[]liborgc.MembershipViewEntity, []dto.Warning, error) {
+		h, err := s.OrgClient.GetMemberHistoryByEhidOrderByStartDateDesc(ctx, ehid)
+		if errors.Is(err, localerror.ErrRemoteNotFound) {
+			return &[]liborgc.MembershipViewEntity{}, nil, nil
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		return h.Data, nil, nil
+	})
+
+	memberships := []MembershipEntity{}
+	for _, h := range histories {
+		if h.Err != nil {
+			return nil, h.Err
+		}
+		for _, m := range *h.Data {
+			if nodeIds != nil && !slices.Contains(nodeIds, m.NodeId) {
+				continue
+			}
+			interval, err := dateinterval.NewFromStrings(m.StartDate, m.EndDate)
+			if err != nil {
+				return nil, err
+			}
+			if interval.StartDate.IsAfter(period.EndDate) || interval.EndDate.IsBefore(period.StartDate) {
+				continue
+			}
+			memberships = append(memberships, MembershipEntity{
+				Ehid:      m.Ehid,
+				NodeId:    m.NodeId,
+				StartDate: m.StartDate,
+				EndDate:   m.EndDate,
+			})
+		}
+	}
+	return memberships, nil
+}
+
+// percentile picks the nearest-rank p-th percentile of sorted values.
+func percentile(sorted []int, p int) int {
+	rank := (p*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
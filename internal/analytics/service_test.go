@@ -0,0 +1,215 @@
+package analytics
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mrexmelle/connect-emp/internal/config"
+	"github.com/mrexmelle/connect-emp/internal/grading"
+	"github.com/mrexmelle/connect-emp/internal/localerror"
+	"github.com/mrexmelle/connect-emp/internal/orgclient"
+	"github.com/mrexmelle/connect-emp/internal/orgnode"
+	"github.com/mrexmelle/connect-org/pkg/liborgc"
+)
+
+type repositoryStub struct {
+	Rows        []HeadcountEntity
+	Memberships []MembershipEntity
+}
+
+func (r *repositoryStub) CountHeadcount(
+	ctx context.Context,
+	q HeadcountQuery,
+	memberships []MembershipEntity,
+) ([]HeadcountEntity, error) {
+	r.Memberships = memberships
+	return r.Rows, nil
+}
+
+type gradingRepositoryStub struct {
+	grading.Repository
+	Entities []grading.Entity
+}
+
+func (r *gradingRepositoryStub) FindEhidsActiveBetween(ctx context.Context, startDate string, endDate string) ([]string, error) {
+	return []string{"u001", "u002", "u003"}, nil
+}
+
+func (r *gradingRepositoryStub) FindByEhidsOrderByStartDate(ctx context.Context, ehids []string, orderDir string) ([]grading.Entity, error) {
+	return r.Entities, nil
+}
+
+type orgClientStub struct {
+	orgclient.Client
+}
+
+func (c *orgClientStub) GetNodeById(ctx context.Context, nodeId string) (*orgclient.GetNodeResponseDto, error) {
+	return &orgclient.GetNodeResponseDto{Data: &orgclient.NodeViewEntity{Id: nodeId}}, nil
+}
+
+func (c *orgClientStub) GetNodeChildrenById(ctx context.Context, nodeId string) (*orgclient.GetNodeChildrenResponseDto, error) {
+	nodes := []orgclient.NodeViewEntity{}
+	if nodeId == "ENG" {
+		nodes = append(nodes, orgclient.NodeViewEntity{Id: "BE"})
+	}
+	return &orgclient.GetNodeChildrenResponseDto{Data: &nodes}, nil
+}
+
+func (c *orgClientStub) GetMemberHistoryByEhidOrderByStartDateDesc(
+	ctx context.Context,
+	ehid string,
+) (*liborgc.GetMemberHistoryResponseDto, error) {
+	history := map[string][]liborgc.MembershipViewEntity{
+		"u001": {
+			{Ehid: "u001", NodeId: "BE", StartDate: "2024-03-01"},
+			{Ehid: "u001", NodeId: "HR", StartDate: "2020-01-01", EndDate: "2024-02-29"},
+		},
+		"u002": {
+			{Ehid: "u002", NodeId: "ENG", StartDate: "2019-01-01", EndDate: "2023-01-31"},
+		},
+	}[ehid]
+	if history == nil {
+		return nil, localerror.NewRemoteError("org", localerror.ErrSvcCodeRecordNotFound, "no member")
+	}
+	return &liborgc.GetMemberHistoryResponseDto{Data: &history}, nil
+}
+
+func date(s string) time.Time {
+	t, _ := time.Parse("2006-01-02", s)
+	return t
+}
+
+func endDate(s string) sql.NullTime {
+	if s == "" {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: date(s), Valid: true}
+}
+
+func newTestService(r *repositoryStub) *Service {
+	cfg := &config.Service{ConfigRepository: &config.RepositoryImpl{BatchConcurrency: 2}}
+	org := &orgClientStub{}
+	gs := grading.NewService(nil, &gradingRepositoryStub{Entities: []grading.Entity{
+		{Ehid: "u001", StartDate: date("2020-01-01"), EndDate: endDate("2021-12-31"), Grade: "E3"},
+		{Ehid: "u001", StartDate: date("2022-01-01"), EndDate: endDate("2023-06-30"), Grade: "E4"},
+		{Ehid: "u001", StartDate: date("2023-07-01"), Grade: "E5"},
+		{Ehid: "u002", StartDate: date("2021-01-01"), EndDate: endDate("2021-12-31"), Grade: "E3"},
+		{Ehid: "u002", StartDate: date("2022-01-01"), EndDate: endDate("2022-12-31"), Grade: "E3"},
+		{Ehid: "u002", StartDate: date("2023-01-01"), Grade: "E4"},
+		{Ehid: "u003", StartDate: date("2020-01-01"), EndDate: endDate("2020-12-31"), Grade: "E3"},
+		{Ehid: "u003", StartDate: date("2022-06-01"), Grade: "E4"},
+	}})
+	return NewService(cfg, r, gs, orgnode.NewService(cfg, gs, nil, org, nil), org)
+}
+
+func TestRetrieveHeadcount(t *testing.T) {
+	r := &repositoryStub{Rows: []HeadcountEntity{
+		{Period: "2024-01-01", GroupKey: "E4", Headcount: 2},
+		{Period: "2024-01-01", GroupKey: "E5", Headcount: 1},
+		{Period: "2024-02-01", GroupKey: "", Headcount: 0},
+	}}
+	s := newTestService(r)
+
+	view, err := s.RetrieveHeadcount(context.Background(), HeadcountQuery{
+		GroupBy:  GroupByGrade,
+		From:     "2024-01-01",
+		To:       "2024-02-15",
+		Interval: IntervalMonth,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Memberships != nil {
+		t.Errorf("expected no membership lookup, got %+v", r.Memberships)
+	}
+	if len(view.Series) != 2 || view.Series[0].Headcount["E4"] != 2 || view.Series[0].Headcount["E5"] != 1 || len(view.Series[1].Headcount) != 0 {
+		t.Errorf("unexpected series %+v", view.Series)
+	}
+}
+
+func TestRetrieveHeadcountInSubtree(t *testing.T) {
+	r := &repositoryStub{}
+	s := newTestService(r)
+
+	_, err := s.RetrieveHeadcount(context.Background(), HeadcountQuery{
+		GroupBy:  GroupByTitle,
+		From:     "2023-01-01",
+		To:       "2024-12-31",
+		Interval: IntervalQuarter,
+		NodeId:   "ENG",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r.Memberships) != 2 || r.Memberships[0].Ehid != "u001" || r.Memberships[0].NodeId != "BE" || r.Memberships[1].Ehid != "u002" {
+		t.Errorf("expected the memberships in the subtree overlapping the dates, got %+v", r.Memberships)
+	}
+}
+
+func TestRetrieveHeadcountBadQuery(t *testing.T) {
+	s := newTestService(&repositoryStub{})
+
+	for _, c := range []struct {
+		q        HeadcountQuery
+		expected error
+	}{
+		{HeadcountQuery{GroupBy: "level", From: "2024-01-01", To: "2024-12-31", Interval: IntervalMonth}, localerror.ErrBadQueryParam},
+		{HeadcountQuery{GroupBy: GroupByGrade, From: "2024-01-01", To: "2024-12-31", Interval: "week"}, localerror.ErrBadQueryParam},
+		{HeadcountQuery{GroupBy: GroupByGrade, From: "2024-13-01", To: "2024-12-31", Interval: IntervalMonth}, localerror.ErrBadQueryParam},
+		{HeadcountQuery{GroupBy: GroupByGrade, From: "2024-12-31", To: "2024-01-01", Interval: IntervalMonth}, localerror.ErrBadDateSequence},
+		{HeadcountQuery{GroupBy: GroupByGrade, From: "1990-01-01", To: "2024-12-31", Interval: IntervalMonth}, localerror.ErrBadQueryParam},
+	} {
+		_, err := s.RetrieveHeadcount(context.Background(), c.q)
+		if !errors.Is(err, c.expected) {
+			t.Errorf("expected %v for %+v, got %v", c.expected, c.q, err)
+		}
+	}
+}
+
+func TestRetrievePromotionVelocity(t *testing.T) {
+	s := newTestService(&repositoryStub{})
+
+	view, err := s.RetrievePromotionVelocity(context.Background(), PromotionVelocityQuery{
+		From: "2022-01-01",
+		To:   "2023-12-31",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []GradeVelocityViewEntity{
+		{Grade: "E3", Changes: 2, MinDays: 730, P25Days: 730, MedianDays: 730, P75Days: 731, MaxDays: 731, MeanDays: 730.5},
+		{Grade: "E4", Changes: 1, MinDays: 546, P25Days: 546, MedianDays: 546, P75Days: 546, MaxDays: 546, MeanDays: 546},
+	}
+	if len(view.Grades) != len(expected) || view.Grades[0] != expected[0] || view.Grades[1] != expected[1] {
+		t.Errorf("expected %+v, got %+v", expected, view.Grades)
+	}
+
+	view, err = s.RetrievePromotionVelocity(context.Background(), PromotionVelocityQuery{
+		From:   "2022-01-01",
+		To:     "2023-12-31",
+		NodeId: "ENG",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(view.Grades) != 1 || view.Grades[0].Grade != "E3" || view.Grades[0].Changes != 1 {
+		t.Errorf("expected the change of u002 in ENG only, got %+v", view.Grades)
+	}
+
+	_, err = s.RetrievePromotionVelocity(context.Background(), PromotionVelocityQuery{From: "2024-01-01", To: "2023-01-01"})
+	if !errors.Is(err, localerror.ErrBadDateSequence) {
+		t.Errorf("expected %v, got %v", localerror.ErrBadDateSequence, err)
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	sorted := []int{10, 20, 30, 40}
+	for p, expected := range map[int]int{0: 10, 25: 10, 50: 20, 75: 30, 100: 40} {
+		if got := percentile(sorted, p); got != expected {
+			t.Errorf("percentile(%v, %d) = %d, expected %d", sorted, p, got, expected)
+		}
+	}
+}
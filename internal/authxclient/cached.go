@@ -0,0 +1,35 @@
+package authxclient
+
+import (
+	"context"
+
+	"github.com/mrexmelle/connect-authx/pkg/libauthxc"
+	"github.com/mrexmelle/connect-emp/internal/cache"
+)
+
+// CachedClient serves lookups from cache before asking connect-authx.
+type CachedClient struct {
+	Client Client
+	Cache  *cache.Cache
+}
+
+func NewCachedClient(c Client, cc *cache.Cache) Client {
+	return &CachedClient{
+		Client: c,
+		Cache:  cc,
+	}
+}
+
+func (c *CachedClient) GetProfileByEhid(
+	ctx context.Context,
+	ehid string,
+) (*libauthxc.GetProfileResponseDto, error) {
+	return cache.GetOrLoad(
+		ctx,
+		c.Cache,
+		cache.Key(cache.SourceAuthx, ehid, "profile"),
+		func(ctx context.Context) (*libauthxc.GetProfileResponseDto, error) {
+			return c.Client.GetProfileByEhid(ctx, ehid)
+		},
+	)
+}
@@ -0,0 +1,65 @@
+package authxclient
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"github.com/mrexmelle/connect-authx/pkg/libauthxc"
+	"github.com/mrexmelle/connect-emp/internal/cache"
+	"github.com/mrexmelle/connect-emp/internal/config"
+	"github.com/mrexmelle/connect-emp/internal/httpclient"
+	"github.com/mrexmelle/connect-emp/internal/localerror"
+	"github.com/mrexmelle/connect-emp/internal/metrics"
+)
+
+// Client calls connect-authx. It speaks the same API as libauthxc.Client but
+// carries the caller's context, so that deadlines, cancellation and the
+// trace context reach the downstream service.
+type Client interface {
+	GetProfileByEhid(ctx context.Context, ehid string) (*libauthxc.GetProfileResponseDto, error)
+}
+
+type ClientImpl struct {
+	HttpClient *httpclient.Client
+}
+
+// NewClient returns a client whose lookups go through the cache of cs.
+func NewClient(cfg *config.Service, cs *cache.Service) Client {
+	return NewCachedClient(newClientImpl(cfg), cs.AuthxCache)
+}
+
+func newClientImpl(cfg *config.Service) *ClientImpl {
+	return &ClientImpl{
+		HttpClient: httpclient.NewClient(
+			metrics.ClientAuthx,
+			cfg.ConfigRepository.GetAuthxHost(),
+			cfg.ConfigRepository.GetAuthxPort(),
+			cfg.ConfigRepository.GetAuthxClientPolicy(),
+		),
+	}
+}
+
+func (c *ClientImpl) GetProfileByEhid(
+	ctx context.Context,
+	ehid string,
+) (*libauthxc.GetProfileResponseDto, error) {
+	data := libauthxc.GetProfileResponseDto{}
+	err := c.HttpClient.GetJson(
+		ctx,
+		"GetProfileByEhid",
+		fmt.Sprintf("/profiles/%s", url.PathEscape(ehid)),
+		&data,
+	)
+	if err != nil {
+		return nil, err
+	}
+	err = localerror.NewRemoteError(metrics.ClientAuthx, data.Error.Code, data.Error.Message)
+	if err != nil {
+		return nil, err
+	}
+	if data.Data == nil {
+		return nil, fmt.Errorf("%w: %s responded without a profile", localerror.ErrRemoteError, metrics.ClientAuthx)
+	}
+	return &data, nil
+}
@@ -0,0 +1,60 @@
+package batch
+
+import (
+	"context"
+
+	"github.com/mrexmelle/connect-emp/internal/dto"
+	"golang.org/x/sync/errgroup"
+)
+
+// Result is the outcome of one EHID of a batch. Err only fails that EHID,
+// never the other ones.
+type Result[T any] struct {
+	Ehid     string
+	Data     *T
+	Warnings []dto.Warning
+	Err      error
+}
+
+// Distinct drops blank and repeated EHIDs, keeping the order in which they
+// first appear.
+func Distinct(ehids []string) []string {
+	seen := map[string]bool{}
+	distinct := []string{}
+	for _, ehid := range ehids {
+		if ehid == "" || seen[ehid] {
+			continue
+		}
+		seen[ehid] = true
+		distinct = append(distinct, ehid)
+	}
+	return distinct
+}
+
+// Map calls fn for every EHID, running at most concurrency calls at once, and
+// returns the results in the order of ehids.
+func Map[T any](
+	ctx context.Context,
+	ehids []string,
+	concurrency int,
+	fn func(ctx context.Context, ehid string) (*T, []dto.Warning, error),
+) []Result[T] {
+	results := make([]Result[T], len(ehids))
+	eg := errgroup.Group{}
+	eg.SetLimit(concurrency)
+	for i, ehid := range ehids {
+		i, ehid := i, ehid
+		eg.Go(func() error {
+			data, warnings, err := fn(ctx, ehid)
+			results[i] = Result[T]{
+				Ehid:     ehid,
+				Data:     data,
+				Warnings: warnings,
+				Err:      err,
+			}
+			return nil
+		})
+	}
+	eg.Wait()
+	return results
+}
@@ -0,0 +1,64 @@
+package batch
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mrexmelle/connect-emp/internal/dto"
+)
+
+func TestDistinct(t *testing.T) {
+	got := Distinct([]string{"u002", "", "u001", "u002", "u003", "u001"})
+	expected := []string{"u002", "u001", "u003"}
+	if !slices.Equal(got, expected) {
+		t.Errorf("expected %v, got %v", expected, got)
+	}
+}
+
+func TestMap(t *testing.T) {
+	failure := errors.New("boom")
+	running, peak := int64(0), int64(0)
+
+	results := Map(context.Background(), []string{"u001", "u002", "u003", "u004", "u005"}, 2, func(
+		ctx context.Context,
+		ehid string,
+	) (*string, []dto.Warning, error) {
+		n := atomic.AddInt64(&running, 1)
+		defer atomic.AddInt64(&running, -1)
+		for {
+			p := atomic.LoadInt64(&peak)
+			if n <= p || atomic.CompareAndSwapInt64(&peak, p, n) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+
+		if ehid == "u003" {
+			return nil, nil, failure
+		}
+		name := "name of " + ehid
+		return &name, nil, nil
+	})
+
+	if peak > 2 {
+		t.Errorf("expected at most 2 calls at once, got %d", peak)
+	}
+	for i, r := range results {
+		if r.Ehid == "u003" {
+			if !errors.Is(r.Err, failure) || r.Data != nil {
+				t.Errorf("expected u003 to fail alone, got %+v", r)
+			}
+			continue
+		}
+		if r.Err != nil || *r.Data != "name of "+r.Ehid {
+			t.Errorf("unexpected result %d: %+v", i, r)
+		}
+	}
+	if results[0].Ehid != "u001" || results[4].Ehid != "u005" {
+		t.Errorf("expected results in request order, got %+v", results)
+	}
+}
@@ -0,0 +1,153 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/mrexmelle/connect-emp/internal/metrics"
+)
+
+// Cache fronts the lookups of one source. An entry younger than Ttl is
+// served as is. An entry older than Ttl but younger than Ttl+StaleTtl is
+// served too, while a single background load refreshes it. Anything older is
+// loaded on the caller's time. Failed loads are never cached.
+type Cache struct {
+	Store          Store
+	Source         string
+	Ttl            time.Duration
+	StaleTtl       time.Duration
+	RefreshTimeout time.Duration
+	Now            func() time.Time
+
+	mu         sync.Mutex
+	refreshing map[string]bool
+}
+
+func New(store Store, source string, ttl time.Duration, staleTtl time.Duration) *Cache {
+	return &Cache{
+		Store:          store,
+		Source:         source,
+		Ttl:            ttl,
+		StaleTtl:       staleTtl,
+		RefreshTimeout: 10 * time.Second,
+		Now:            time.Now,
+		refreshing:     map[string]bool{},
+	}
+}
+
+// Key builds the key of a lookup about an EHID, starting with EhidPrefix so
+// that the source and the EHID can be invalidated by prefix.
+func Key(source string, ehid string, kind string) string {
+	return EhidPrefix(source, ehid) + kind
+}
+
+// EhidPrefix starts the keys of an EHID. Keys name what they are about after
+// the source and escape its ID, so that an EHID never clashes with a node, a
+// role or another EHID.
+func EhidPrefix(source string, ehid string) string {
+	return source + ":ehid:" + url.QueryEscape(ehid) + ":"
+}
+
+// NodeKey builds the key of a lookup about an organization node. Such keys go
+// with the whole source.
+func NodeKey(source string, nodeId string, kind string) string {
+	return source + ":node:" + url.QueryEscape(nodeId) + ":" + kind
+}
+
+// RoleKey builds the key of a lookup about a role, see NodeKey.
+func RoleKey(source string, roleId string) string {
+	return source + ":role:" + url.QueryEscape(roleId)
+}
+
+func GetOrLoad[T any](
+	ctx context.Context,
+	c *Cache,
+	key string,
+	load func(ctx context.Context) (*T, error),
+) (*T, error) {
+	e, err := c.Store.Get(ctx, key)
+	if err != nil {
+		c.observe(ResultError)
+		log.Printf("cache %s: reading %s: %v", c.Source, key, err)
+		e = nil
+	}
+
+	if e != nil {
+		value := new(T)
+		err = json.Unmarshal(e.Value, value)
+		if err == nil {
+			age := c.Now().Sub(e.StoredAt)
+			if age < c.Ttl {
+				c.observe(ResultHit)
+				return value, nil
+			}
+			if age < c.Ttl+c.StaleTtl {
+				c.observe(ResultStale)
+				c.refresh(ctx, key, func(ctx context.Context) error {
+					_, err := loadAndStore(ctx, c, key, load)
+					return err
+				})
+				return value, nil
+			}
+		}
+	}
+
+	c.observe(ResultMiss)
+	return loadAndStore(ctx, c, key, load)
+}
+
+func loadAndStore[T any](
+	ctx context.Context,
+	c *Cache,
+	key string,
+	load func(ctx context.Context) (*T, error),
+) (*T, error) {
+	value, err := load(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	raw, err := json.Marshal(value)
+	if err == nil {
+		err = c.Store.Set(ctx, key, &Entry{Value: raw, StoredAt: c.Now()}, c.Ttl+c.StaleTtl)
+	}
+	if err != nil {
+		log.Printf("cache %s: writing %s: %v", c.Source, key, err)
+	}
+	return value, nil
+}
+
+// refresh runs fn in the background unless a refresh of key is already
+// running. It outlives the request but keeps its values, e.g. the trace.
+func (c *Cache) refresh(ctx context.Context, key string, fn func(ctx context.Context) error) {
+	c.mu.Lock()
+	if c.refreshing[key] {
+		c.mu.Unlock()
+		return
+	}
+	c.refreshing[key] = true
+	c.mu.Unlock()
+
+	go func() {
+		defer func() {
+			c.mu.Lock()
+			delete(c.refreshing, key)
+			c.mu.Unlock()
+		}()
+
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), c.RefreshTimeout)
+		defer cancel()
+		err := fn(ctx)
+		if err != nil {
+			log.Printf("cache %s: refreshing %s: %v", c.Source, key, err)
+		}
+	}()
+}
+
+func (c *Cache) observe(result string) {
+	metrics.CacheRequests.WithLabelValues(c.Source, result).Inc()
+}
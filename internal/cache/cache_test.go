@@ -0,0 +1,155 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mrexmelle/connect-emp/internal/metrics"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+type value struct {
+	N int64 `json:"n"`
+}
+
+type clock struct {
+	now time.Time
+}
+
+func (c *clock) Now() time.Time {
+	return c.now
+}
+
+func newTestCache(store Store, source string) (*Cache, *clock) {
+	clk := &clock{now: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)}
+	c := New(store, source, time.Minute, 30*time.Second)
+	c.Now = clk.Now
+	if lru, ok := store.(*LruStore); ok {
+		lru.Now = clk.Now
+	}
+	return c, clk
+}
+
+func counter(calls *int64) func(ctx context.Context) (*value, error) {
+	return func(ctx context.Context) (*value, error) {
+		return &value{N: atomic.AddInt64(calls, 1)}, nil
+	}
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met in time")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestGetOrLoad(t *testing.T) {
+	c, clk := newTestCache(NewLruStore(10), "test_get_or_load")
+	calls := int64(0)
+	before := map[string]float64{}
+	for _, result := range []string{ResultHit, ResultStale, ResultMiss} {
+		before[result] = testutil.ToFloat64(metrics.CacheRequests.WithLabelValues(c.Source, result))
+	}
+	ctx := context.Background()
+
+	v, err := GetOrLoad(ctx, c, "k", counter(&calls))
+	if err != nil || v.N != 1 {
+		t.Fatalf("expected first load, got %+v, %v", v, err)
+	}
+
+	clk.now = clk.now.Add(59 * time.Second)
+	v, _ = GetOrLoad(ctx, c, "k", counter(&calls))
+	if v.N != 1 || atomic.LoadInt64(&calls) != 1 {
+		t.Errorf("expected fresh hit, got %+v after %d loads", v, calls)
+	}
+
+	clk.now = clk.now.Add(10 * time.Second)
+	v, _ = GetOrLoad(ctx, c, "k", counter(&calls))
+	if v.N != 1 {
+		t.Errorf("expected stale value to be served, got %+v", v)
+	}
+	waitFor(t, func() bool { return atomic.LoadInt64(&calls) == 2 })
+	waitFor(t, func() bool {
+		e, _ := c.Store.Get(ctx, "k")
+		return e != nil && e.StoredAt.Equal(clk.now)
+	})
+
+	v, _ = GetOrLoad(ctx, c, "k", counter(&calls))
+	if v.N != 2 {
+		t.Errorf("expected refreshed value, got %+v", v)
+	}
+
+	clk.now = clk.now.Add(2 * time.Minute)
+	v, _ = GetOrLoad(ctx, c, "k", counter(&calls))
+	if v.N != 3 {
+		t.Errorf("expected expired entry to be loaded again, got %+v", v)
+	}
+
+	for result, expected := range map[string]float64{
+		ResultHit:   2,
+		ResultStale: 1,
+		ResultMiss:  2,
+	} {
+		got := testutil.ToFloat64(metrics.CacheRequests.WithLabelValues(c.Source, result)) - before[result]
+		if got != expected {
+			t.Errorf("expected %v %s, got %v", expected, result, got)
+		}
+	}
+}
+
+func TestGetOrLoadDoesNotCacheErrors(t *testing.T) {
+	c, _ := newTestCache(NewLruStore(10), "test_errors")
+	failure := errors.New("down")
+
+	_, err := GetOrLoad(context.Background(), c, "k", func(ctx context.Context) (*value, error) {
+		return nil, failure
+	})
+	if !errors.Is(err, failure) {
+		t.Fatalf("expected load error, got %v", err)
+	}
+
+	calls := int64(0)
+	v, err := GetOrLoad(context.Background(), c, "k", counter(&calls))
+	if err != nil || v.N != 1 {
+		t.Errorf("expected a fresh load after a failure, got %+v, %v", v, err)
+	}
+}
+
+func TestGetOrLoadWithNoopStore(t *testing.T) {
+	c, _ := newTestCache(NoopStore{}, "test_noop")
+	calls := int64(0)
+	for i := 0; i < 3; i++ {
+		GetOrLoad(context.Background(), c, "k", counter(&calls))
+	}
+	if calls != 3 {
+		t.Errorf("expected every lookup to load, got %d loads", calls)
+	}
+}
+
+func TestKeysDoNotClash(t *testing.T) {
+	for _, c := range []struct {
+		key    string
+		prefix string
+	}{
+		{NodeKey(SourceOrg, "ENG", "members"), EhidPrefix(SourceOrg, "node")},
+		{RoleKey(SourceOrg, "1"), EhidPrefix(SourceOrg, "role")},
+		{Key(SourceOrg, "u001:x", "history"), EhidPrefix(SourceOrg, "u001")},
+		{NodeKey(SourceOrg, "ENG:x", "members"), NodeKey(SourceOrg, "ENG", "")},
+	} {
+		if strings.HasPrefix(c.key, c.prefix) {
+			t.Errorf("expected %q to not start with %q", c.key, c.prefix)
+		}
+	}
+	for _, key := range []string{Key(SourceOrg, "u001", "history"), NodeKey(SourceOrg, "ENG", "members"), RoleKey(SourceOrg, "1")} {
+		if !strings.HasPrefix(key, SourceOrg+":") {
+			t.Errorf("expected %q to go with its source", key)
+		}
+	}
+}
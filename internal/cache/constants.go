@@ -0,0 +1,20 @@
+package cache
+
+const (
+	BackendNone  = "none"
+	BackendLru   = "lru"
+	BackendRedis = "redis"
+
+	SourceOrg   = "org"
+	SourceAuthx = "authx"
+
+	ResultHit   = "hit"
+	ResultStale = "stale"
+	ResultMiss  = "miss"
+	ResultError = "error"
+)
+
+var Sources = []string{
+	SourceOrg,
+	SourceAuthx,
+}
@@ -0,0 +1,73 @@
+package cache
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi"
+	"github.com/mrexmelle/connect-emp/internal/config"
+	"github.com/mrexmelle/connect-emp/internal/dto/dtorespwithdata"
+	"github.com/mrexmelle/connect-emp/internal/localerror"
+)
+
+type Controller struct {
+	ConfigService     *config.Service
+	LocalErrorService *localerror.Service
+	CacheService      *Service
+}
+
+func NewController(cfg *config.Service, les *localerror.Service, svc *Service) *Controller {
+	return &Controller{
+		ConfigService:     cfg,
+		LocalErrorService: les,
+		CacheService:      svc,
+	}
+}
+
+// Delete Source : HTTP endpoint to invalidate every cached lookup of a source
+// @Tags Cache
+// @Description Invalidate the cached lookups of connect-org (org) or connect-authx (authx)
+// @Produce json
+// @Param source path string true "org or authx"
+// @Success 200 {object} DeleteResponseDto "Success Response"
+// @Failure 400 "BadRequest"
+// @Failure 500 "InternalServerError"
+// @Router /cache/{source} [DELETE]
+func (c *Controller) DeleteBySource(w http.ResponseWriter, r *http.Request) {
+	source := chi.URLParam(r, "source")
+	deleted, err := c.CacheService.InvalidateBySource(r.Context(), source)
+	info := c.LocalErrorService.Map(err)
+	dtorespwithdata.New(
+		&InvalidationViewEntity{
+			Source:  source,
+			Deleted: deleted,
+		},
+		info.ServiceErrorCode,
+		info.ServiceErrorMessage,
+	).RenderTo(w, info.HttpStatusCode)
+}
+
+// Delete Source And Ehid : HTTP endpoint to invalidate the cached lookups of a source for an account
+// @Tags Cache
+// @Description Invalidate the cached lookups of connect-org (org) or connect-authx (authx) for one EHID
+// @Produce json
+// @Param source path string true "org or authx"
+// @Param ehid path string true "EHID"
+// @Success 200 {object} DeleteResponseDto "Success Response"
+// @Failure 400 "BadRequest"
+// @Failure 500 "InternalServerError"
+// @Router /cache/{source}/{ehid} [DELETE]
+func (c *Controller) DeleteBySourceAndEhid(w http.ResponseWriter, r *http.Request) {
+	source := chi.URLParam(r, "source")
+	ehid := chi.URLParam(r, "ehid")
+	deleted, err := c.CacheService.InvalidateBySourceAndEhid(r.Context(), source, ehid)
+	info := c.LocalErrorService.Map(err)
+	dtorespwithdata.New(
+		&InvalidationViewEntity{
+			Source:  source,
+			Ehid:    ehid,
+			Deleted: deleted,
+		},
+		info.ServiceErrorCode,
+		info.ServiceErrorMessage,
+	).RenderTo(w, info.HttpStatusCode)
+}
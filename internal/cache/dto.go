@@ -0,0 +1,7 @@
+package cache
+
+import (
+	"github.com/mrexmelle/connect-emp/internal/dto/dtorespwithdata"
+)
+
+type DeleteResponseDto = dtorespwithdata.Class[InvalidationViewEntity]
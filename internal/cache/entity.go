@@ -0,0 +1,7 @@
+package cache
+
+type InvalidationViewEntity struct {
+	Source  string `json:"source"`
+	Ehid    string `json:"ehid,omitempty"`
+	Deleted int    `json:"deleted"`
+}
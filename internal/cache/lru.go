@@ -0,0 +1,99 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"strings"
+	"sync"
+	"time"
+)
+
+type lruItem struct {
+	key       string
+	entry     *Entry
+	expiresAt time.Time
+}
+
+// LruStore is an in-process store that evicts the least recently used entry
+// once Capacity is reached.
+type LruStore struct {
+	Capacity int
+	Now      func() time.Time
+
+	mu    sync.Mutex
+	items map[string]*list.Element
+	order *list.List
+}
+
+func NewLruStore(capacity int) *LruStore {
+	return &LruStore{
+		Capacity: capacity,
+		Now:      time.Now,
+		items:    map[string]*list.Element{},
+		order:    list.New(),
+	}
+}
+
+func (s *LruStore) Get(ctx context.Context, key string) (*Entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	el, ok := s.items[key]
+	if !ok {
+		return nil, nil
+	}
+	item := el.Value.(*lruItem)
+	if !s.Now().Before(item.expiresAt) {
+		s.remove(el)
+		return nil, nil
+	}
+	s.order.MoveToFront(el)
+	return item.entry, nil
+}
+
+func (s *LruStore) Set(ctx context.Context, key string, e *Entry, lifetime time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	item := &lruItem{
+		key:       key,
+		entry:     e,
+		expiresAt: s.Now().Add(lifetime),
+	}
+	if el, ok := s.items[key]; ok {
+		el.Value = item
+		s.order.MoveToFront(el)
+		return nil
+	}
+
+	s.items[key] = s.order.PushFront(item)
+	for s.order.Len() > s.Capacity {
+		s.remove(s.order.Back())
+	}
+	return nil
+}
+
+func (s *LruStore) DeletePrefix(ctx context.Context, prefix string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	deleted := 0
+	for key, el := range s.items {
+		if strings.HasPrefix(key, prefix) {
+			s.remove(el)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+func (s *LruStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.order.Len()
+}
+
+func (s *LruStore) remove(el *list.Element) {
+	s.order.Remove(el)
+	delete(s.items, el.Value.(*lruItem).key)
+}
@@ -0,0 +1,72 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const redisScanCount = 500
+
+// redisGlobEscaper escapes what SCAN MATCH would take for a pattern.
+var redisGlobEscaper = strings.NewReplacer(`\`, `\\`, `*`, `\*`, `?`, `\?`, `[`, `\[`, `]`, `\]`)
+
+// RedisStore shares entries between replicas. Keys are namespaced with
+// KeyPrefix so that the database can be shared with other services.
+type RedisStore struct {
+	Client    *redis.Client
+	KeyPrefix string
+}
+
+func NewRedisStore(client *redis.Client, keyPrefix string) *RedisStore {
+	return &RedisStore{
+		Client:    client,
+		KeyPrefix: keyPrefix,
+	}
+}
+
+func (s *RedisStore) Get(ctx context.Context, key string) (*Entry, error) {
+	raw, err := s.Client.Get(ctx, s.KeyPrefix+key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	e := Entry{}
+	err = json.Unmarshal(raw, &e)
+	if err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+func (s *RedisStore) Set(ctx context.Context, key string, e *Entry, lifetime time.Duration) error {
+	raw, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	return s.Client.Set(ctx, s.KeyPrefix+key, raw, lifetime).Err()
+}
+
+func (s *RedisStore) DeletePrefix(ctx context.Context, prefix string) (int, error) {
+	deleted := 0
+	iter := s.Client.Scan(ctx, 0, redisGlobEscaper.Replace(s.KeyPrefix+prefix)+"*", redisScanCount).Iterator()
+	for iter.Next(ctx) {
+		n, err := s.Client.Del(ctx, iter.Val()).Result()
+		if err != nil {
+			return deleted, err
+		}
+		deleted += int(n)
+	}
+	return deleted, iter.Err()
+}
+
+func (s *RedisStore) Close() error {
+	return s.Client.Close()
+}
@@ -0,0 +1,78 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/mrexmelle/connect-emp/internal/config"
+	"github.com/mrexmelle/connect-emp/internal/localerror"
+	"github.com/redis/go-redis/v9"
+)
+
+type Service struct {
+	ConfigService *config.Service
+	Store         Store
+	OrgCache      *Cache
+	AuthxCache    *Cache
+}
+
+func NewService(cfg *config.Service) (*Service, error) {
+	store, err := newStore(cfg.ConfigRepository)
+	if err != nil {
+		return nil, err
+	}
+
+	op := cfg.ConfigRepository.GetOrgCachePolicy()
+	ap := cfg.ConfigRepository.GetAuthxCachePolicy()
+	return &Service{
+		ConfigService: cfg,
+		Store:         store,
+		OrgCache:      New(store, SourceOrg, op.Ttl, op.StaleTtl),
+		AuthxCache:    New(store, SourceAuthx, ap.Ttl, ap.StaleTtl),
+	}, nil
+}
+
+func newStore(cr config.Repository) (Store, error) {
+	switch cr.GetCacheBackend() {
+	case BackendNone:
+		return NoopStore{}, nil
+	case BackendLru:
+		return NewLruStore(cr.GetCacheLruCapacity()), nil
+	case BackendRedis:
+		return NewRedisStore(
+			redis.NewClient(&redis.Options{
+				Addr:     cr.GetCacheRedisAddress(),
+				Password: cr.GetCacheRedisPassword(),
+				DB:       cr.GetCacheRedisDb(),
+			}),
+			cr.GetCacheRedisKeyPrefix(),
+		), nil
+	default:
+		return nil, fmt.Errorf("unknown cache backend %q", cr.GetCacheBackend())
+	}
+}
+
+// InvalidateBySource drops every cached lookup of a source.
+func (s *Service) InvalidateBySource(ctx context.Context, source string) (int, error) {
+	if !slices.Contains(Sources, source) {
+		return 0, localerror.ErrUnknownCacheSource
+	}
+	return s.Store.DeletePrefix(ctx, source+":")
+}
+
+// InvalidateBySourceAndEhid drops the cached lookups of a source for one
+// EHID.
+func (s *Service) InvalidateBySourceAndEhid(ctx context.Context, source string, ehid string) (int, error) {
+	if !slices.Contains(Sources, source) {
+		return 0, localerror.ErrUnknownCacheSource
+	}
+	return s.Store.DeletePrefix(ctx, EhidPrefix(source, ehid))
+}
+
+func (s *Service) Close() error {
+	if rs, ok := s.Store.(*RedisStore); ok {
+		return rs.Close()
+	}
+	return nil
+}
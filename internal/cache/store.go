@@ -0,0 +1,34 @@
+package cache
+
+import (
+	"context"
+	"time"
+)
+
+type Entry struct {
+	Value    []byte    `json:"value"`
+	StoredAt time.Time `json:"stored_at"`
+}
+
+// Store keeps entries until their lifetime runs out. Freshness is judged by
+// Cache, not by the store.
+type Store interface {
+	Get(ctx context.Context, key string) (*Entry, error)
+	Set(ctx context.Context, key string, e *Entry, lifetime time.Duration) error
+	DeletePrefix(ctx context.Context, prefix string) (int, error)
+}
+
+// NoopStore stores nothing, so every lookup goes to the source.
+type NoopStore struct{}
+
+func (s NoopStore) Get(ctx context.Context, key string) (*Entry, error) {
+	return nil, nil
+}
+
+func (s NoopStore) Set(ctx context.Context, key string, e *Entry, lifetime time.Duration) error {
+	return nil
+}
+
+func (s NoopStore) DeletePrefix(ctx context.Context, prefix string) (int, error) {
+	return 0, nil
+}
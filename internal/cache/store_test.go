@@ -0,0 +1,99 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func testStore(t *testing.T, s Store, expire func(d time.Duration)) {
+	ctx := context.Background()
+	storedAt := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	for _, key := range []string{"org:u001:nodes", "org:u001:history", "org:u002:nodes", "authx:u001:profile"} {
+		err := s.Set(ctx, key, &Entry{Value: []byte(`"` + key + `"`), StoredAt: storedAt}, time.Minute)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	e, err := s.Get(ctx, "org:u001:nodes")
+	if err != nil || e == nil || string(e.Value) != `"org:u001:nodes"` || !e.StoredAt.Equal(storedAt) {
+		t.Fatalf("unexpected entry %+v, %v", e, err)
+	}
+
+	deleted, err := s.DeletePrefix(ctx, "org:u001:")
+	if err != nil || deleted != 2 {
+		t.Errorf("expected 2 entries deleted, got %d, %v", deleted, err)
+	}
+	if e, _ := s.Get(ctx, "org:u001:history"); e != nil {
+		t.Errorf("expected entry to be invalidated, got %+v", e)
+	}
+	if e, _ := s.Get(ctx, "org:u002:nodes"); e == nil {
+		t.Error("expected entry of another EHID to be kept")
+	}
+
+	for _, key := range []string{"org:u[1]:nodes", "org:u1:nodes"} {
+		err := s.Set(ctx, key, &Entry{StoredAt: storedAt}, time.Minute)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	deleted, err = s.DeletePrefix(ctx, "org:u[1]:")
+	if err != nil || deleted != 1 {
+		t.Errorf("expected 1 entry deleted, got %d, %v", deleted, err)
+	}
+	if e, _ := s.Get(ctx, "org:u1:nodes"); e == nil {
+		t.Error("expected the prefix to be taken literally")
+	}
+
+	expire(time.Minute)
+	if e, _ := s.Get(ctx, "authx:u001:profile"); e != nil {
+		t.Errorf("expected entry to expire, got %+v", e)
+	}
+}
+
+func TestLruStore(t *testing.T) {
+	now := time.Now()
+	s := NewLruStore(10)
+	s.Now = func() time.Time { return now }
+
+	testStore(t, s, func(d time.Duration) {
+		now = now.Add(d)
+	})
+}
+
+func TestLruStoreEvictsLeastRecentlyUsed(t *testing.T) {
+	ctx := context.Background()
+	s := NewLruStore(2)
+	for _, key := range []string{"a", "b"} {
+		s.Set(ctx, key, &Entry{}, time.Minute)
+	}
+	s.Get(ctx, "a")
+	s.Set(ctx, "c", &Entry{}, time.Minute)
+
+	if e, _ := s.Get(ctx, "b"); e != nil {
+		t.Error("expected b to be evicted")
+	}
+	if e, _ := s.Get(ctx, "a"); e == nil {
+		t.Error("expected a to be kept")
+	}
+	if s.Len() != 2 {
+		t.Errorf("expected 2 entries, got %d", s.Len())
+	}
+}
+
+func TestRedisStore(t *testing.T) {
+	mr := miniredis.RunT(t)
+	s := NewRedisStore(redis.NewClient(&redis.Options{Addr: mr.Addr()}), "connect-emp:")
+	defer s.Close()
+
+	testStore(t, s, mr.FastForward)
+
+	s.Set(context.Background(), "org:u003:nodes", &Entry{}, time.Minute)
+	if !mr.Exists("connect-emp:org:u003:nodes") {
+		t.Error("expected keys to carry the prefix")
+	}
+}
@@ -1,9 +1,109 @@
 package career
 
 type Aggregate struct {
-	StartDate        string `json:"start_date"`
-	EndDate          string `json:"end_date"`
-	Grade            string `json:"grade"`
-	Title            string `json:"title"`
-	OrganizationNode string `json:"organization_node"`
+	StartDate        string   `json:"start_date"`
+	EndDate          string   `json:"end_date"`
+	Grade            string   `json:"grade"`
+	Title            string   `json:"title"`
+	OrganizationNode string   `json:"organization_node,omitempty"`
+	Sources          *Sources `json:"sources,omitempty"`
+	Org              *Node    `json:"org,omitempty"`
+}
+
+// NodeRef names an organization node. Type is named after the depth of the
+// node by app.career.node-types.
+type NodeRef struct {
+	Id   string `json:"id"`
+	Name string `json:"name"`
+	Type string `json:"type,omitempty"`
+}
+
+// Node is an organization node expanded with its ancestors, from the root
+// down to its parent.
+type Node struct {
+	NodeRef
+	Path []NodeRef `json:"path"`
+}
+
+// Sources lists the gradings, titlings and memberships a segment is made of,
+// several of a kind when a segment spans records holding the same value.
+type Sources struct {
+	Gradings    []Source `json:"gradings"`
+	Titlings    []Source `json:"titlings"`
+	Memberships []Source `json:"memberships"`
+}
+
+// Source is the record behind a segment. Gradings and titlings link to their
+// GET endpoints while memberships, kept by connect-org, only have an ID.
+type Source struct {
+	Id   int    `json:"id"`
+	Href string `json:"href,omitempty"`
+}
+
+// Tenure tells how long an employee has been employed as of a date and since
+// when they hold their grade, title and organization node. Total tenure only
+// counts graded days, so gaps between employments are left out.
+type Tenure struct {
+	Ehid             string      `json:"ehid"`
+	AsOf             string      `json:"as_of"`
+	Total            TenureSpan  `json:"total"`
+	Grade            *TenureSpan `json:"grade,omitempty"`
+	Title            *TenureSpan `json:"title,omitempty"`
+	OrganizationNode *TenureSpan `json:"organization_node,omitempty"`
+}
+
+// TenureSpan is the number of days something has been held for, since the
+// given date.
+type TenureSpan struct {
+	Value string `json:"value,omitempty"`
+	Since string `json:"since"`
+	Days  int    `json:"days"`
+}
+
+const (
+	ChangeHire         = "hire"
+	ChangePromotion    = "promotion"
+	ChangeDemotion     = "demotion"
+	ChangeGrade        = "grade_change"
+	ChangeLateralTitle = "lateral_title_change"
+	ChangeTransfer     = "transfer"
+	ChangeGapStart     = "gap_start"
+	ChangeGapEnd       = "gap_end"
+)
+
+// Position is what an employee holds on a given day. An employee without a
+// grade is not employed.
+type Position struct {
+	Grade            string `json:"grade,omitempty"`
+	Title            string `json:"title,omitempty"`
+	OrganizationNode string `json:"organization_node,omitempty"`
+}
+
+// Change is an event of a career, taking effect on Date. Before is left out
+// for a hire and After for the start of a gap. The end of a gap compares the
+// position held before the gap with the one held after it.
+type Change struct {
+	Date   string    `json:"date"`
+	Type   string    `json:"type"`
+	Before *Position `json:"before,omitempty"`
+	After  *Position `json:"after,omitempty"`
+}
+
+// Diff compares the positions held on From and To. Changes lists the changes
+// after From up to To and Segments the career between both dates, both in
+// chronological order.
+type Diff struct {
+	From             string        `json:"from"`
+	To               string        `json:"to"`
+	Grade            DimensionDiff `json:"grade"`
+	Title            DimensionDiff `json:"title"`
+	OrganizationNode DimensionDiff `json:"organization_node"`
+	Changes          []Change      `json:"changes"`
+	Segments         []Aggregate   `json:"segments"`
+}
+
+type DimensionDiff struct {
+	Before  string `json:"before"`
+	After   string `json:"after"`
+	Changed bool   `json:"changed"`
 }
@@ -0,0 +1,270 @@
+package career
+
+import (
+	"math/rand"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/mrexmelle/connect-emp/internal/grading"
+	"github.com/mrexmelle/connect-emp/internal/titling"
+	"github.com/mrexmelle/connect-org/pkg/liborgc"
+)
+
+// histories is the input of mergeHistories.
+type histories struct {
+	gradings    []grading.ViewEntity
+	titlings    []titling.ViewEntity
+	memberships []liborgc.MembershipViewEntity
+}
+
+// add appends a record of the given kind held from start for days days, or
+// for good when days is zero. Dates are counted from 2020-01-01.
+func (h *histories) add(kind int, value string, start int, days int) {
+	base := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	sd := base.AddDate(0, 0, start).Format("2006-01-02")
+	ed := ""
+	if days > 0 {
+		ed = base.AddDate(0, 0, start+days-1).Format("2006-01-02")
+	}
+	switch kind {
+	case kindGrade:
+		h.gradings = append(h.gradings, grading.ViewEntity{StartDate: sd, EndDate: ed, Grade: value})
+	case kindTitle:
+		h.titlings = append(h.titlings, titling.ViewEntity{StartDate: sd, EndDate: ed, Title: value})
+	default:
+		h.memberships = append(h.memberships, liborgc.MembershipViewEntity{StartDate: sd, EndDate: ed, NodeId: value})
+	}
+}
+
+// dayRecord is a record as the reference sees it, held from start through
+// end, or for good when end is empty.
+type dayRecord struct {
+	value string
+	start string
+	end   string
+}
+
+// dayValues are the values held on a day.
+type dayValues struct {
+	grade string
+	title string
+	node  string
+}
+
+// heldOn is the value of the record held on d that started last, the one
+// listed first on a tie, or empty when none is held.
+func heldOn(records []dayRecord, d string) string {
+	var held *dayRecord
+	for i, r := range records {
+		if r.start > d || (r.end != "" && r.end < d) {
+			continue
+		}
+		if held == nil || r.start > held.start {
+			held = &records[i]
+		}
+	}
+	if held == nil {
+		return ""
+	}
+	return held.value
+}
+
+// mergeHistoriesByDay is the reference of mergeHistories. It looks up the
+// values held on every day from the first start date to the day after the
+// last date of all, which holds the values kept for good, and joins the days
+// holding the same values unless a record starts or ends in between.
+func mergeHistoriesByDay(h histories) []Aggregate {
+	gradings, titlings, memberships := []dayRecord{}, []dayRecord{}, []dayRecord{}
+	for _, g := range h.gradings {
+		gradings = append(gradings, dayRecord{value: g.Grade, start: g.StartDate, end: g.EndDate})
+	}
+	for _, t := range h.titlings {
+		titlings = append(titlings, dayRecord{value: t.Title, start: t.StartDate, end: t.EndDate})
+	}
+	for _, m := range h.memberships {
+		memberships = append(memberships, dayRecord{value: m.NodeId, start: m.StartDate, end: m.EndDate})
+	}
+	all := append(append(append([]dayRecord{}, gradings...), titlings...), memberships...)
+	if len(all) == 0 {
+		return []Aggregate{}
+	}
+
+	parse := func(s string) time.Time {
+		t, _ := time.Parse("2006-01-02", s)
+		return t
+	}
+	first, last := parse(all[0].start), parse(all[0].start)
+	boundaries := map[string]bool{}
+	for _, r := range all {
+		boundaries[r.start] = true
+		if r.end != "" {
+			boundaries[parse(r.end).AddDate(0, 0, 1).Format("2006-01-02")] = true
+		}
+		for _, d := range []string{r.start, r.end} {
+			if d == "" {
+				continue
+			}
+			if parse(d).Before(first) {
+				first = parse(d)
+			}
+			if parse(d).After(last) {
+				last = parse(d)
+			}
+		}
+	}
+	horizon := last.AddDate(0, 0, 1)
+
+	type segment struct {
+		start  string
+		end    string
+		values dayValues
+	}
+	joined := []segment{}
+	for day := first; !day.After(horizon); day = day.AddDate(0, 0, 1) {
+		d := day.Format("2006-01-02")
+		values := dayValues{
+			grade: heldOn(gradings, d),
+			title: heldOn(titlings, d),
+			node:  heldOn(memberships, d),
+		}
+		end := d
+		if day.Equal(horizon) {
+			end = ""
+		}
+		if n := len(joined) - 1; n >= 0 && joined[n].values == values && !boundaries[d] {
+			joined[n].end = end
+			continue
+		}
+		joined = append(joined, segment{start: d, end: end, values: values})
+	}
+
+	aggs := []Aggregate{}
+	for i := len(joined) - 1; i >= 0; i-- {
+		if joined[i].values == (dayValues{}) {
+			continue
+		}
+		aggs = append(aggs, Aggregate{
+			StartDate:        joined[i].start,
+			EndDate:          joined[i].end,
+			Grade:            joined[i].values.grade,
+			Title:            joined[i].values.title,
+			OrganizationNode: joined[i].values.node,
+		})
+	}
+	return aggs
+}
+
+// randomHistories makes up to n records spread over a few months, with
+// overlaps, gaps and open ends.
+func randomHistories(r *rand.Rand, n int) histories {
+	h := histories{}
+	values := []string{"A", "B", "C"}
+	for i := r.Intn(n + 1); i > 0; i-- {
+		days := 0
+		if r.Intn(4) > 0 {
+			days = 1 + r.Intn(40)
+		}
+		h.add(r.Intn(kindCount), values[r.Intn(len(values))], r.Intn(90), days)
+	}
+	return h
+}
+
+func checkMergeHistories(t *testing.T, h histories) {
+	t.Helper()
+	s := &Service{}
+	result, err := s.mergeHistories(h.gradings, h.titlings, h.memberships)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := mergeHistoriesByDay(h)
+	if !slices.Equal(result, expected) {
+		t.Fatalf("histories %+v\nresult:   %+v\nexpected: %+v", h, result, expected)
+	}
+}
+
+func TestMergeHistoriesMatchesDayByDay(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 2000; i++ {
+		checkMergeHistories(t, randomHistories(r, 12))
+	}
+}
+
+func TestMergeHistoriesKeepsRecordBoundaries(t *testing.T) {
+	h := histories{}
+	h.add(kindGrade, "E4", 0, 100)
+	h.add(kindGrade, "E4", 100, 0)
+	h.add(kindTitle, "Engineer", 0, 0)
+
+	s := &Service{}
+	aggs, err := s.mergeHistories(h.gradings, h.titlings, h.memberships)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []Aggregate{
+		{StartDate: "2020-04-10", EndDate: "", Grade: "E4", Title: "Engineer"},
+		{StartDate: "2020-01-01", EndDate: "2020-04-09", Grade: "E4", Title: "Engineer"},
+	}
+	if !slices.Equal(aggs, expected) {
+		t.Errorf("expected %+v, got %+v", expected, aggs)
+	}
+}
+
+func TestMergeHistoriesWithGap(t *testing.T) {
+	h := histories{}
+	h.add(kindGrade, "E4", 0, 366)
+	h.add(kindGrade, "E5", 517, 0)
+	h.add(kindTitle, "Engineer", 0, 0)
+
+	s := &Service{}
+	aggs, err := s.mergeHistories(h.gradings, h.titlings, h.memberships)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []Aggregate{
+		{StartDate: "2021-06-01", EndDate: "", Grade: "E5", Title: "Engineer"},
+		{StartDate: "2021-01-01", EndDate: "2021-05-31", Title: "Engineer"},
+		{StartDate: "2020-01-01", EndDate: "2020-12-31", Grade: "E4", Title: "Engineer"},
+	}
+	if !slices.Equal(aggs, expected) {
+		t.Errorf("expected %+v, got %+v", expected, aggs)
+	}
+}
+
+// FuzzMergeHistories reads every 4 bytes as a record: its kind and whether
+// it is open-ended, its start, its length and its value.
+func FuzzMergeHistories(f *testing.F) {
+	f.Add([]byte{0, 0, 10, 0, 1, 5, 10, 1, 2, 12, 0, 2})
+	f.Add([]byte{0, 0, 10, 0, 0, 20, 10, 0, 128, 15, 0, 1})
+	f.Add([]byte{2, 3, 3, 0, 2, 3, 3, 1, 5, 4, 0, 2})
+	f.Fuzz(func(t *testing.T, data []byte) {
+		if len(data) > 4*32 {
+			return
+		}
+		h := histories{}
+		values := []string{"A", "B", "C"}
+		for i := 0; i+3 < len(data); i += 4 {
+			days := 1 + int(data[i+2]%40)
+			if data[i]&0x80 != 0 {
+				days = 0
+			}
+			h.add(int(data[i]&0x7f)%kindCount, values[int(data[i+3])%len(values)], int(data[i+1]%90), days)
+		}
+		checkMergeHistories(t, h)
+	})
+}
+
+func BenchmarkMergeHistories(b *testing.B) {
+	h := histories{}
+	for i := 0; i < 1000; i++ {
+		h.add(i%kindCount, []string{"A", "B", "C"}[i%3], i*10, 10)
+	}
+	s := &Service{}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, err := s.mergeHistories(h.gradings, h.titlings, h.memberships)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
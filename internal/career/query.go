@@ -5,3 +5,34 @@ var (
 	OrderDesc = "DESC"
 	OrderNone = ""
 )
+
+const (
+	GranularityMonth = "month"
+	GranularityYear  = "year"
+)
+
+var Granularities = []string{
+	GranularityMonth,
+	GranularityYear,
+}
+
+const (
+	IncludeSources = "sources"
+)
+
+const (
+	ExpandOrg = "org"
+)
+
+// TimelineQuery shapes a career. An empty From or To leaves the career open
+// on that side, and an empty Granularity keeps its segments as they are.
+// IncludeSources adds the records behind every segment and ExpandOrg the
+// name, type and ancestors of their organization nodes.
+type TimelineQuery struct {
+	From           string
+	To             string
+	Granularity    string
+	Compact        bool
+	IncludeSources bool
+	ExpandOrg      bool
+}
@@ -1,58 +1,166 @@
 package career
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"slices"
 	"sort"
+	"strings"
+	"time"
 
+	"github.com/mrexmelle/connect-emp/internal/batch"
 	"github.com/mrexmelle/connect-emp/internal/config"
 	"github.com/mrexmelle/connect-emp/internal/dateinterval"
-	"github.com/mrexmelle/connect-emp/internal/datesort"
 	"github.com/mrexmelle/connect-emp/internal/datestr"
+	"github.com/mrexmelle/connect-emp/internal/dto"
 	"github.com/mrexmelle/connect-emp/internal/grading"
 	"github.com/mrexmelle/connect-emp/internal/localerror"
+	"github.com/mrexmelle/connect-emp/internal/orgclient"
 	"github.com/mrexmelle/connect-emp/internal/titling"
+	"github.com/mrexmelle/connect-emp/internal/txtime"
 	"github.com/mrexmelle/connect-org/pkg/liborgc"
+	"golang.org/x/sync/errgroup"
+	"gorm.io/gorm"
 )
 
 type Service struct {
 	ConfigService  *config.Service
 	GradingService *grading.Service
 	TitlingService *titling.Service
-	OrgClient      *liborgc.Client
+	OrgClient      orgclient.Client
 }
 
 func NewService(
 	cfg *config.Service,
 	gs *grading.Service,
 	ts *titling.Service,
+	oc orgclient.Client,
 ) *Service {
 	return &Service{
 		ConfigService:  cfg,
 		GradingService: gs,
 		TitlingService: ts,
-		OrgClient: liborgc.NewClient(
-			cfg.ConfigRepository.GetOrgHost(),
-			cfg.ConfigRepository.GetOrgPort(),
-		),
+		OrgClient:      oc,
 	}
 }
 
-func (s *Service) RetrieveCurrentByEhid(ehid string) (*Aggregate, error) {
-	g, err := s.GradingService.RetrieveCurrentByEhid(ehid)
+// RetrieveCurrentByEhid merges the current grading, titling and membership,
+// which are looked up concurrently. The first failure cancels the other
+// lookups. When degraded is set and connect-org cannot be reached, the
+// aggregate is built without the membership and a warning says so.
+func (s *Service) RetrieveCurrentByEhid(
+	ctx context.Context,
+	ehid string,
+	degraded bool,
+) (*Aggregate, []dto.Warning, error) {
+	var (
+		g           *grading.ViewEntity
+		t           *titling.ViewEntity
+		memberships []liborgc.MembershipViewEntity
+		warnings    []dto.Warning
+	)
+	eg, ctx := errgroup.WithContext(ctx)
+	eg.Go(func() (err error) {
+		g, err = s.GradingService.RetrieveCurrentByEhid(ctx, ehid)
+		return err
+	})
+	eg.Go(func() (err error) {
+		t, err = s.TitlingService.RetrieveCurrentByEhid(ctx, ehid)
+		return err
+	})
+	eg.Go(func() (err error) {
+		memberships, warnings, err = s.retrieveMemberships(degraded, func() (*liborgc.GetMemberNodesResponseDto, error) {
+			return s.OrgClient.GetMemberNodesByEhid(ctx, ehid)
+		})
+		return err
+	})
+	err := eg.Wait()
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	t, err := s.TitlingService.RetrieveCurrentByEhid(ehid)
+	agg, err := s.mergeCurrent(g, t, memberships, warnings)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
+	return agg, warnings, nil
+}
 
-	m, err := s.OrgClient.GetMemberNodesByEhid(ehid)
-	if err != nil || len(*m.Data) == 0 {
+// RetrieveCurrentByEhids builds the current career of many EHIDs. Gradings
+// and titlings are read with one query each while memberships are looked up
+// with at most concurrency calls at once. A failing query fails the whole
+// batch; any other failure only fails its EHID.
+func (s *Service) RetrieveCurrentByEhids(
+	ctx context.Context,
+	ehids []string,
+	concurrency int,
+	degraded bool,
+) ([]batch.Result[Aggregate], error) {
+	var (
+		gradings    map[string]grading.ViewEntity
+		titlings    map[string]titling.ViewEntity
+		memberships []batch.Result[[]liborgc.MembershipViewEntity]
+	)
+	eg, ctx := errgroup.WithContext(ctx)
+	eg.Go(func() (err error) {
+		gradings, err = s.GradingService.RetrieveCurrentByEhids(ctx, ehids)
+		return err
+	})
+	eg.Go(func() (err error) {
+		titlings, err = s.TitlingService.RetrieveCurrentByEhids(ctx, ehids)
+		return err
+	})
+	eg.Go(func() error {
+		memberships = batch.Map(ctx, ehids, concurrency, func(
+			ctx context.Context,
+			ehid string,
+		) (*[]liborgc.MembershipViewEntity, []dto.Warning, error) {
+			m, warnings, err := s.retrieveMemberships(degraded, func() (*liborgc.GetMemberNodesResponseDto, error) {
+				return s.OrgClient.GetMemberNodesByEhid(ctx, ehid)
+			})
+			return &m, warnings, err
+		})
+		return nil
+	})
+	err := eg.Wait()
+	if err != nil {
 		return nil, err
 	}
 
+	results := []batch.Result[Aggregate]{}
+	for _, m := range memberships {
+		result := batch.Result[Aggregate]{
+			Ehid:     m.Ehid,
+			Warnings: m.Warnings,
+			Err:      m.Err,
+		}
+		g, hasGrading := gradings[m.Ehid]
+		t, hasTitling := titlings[m.Ehid]
+		if result.Err == nil && (!hasGrading || !hasTitling) {
+			result.Err = gorm.ErrRecordNotFound
+		}
+		if result.Err == nil {
+			result.Data, result.Err = s.mergeCurrent(&g, &t, *m.Data, m.Warnings)
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// mergeCurrent narrows the current grading, titling and membership down to
+// the period they all hold. Memberships may only be missing when a warning
+// explains why.
+func (s *Service) mergeCurrent(
+	g *grading.ViewEntity,
+	t *titling.ViewEntity,
+	memberships []liborgc.MembershipViewEntity,
+	warnings []dto.Warning,
+) (*Aggregate, error) {
+	if len(memberships) == 0 && len(warnings) == 0 {
+		return nil, localerror.ErrMembershipNotFound
+	}
+
 	gsd, err := datestr.NewFromString(g.StartDate)
 	if err != nil {
 		return nil, err
@@ -73,145 +181,915 @@ func (s *Service) RetrieveCurrentByEhid(ehid string) (*Aggregate, error) {
 		return nil, err
 	}
 
-	msd, err := datestr.NewFromString((*m.Data)[0].StartDate)
-	if err != nil {
-		return nil, err
-	}
+	startDates := []datestr.Class{*gsd, *tsd}
+	endDates := []datestr.Class{*ged, *ted}
+	nodeId := ""
+	if len(memberships) > 0 {
+		msd, err := datestr.NewFromString(memberships[0].StartDate)
+		if err != nil {
+			return nil, err
+		}
 
-	med, err := datestr.NewFromString((*m.Data)[0].EndDate)
-	if err != nil {
-		return nil, err
+		med, err := datestr.NewFromString(memberships[0].EndDate)
+		if err != nil {
+			return nil, err
+		}
+
+		startDates = append(startDates, *msd)
+		endDates = append(endDates, *med)
+		nodeId = memberships[0].NodeId
 	}
 
-	sd := s.maxDate([]datestr.Class{*gsd, *tsd, *msd})
-	ed := s.minDate([]datestr.Class{*ged, *ted, *med})
+	sd := s.maxDate(startDates)
+	ed := s.minDate(endDates)
 
 	return &Aggregate{
 		StartDate:        (*sd).AsString(),
 		EndDate:          (*ed).AsString(),
 		Grade:            g.Grade,
 		Title:            t.Title,
-		OrganizationNode: (*m.Data)[0].NodeId,
+		OrganizationNode: nodeId,
 	}, nil
 }
 
-func (s *Service) RetrieveByEhidOrderByStartDateDesc(ehid string) ([]Aggregate, error) {
-	gradings, err := s.GradingService.RetrieveByEhidOrderByStartDate(ehid, grading.OrderDesc)
+func (s *Service) RetrieveByEhidOrderByStartDateDesc(ctx context.Context, ehid string) ([]Aggregate, error) {
+	aggs, _, err := s.RetrieveByEhidAsKnownAtOrderByStartDateDesc(ctx, ehid, txtime.NewCurrent(), false)
+	return aggs, err
+}
+
+// RetrieveByEhidAsKnownAtOrderByStartDateDesc merges the histories, which are
+// looked up concurrently. When degraded is set and connect-org cannot be
+// reached, the career is built without memberships and a warning says so.
+func (s *Service) RetrieveByEhidAsKnownAtOrderByStartDateDesc(
+	ctx context.Context,
+	ehid string,
+	knownAt *txtime.Class,
+	degraded bool,
+) ([]Aggregate, []dto.Warning, error) {
+	gradings, titlings, memberships, warnings, err := s.retrieveHistories(ctx, ehid, knownAt, degraded)
 	if err != nil {
-		return []Aggregate{}, err
+		return []Aggregate{}, nil, err
 	}
-	titlings, err := s.TitlingService.RetrieveByEhidOrderByStartDate(ehid, titling.OrderDesc)
+
+	aggs, err := s.mergeHistories(gradings, titlings, memberships)
+	return aggs, warnings, err
+}
+
+// retrieveHistories looks up the gradings and titlings as known at knownAt,
+// and the memberships, concurrently and latest first. The first failure
+// cancels the other lookups.
+func (s *Service) retrieveHistories(
+	ctx context.Context,
+	ehid string,
+	knownAt *txtime.Class,
+	degraded bool,
+) ([]grading.ViewEntity, []titling.ViewEntity, []liborgc.MembershipViewEntity, []dto.Warning, error) {
+	var (
+		gradings    []grading.ViewEntity
+		titlings    []titling.ViewEntity
+		memberships []liborgc.MembershipViewEntity
+		warnings    []dto.Warning
+	)
+	eg, ctx := errgroup.WithContext(ctx)
+	eg.Go(func() (err error) {
+		gradings, err = s.GradingService.RetrieveByEhidAsKnownAtOrderByStartDate(ctx, ehid, knownAt, grading.OrderDesc)
+		return err
+	})
+	eg.Go(func() (err error) {
+		titlings, err = s.TitlingService.RetrieveByEhidAsKnownAtOrderByStartDate(ctx, ehid, knownAt, titling.OrderDesc)
+		return err
+	})
+	eg.Go(func() (err error) {
+		memberships, warnings, err = s.retrieveMemberships(degraded, func() (*liborgc.GetMemberHistoryResponseDto, error) {
+			return s.OrgClient.GetMemberHistoryByEhidOrderByStartDateDesc(ctx, ehid)
+		})
+		return err
+	})
+	err := eg.Wait()
 	if err != nil {
-		return []Aggregate{}, err
+		return nil, nil, nil, nil, err
 	}
-	membership, err := s.OrgClient.GetMemberHistoryByEhidOrderByStartDateDesc(ehid)
-	if err != nil || membership.Error.Code != localerror.ErrSvcCodeNone {
-		return nil, err
+	return gradings, titlings, memberships, warnings, nil
+}
+
+// RetrieveChangesByEhidAsKnownAt lists the changes of a career in
+// chronological order. A change of grade is a promotion or a demotion when
+// both grades are listed in app.career.grade-order, and a grade_change
+// otherwise. A change of title without a change of grade is lateral. When
+// degraded is set and connect-org cannot be reached, transfers are left out
+// and a warning says so.
+func (s *Service) RetrieveChangesByEhidAsKnownAt(
+	ctx context.Context,
+	ehid string,
+	knownAt *txtime.Class,
+	degraded bool,
+) ([]Change, []dto.Warning, error) {
+	gradings, titlings, memberships, warnings, err := s.retrieveHistories(ctx, ehid, knownAt, degraded)
+	if err != nil {
+		return []Change{}, nil, err
 	}
 
-	return s.mergeHistories(gradings, titlings, *membership.Data)
+	aggs, err := s.mergeHistories(gradings, titlings, memberships)
+	if err != nil {
+		return []Change{}, nil, err
+	}
+	changes, err := s.diffSegments(aggs)
+	return changes, warnings, err
 }
 
-func (s *Service) mergeHistories(
-	gradings []grading.ViewEntity,
-	titlings []titling.ViewEntity,
-	memberships []liborgc.MembershipViewEntity,
-) ([]Aggregate, error) {
-	aggs := []Aggregate{}
+// RetrieveDiffByEhid compares the grade, title and node held on from and to,
+// listing the changes and the segments of the career in between. The
+// segments are clipped to both dates. When degraded is set and connect-org
+// cannot be reached, nodes and transfers are left out and a warning says so.
+func (s *Service) RetrieveDiffByEhid(
+	ctx context.Context,
+	ehid string,
+	from *datestr.Class,
+	to *datestr.Class,
+	degraded bool,
+) (*Diff, []dto.Warning, error) {
+	_, err := dateinterval.NewFromDateStrings(from, to)
+	if err != nil {
+		return nil, nil, err
+	}
 
-	endDates := []string{}
-	earliestStartDate := ""
-	for _, g := range gradings {
-		idx := slices.Index(endDates, g.EndDate)
-		if idx == -1 {
-			endDates = append(endDates, g.EndDate)
-		}
+	gradings, titlings, memberships, warnings, err := s.retrieveHistories(ctx, ehid, txtime.NewCurrent(), degraded)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(gradings) == 0 {
+		return nil, nil, gorm.ErrRecordNotFound
+	}
 
-		if earliestStartDate == "" || g.StartDate < earliestStartDate {
-			earliestStartDate = g.StartDate
+	aggs, err := s.mergeHistories(gradings, titlings, memberships)
+	if err != nil {
+		return nil, nil, err
+	}
+	before := s.positionIn(aggs, from.AsString())
+	after := s.positionIn(aggs, to.AsString())
+	diff := &Diff{
+		From:             from.AsString(),
+		To:               to.AsString(),
+		Grade:            DimensionDiff{Before: before.Grade, After: after.Grade, Changed: before.Grade != after.Grade},
+		Title:            DimensionDiff{Before: before.Title, After: after.Title, Changed: before.Title != after.Title},
+		OrganizationNode: DimensionDiff{Before: before.OrganizationNode, After: after.OrganizationNode, Changed: before.OrganizationNode != after.OrganizationNode},
+		Changes:          []Change{},
+		Segments:         []Aggregate{},
+	}
+
+	changes, err := s.diffSegments(aggs)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, c := range changes {
+		if c.Date > diff.From && c.Date <= diff.To {
+			diff.Changes = append(diff.Changes, c)
 		}
 	}
 
-	for _, t := range titlings {
-		idx := slices.Index(endDates, t.EndDate)
-		if idx == -1 {
-			endDates = append(endDates, t.EndDate)
+	diff.Segments = s.clipSegments(aggs, diff.From, diff.To)
+	slices.Reverse(diff.Segments)
+	return diff, warnings, nil
+}
+
+// diffSegments compares the positions held by consecutive segments ordered
+// latest first, as mergeHistories cuts them on every day a position may
+// change. Segments that do not follow each other have a gap in between
+// where nothing is held.
+func (s *Service) diffSegments(aggs []Aggregate) ([]Change, error) {
+	changes := []Change{}
+	previous := &Position{}
+	var lastEmployed *Position
+	until := ""
+	for i := len(aggs) - 1; i >= 0; i-- {
+		a := aggs[i]
+		if until != "" && a.StartDate != until {
+			if previous.Grade != "" {
+				changes = append(changes, Change{Date: until, Type: ChangeGapStart, Before: previous})
+			}
+			previous = &Position{}
 		}
+		current := &Position{Grade: a.Grade, Title: a.Title, OrganizationNode: a.OrganizationNode}
 
-		if earliestStartDate == "" || t.StartDate < earliestStartDate {
-			earliestStartDate = t.StartDate
+		if previous.Grade == "" && current.Grade != "" {
+			if lastEmployed == nil {
+				changes = append(changes, Change{Date: a.StartDate, Type: ChangeHire, After: current})
+			} else {
+				changes = append(changes, Change{Date: a.StartDate, Type: ChangeGapEnd, Before: lastEmployed, After: current})
+			}
+		} else if previous.Grade != "" && current.Grade == "" {
+			changes = append(changes, Change{Date: a.StartDate, Type: ChangeGapStart, Before: previous})
+		} else if previous.Grade != "" {
+			if previous.Grade != current.Grade {
+				changes = append(changes, Change{Date: a.StartDate, Type: s.gradeChange(previous.Grade, current.Grade), Before: previous, After: current})
+			} else if previous.Title != current.Title && previous.Title != "" && current.Title != "" {
+				changes = append(changes, Change{Date: a.StartDate, Type: ChangeLateralTitle, Before: previous, After: current})
+			}
+			if previous.OrganizationNode != current.OrganizationNode && previous.OrganizationNode != "" && current.OrganizationNode != "" {
+				changes = append(changes, Change{Date: a.StartDate, Type: ChangeTransfer, Before: previous, After: current})
+			}
 		}
+
+		if current.Grade != "" {
+			lastEmployed = current
+		}
+		previous = current
+		until = ""
+		if a.EndDate != "" {
+			ed, err := datestr.NewFromString(a.EndDate)
+			if err != nil {
+				return []Change{}, err
+			}
+			until = ed.OffsetAndClone(+1).AsString()
+		}
+	}
+	if until != "" && previous.Grade != "" {
+		changes = append(changes, Change{Date: until, Type: ChangeGapStart, Before: previous})
 	}
+	return changes, nil
+}
 
-	for _, m := range memberships {
-		idx := slices.Index(endDates, m.EndDate)
+// positionIn finds the grade, title and node held on d in segments ordered
+// latest first.
+func (s *Service) positionIn(aggs []Aggregate, d string) *Position {
+	for _, a := range aggs {
+		if a.StartDate <= d && (a.EndDate == "" || d <= a.EndDate) {
+			return &Position{Grade: a.Grade, Title: a.Title, OrganizationNode: a.OrganizationNode}
+		}
+	}
+	return &Position{}
+}
+
+// gradeChange tells a promotion from a demotion by the places of both grades
+// in app.career.grade-order.
+func (s *Service) gradeChange(before string, after string) string {
+	order := s.ConfigService.ConfigRepository.GetCareerGradeOrder()
+	b := slices.Index(order, before)
+	a := slices.Index(order, after)
+	if b == -1 || a == -1 {
+		return ChangeGrade
+	}
+	if a > b {
+		return ChangePromotion
+	}
+	return ChangeDemotion
+}
+
+// RetrieveTimelineByEhidAsKnownAt shapes a career as q asks. It is clipped
+// to q.From and q.To, then summed up per month or year when q.Granularity is
+// set: every bucket holds the values held for the most days in it, the
+// latest ones on a tie. With q.Compact, adjacent segments or buckets holding
+// the same values are merged, such as the segments of a record split by a
+// correction. The sources of the resulting segments are traced last, when
+// q.IncludeSources is set.
+func (s *Service) RetrieveTimelineByEhidAsKnownAt(
+	ctx context.Context,
+	ehid string,
+	knownAt *txtime.Class,
+	q TimelineQuery,
+	degraded bool,
+) ([]Aggregate, []dto.Warning, error) {
+	err := s.checkTimelineQuery(q)
+	if err != nil {
+		return []Aggregate{}, nil, err
+	}
 
-		if idx == -1 {
-			endDates = append(endDates, m.EndDate)
+	gradings, titlings, memberships, warnings, err := s.retrieveHistories(ctx, ehid, knownAt, degraded)
+	if err != nil {
+		return []Aggregate{}, nil, err
+	}
+	records, segments, err := s.sweepHistories(gradings, titlings, memberships)
+	if err != nil {
+		return []Aggregate{}, nil, err
+	}
+
+	aggs := s.clipSegments(segmentAggregates(segments), q.From, q.To)
+	if q.Granularity != "" {
+		aggs = s.bucketSegments(aggs, q.Granularity, time.Now())
+	}
+	if q.Compact {
+		aggs, err = s.compactSegments(aggs)
+		if err != nil {
+			return []Aggregate{}, nil, err
 		}
+	}
+	if q.IncludeSources {
+		s.traceSources(aggs, records, segments)
+	}
+	if q.ExpandOrg {
+		nodeWarnings, err := s.expandSegments(ctx, aggs, degraded)
+		if err != nil {
+			return []Aggregate{}, nil, err
+		}
+		warnings = append(warnings, nodeWarnings...)
+	}
+	return aggs, warnings, nil
+}
 
-		if earliestStartDate == "" || m.StartDate < earliestStartDate {
-			earliestStartDate = m.StartDate
+// expandSegments expands the organization nodes of aggs, see ExpandNodes.
+func (s *Service) expandSegments(ctx context.Context, aggs []Aggregate, degraded bool) ([]dto.Warning, error) {
+	nodeIds := []string{}
+	for _, agg := range aggs {
+		nodeIds = append(nodeIds, agg.OrganizationNode)
+	}
+	nodes, warnings, err := s.ExpandNodes(ctx, nodeIds, degraded)
+	if err != nil {
+		return nil, err
+	}
+	for i := range aggs {
+		if node, exists := nodes[aggs[i].OrganizationNode]; exists {
+			aggs[i].Org = &node
 		}
 	}
+	return warnings, nil
+}
 
-	sort.Sort(sort.Reverse(datesort.DateStringSlice(endDates)))
-	for i := 0; i < len(endDates); i++ {
-		var startDate = ""
-		if i == len(endDates)-1 {
-			startDate = earliestStartDate
-		} else {
-			ds, err := datestr.NewFromString(endDates[i+1])
+// ExpandNodes resolves the name, type and ancestors of organization nodes.
+// Blank and repeated IDs are dropped, so that every node is looked up once,
+// with at most app.batch.concurrency lineages asked for at once. Lineages go
+// through the cache of the org client, which is shared across requests.
+// Nodes unknown to connect-org are left out of the map. When degraded is set
+// and connect-org cannot be reached, the nodes it failed on are left out too
+// and a warning says so.
+func (s *Service) ExpandNodes(
+	ctx context.Context,
+	nodeIds []string,
+	degraded bool,
+) (map[string]Node, []dto.Warning, error) {
+	nodeIds = batch.Distinct(nodeIds)
+	results := batch.Map(
+		ctx,
+		nodeIds,
+		s.ConfigService.ConfigRepository.GetBatchConcurrency(),
+		func(ctx context.Context, nodeId string) (*Node, []dto.Warning, error) {
+			lineage, err := s.OrgClient.GetNodeLineageById(ctx, nodeId)
 			if err != nil {
-				return []Aggregate{}, err
+				return nil, nil, err
 			}
-			startDate = ds.OffsetAndClone(+1).AsString()
+			return s.newNode(nodeId, lineage.Data), nil, nil
+		},
+	)
+
+	nodes := map[string]Node{}
+	warnings := []dto.Warning{}
+	for i, result := range results {
+		switch {
+		case result.Err == nil:
+			if result.Data != nil {
+				nodes[nodeIds[i]] = *result.Data
+			}
+		case errors.Is(result.Err, localerror.ErrRemoteNotFound):
+		case degraded && localerror.IsRemoteFailure(result.Err):
+			warnings = append(warnings, localerror.NewWarning(localerror.WarningSourceOrg, result.Err))
+		default:
+			return nil, nil, result.Err
 		}
-		aggs = append(aggs, Aggregate{
-			StartDate: startDate,
-			EndDate:   endDates[i],
-		})
 	}
+	return nodes, warnings, nil
+}
 
-	for i, a := range aggs {
-		aggsInterval, err := dateinterval.NewFromStrings(a.StartDate, a.EndDate)
+// newNode finds nodeId in its lineage and names its ancestors after the IDs
+// of its hierarchy. Ancestors missing from the lineage keep their ID only.
+// It gives nil when the lineage does not hold the node.
+func (s *Service) newNode(nodeId string, lineage *orgclient.NodeTreeViewEntity) *Node {
+	byId := map[string]orgclient.NodeViewEntity{}
+	pending := []*orgclient.NodeTreeViewEntity{lineage}
+	for len(pending) > 0 {
+		n := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+		if n == nil {
+			continue
+		}
+		if n.Data != nil {
+			byId[n.Data.Id] = *n.Data
+		}
+		for i := range n.Children {
+			pending = append(pending, &n.Children[i])
+		}
+	}
+	entity, exists := byId[nodeId]
+	if !exists {
+		return nil
+	}
+
+	types := s.ConfigService.ConfigRepository.GetCareerNodeTypes()
+	ref := func(id string, depth int) NodeRef {
+		r := NodeRef{Id: id, Name: byId[id].Name}
+		if depth < len(types) {
+			r.Type = types[depth]
+		}
+		return r
+	}
+	ids := strings.Split(entity.Hierarchy, ".")
+	node := &Node{
+		NodeRef: ref(nodeId, len(ids)-1),
+		Path:    []NodeRef{},
+	}
+	for depth, id := range ids[:len(ids)-1] {
+		node.Path = append(node.Path, ref(id, depth))
+	}
+	return node
+}
+
+// traceSources cites, for every segment, the records that won the sweep
+// somewhere within it and hold its values, and links gradings and titlings
+// to their GET endpoints.
+func (s *Service) traceSources(aggs []Aggregate, records []historyRecord, segments []historySegment) {
+	for i := range aggs {
+		values := [kindCount]string{aggs[i].Grade, aggs[i].Title, aggs[i].OrganizationNode}
+		sources := &Sources{Gradings: []Source{}, Titlings: []Source{}, Memberships: []Source{}}
+		cited := map[int]bool{}
+		for _, seg := range segments {
+			if (aggs[i].EndDate != "" && seg.StartDate > aggs[i].EndDate) || (seg.EndDate != "" && seg.EndDate < aggs[i].StartDate) {
+				continue
+			}
+			for kind, r := range seg.records {
+				if r == -1 || cited[r] || records[r].value != values[kind] {
+					continue
+				}
+				cited[r] = true
+				switch kind {
+				case kindGrade:
+					sources.Gradings = append(sources.Gradings, Source{Id: records[r].id, Href: fmt.Sprintf("/gradings/%d", records[r].id)})
+				case kindTitle:
+					sources.Titlings = append(sources.Titlings, Source{Id: records[r].id, Href: fmt.Sprintf("/titlings/%d", records[r].id)})
+				case kindNode:
+					sources.Memberships = append(sources.Memberships, Source{Id: records[r].id})
+				}
+			}
+		}
+		aggs[i].Sources = sources
+	}
+}
+
+func (s *Service) checkTimelineQuery(q TimelineQuery) error {
+	if q.Granularity != "" && !slices.Contains(Granularities, q.Granularity) {
+		return fmt.Errorf("%w: granularity must be one of %v, got %q", localerror.ErrBadQueryParam, Granularities, q.Granularity)
+	}
+	for name, d := range map[string]string{"from": q.From, "to": q.To} {
+		_, err := datestr.NewFromString(d)
 		if err != nil {
-			return []Aggregate{}, err
+			return fmt.Errorf("%w: %s: %w", localerror.ErrBadQueryParam, name, err)
 		}
-		for _, g := range gradings {
-			gradeInterval, err := dateinterval.NewFromStrings(g.StartDate, g.EndDate)
-			if err != nil {
-				return []Aggregate{}, err
+	}
+	if q.From != "" && q.To != "" && q.From > q.To {
+		return fmt.Errorf("%w: from is after to", localerror.ErrBadDateSequence)
+	}
+	return nil
+}
+
+// clipSegments drops the segments outside of from and to and cuts the ones
+// crossing them. An empty from or to leaves that side open.
+func (s *Service) clipSegments(aggs []Aggregate, from string, to string) []Aggregate {
+	clipped := []Aggregate{}
+	for _, a := range aggs {
+		if (to != "" && a.StartDate > to) || (from != "" && a.EndDate != "" && a.EndDate < from) {
+			continue
+		}
+		if from != "" && a.StartDate < from {
+			a.StartDate = from
+		}
+		if to != "" && (a.EndDate == "" || a.EndDate > to) {
+			a.EndDate = to
+		}
+		clipped = append(clipped, a)
+	}
+	return clipped
+}
+
+// bucketSegments sums segments ordered latest first up per month or year.
+// An open-ended career is summed up until now. Buckets keep the bounds of
+// the segments, so the first one starts with the career and the last one is
+// open-ended when the career is.
+func (s *Service) bucketSegments(aggs []Aggregate, granularity string, now time.Time) []Aggregate {
+	if len(aggs) == 0 {
+		return aggs
+	}
+	parse := func(d string) time.Time {
+		t, _ := time.Parse(datestr.FormatDefault, d)
+		return t
+	}
+	next := func(t time.Time) time.Time {
+		if granularity == GranularityYear {
+			return t.AddDate(1, 0, 0)
+		}
+		return t.AddDate(0, 1, 0)
+	}
+
+	first := parse(aggs[len(aggs)-1].StartDate)
+	horizon := parse(now.Format(datestr.FormatDefault))
+	if aggs[0].EndDate != "" {
+		horizon = parse(aggs[0].EndDate)
+	}
+	if start := parse(aggs[0].StartDate); horizon.Before(start) {
+		horizon = start
+	}
+	bucketStart := time.Date(first.Year(), first.Month(), 1, 0, 0, 0, 0, time.UTC)
+	if granularity == GranularityYear {
+		bucketStart = time.Date(first.Year(), 1, 1, 0, 0, 0, 0, time.UTC)
+	}
+
+	buckets := []Aggregate{}
+	for bs := bucketStart; !bs.After(horizon); bs = next(bs) {
+		be := next(bs).AddDate(0, 0, -1)
+		days := map[Aggregate]int{}
+		latest := map[Aggregate]string{}
+		for _, a := range aggs {
+			start := parse(a.StartDate)
+			end := horizon
+			if a.EndDate != "" {
+				end = parse(a.EndDate)
+			}
+			if start.Before(bs) {
+				start = bs
+			}
+			if end.After(be) {
+				end = be
+			}
+			if end.Before(start) {
+				continue
 			}
-			if gradeInterval.IsEncompassingInterval(aggsInterval) {
-				aggs[i].Grade = g.Grade
-				break
+			key := Aggregate{Grade: a.Grade, Title: a.Title, OrganizationNode: a.OrganizationNode}
+			days[key] += int(end.Sub(start).Hours()/24) + 1
+			if a.StartDate > latest[key] {
+				latest[key] = a.StartDate
 			}
 		}
-		for _, t := range titlings {
-			titleInterval, err := dateinterval.NewFromStrings(t.StartDate, t.EndDate)
+
+		var dominant *Aggregate
+		for key := range days {
+			if dominant == nil || days[key] > days[*dominant] ||
+				(days[key] == days[*dominant] && latest[key] > latest[*dominant]) {
+				k := key
+				dominant = &k
+			}
+		}
+		if dominant == nil {
+			continue
+		}
+
+		bucket := *dominant
+		bucket.StartDate = bs.Format(datestr.FormatDefault)
+		if bs.Before(first) {
+			bucket.StartDate = first.Format(datestr.FormatDefault)
+		}
+		bucket.EndDate = be.Format(datestr.FormatDefault)
+		if !be.Before(horizon) {
+			bucket.EndDate = aggs[0].EndDate
+		}
+		buckets = append(buckets, bucket)
+	}
+
+	slices.Reverse(buckets)
+	return buckets
+}
+
+// compactSegments merges the segments ordered latest first that follow each
+// other and hold the same values.
+func (s *Service) compactSegments(aggs []Aggregate) ([]Aggregate, error) {
+	compacted := []Aggregate{}
+	for _, a := range aggs {
+		last := len(compacted) - 1
+		if last >= 0 && a.EndDate != "" && sameValues(compacted[last], a) {
+			ed, err := datestr.NewFromString(a.EndDate)
 			if err != nil {
 				return []Aggregate{}, err
 			}
-			if titleInterval.IsEncompassingInterval(aggsInterval) {
-				aggs[i].Title = t.Title
-				break
+			if ed.OffsetAndClone(+1).AsString() == compacted[last].StartDate {
+				compacted[last].StartDate = a.StartDate
+				continue
 			}
 		}
+		compacted = append(compacted, a)
+	}
+	return compacted, nil
+}
 
-		for _, m := range memberships {
-			membershipInterval, err := dateinterval.NewFromStrings(m.StartDate, m.EndDate)
-			if err != nil {
-				return []Aggregate{}, err
+// RetrieveByEhidsOrderByStartDateDesc builds the careers of many EHIDs.
+// Gradings and titlings are read with one query each while membership
+// histories are looked up with at most concurrency calls at once. A failing
+// query fails the whole batch; any other failure only fails its EHID.
+func (s *Service) RetrieveByEhidsOrderByStartDateDesc(
+	ctx context.Context,
+	ehids []string,
+	concurrency int,
+	degraded bool,
+) ([]batch.Result[[]Aggregate], error) {
+	var (
+		gradings    map[string][]grading.ViewEntity
+		titlings    map[string][]titling.ViewEntity
+		memberships []batch.Result[[]liborgc.MembershipViewEntity]
+	)
+	eg, ctx := errgroup.WithContext(ctx)
+	eg.Go(func() (err error) {
+		gradings, err = s.GradingService.RetrieveByEhidsOrderByStartDate(ctx, ehids, grading.OrderDesc)
+		return err
+	})
+	eg.Go(func() (err error) {
+		titlings, err = s.TitlingService.RetrieveByEhidsOrderByStartDate(ctx, ehids, titling.OrderDesc)
+		return err
+	})
+	eg.Go(func() error {
+		memberships = batch.Map(ctx, ehids, concurrency, func(
+			ctx context.Context,
+			ehid string,
+		) (*[]liborgc.MembershipViewEntity, []dto.Warning, error) {
+			m, warnings, err := s.retrieveMemberships(degraded, func() (*liborgc.GetMemberHistoryResponseDto, error) {
+				return s.OrgClient.GetMemberHistoryByEhidOrderByStartDateDesc(ctx, ehid)
+			})
+			return &m, warnings, err
+		})
+		return nil
+	})
+	err := eg.Wait()
+	if err != nil {
+		return nil, err
+	}
+
+	results := []batch.Result[[]Aggregate]{}
+	for _, m := range memberships {
+		result := batch.Result[[]Aggregate]{
+			Ehid:     m.Ehid,
+			Warnings: m.Warnings,
+			Err:      m.Err,
+		}
+		if result.Err == nil {
+			aggs, err := s.mergeHistories(gradings[m.Ehid], titlings[m.Ehid], *m.Data)
+			result.Data, result.Err = &aggs, err
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// RetrieveTenureByEhid tells how long ehid has been employed as of asOf and
+// since when they hold their grade, title and organization node. Grade,
+// title or node are left out when none is held on asOf. When degraded is set
+// and connect-org cannot be reached, the node is left out and a warning says
+// so.
+func (s *Service) RetrieveTenureByEhid(
+	ctx context.Context,
+	ehid string,
+	asOf *datestr.Class,
+	degraded bool,
+) (*Tenure, []dto.Warning, error) {
+	gradings, titlings, memberships, warnings, err := s.retrieveHistories(ctx, ehid, txtime.NewCurrent(), degraded)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	gradeSpans := []dateinterval.Span{}
+	for _, g := range gradings {
+		interval, err := dateinterval.NewFromStrings(g.StartDate, g.EndDate)
+		if err != nil {
+			return nil, nil, err
+		}
+		gradeSpans = append(gradeSpans, dateinterval.Span{Class: interval, Value: g.Grade})
+	}
+	gradeSpans = dateinterval.MergeSpans(gradeSpans)
+	if len(gradeSpans) == 0 || gradeSpans[0].StartDate.IsAfter(asOf) {
+		return nil, nil, gorm.ErrRecordNotFound
+	}
+
+	titleSpans := []dateinterval.Span{}
+	for _, t := range titlings {
+		interval, err := dateinterval.NewFromStrings(t.StartDate, t.EndDate)
+		if err != nil {
+			return nil, nil, err
+		}
+		titleSpans = append(titleSpans, dateinterval.Span{Class: interval, Value: t.Title})
+	}
+
+	nodeSpans := []dateinterval.Span{}
+	for _, m := range memberships {
+		interval, err := dateinterval.NewFromStrings(m.StartDate, m.EndDate)
+		if err != nil {
+			return nil, nil, err
+		}
+		nodeSpans = append(nodeSpans, dateinterval.Span{Class: interval, Value: m.NodeId})
+	}
+
+	tenure := &Tenure{
+		Ehid: ehid,
+		AsOf: asOf.AsString(),
+		Total: TenureSpan{
+			Since: gradeSpans[0].StartDate.AsString(),
+		},
+		Grade:            s.spanHeldOn(gradeSpans, asOf),
+		Title:            s.spanHeldOn(titleSpans, asOf),
+		OrganizationNode: s.spanHeldOn(nodeSpans, asOf),
+	}
+	for _, g := range gradeSpans {
+		tenure.Total.Days += g.DaysUntil(asOf)
+	}
+	return tenure, warnings, nil
+}
+
+// spanHeldOn finds the value held on d, counting the days since it has been
+// held without interruption.
+func (s *Service) spanHeldOn(spans []dateinterval.Span, d *datestr.Class) *TenureSpan {
+	for _, span := range dateinterval.MergeSpans(spans) {
+		if span.IsEncompassingDate(d) {
+			return &TenureSpan{
+				Value: span.Value,
+				Since: span.StartDate.AsString(),
+				Days:  span.DaysUntil(d),
 			}
-			if membershipInterval.IsEncompassingInterval(aggsInterval) {
-				aggs[i].OrganizationNode = m.NodeId
-				break
+		}
+	}
+	return nil
+}
+
+// retrieveMemberships turns a failure of connect-org into a warning when
+// degraded is set.
+func (s *Service) retrieveMemberships(
+	degraded bool,
+	call func() (*liborgc.GetMemberHistoryResponseDto, error),
+) ([]liborgc.MembershipViewEntity, []dto.Warning, error) {
+	m, err := call()
+	if err != nil {
+		if degraded && localerror.IsRemoteFailure(err) {
+			return []liborgc.MembershipViewEntity{}, []dto.Warning{
+				localerror.NewWarning(localerror.WarningSourceOrg, err),
+			}, nil
+		}
+		return nil, nil, err
+	}
+	return *m.Data, nil, nil
+}
+
+// mergeHistories cuts a career into segments as sweepHistories does.
+func (s *Service) mergeHistories(
+	gradings []grading.ViewEntity,
+	titlings []titling.ViewEntity,
+	memberships []liborgc.MembershipViewEntity,
+) ([]Aggregate, error) {
+	_, segments, err := s.sweepHistories(gradings, titlings, memberships)
+	if err != nil {
+		return []Aggregate{}, err
+	}
+	return segmentAggregates(segments), nil
+}
+
+// sweepHistories cuts a career into segments by sweeping over the days on
+// which a grading, titling or membership starts or the day after it ends.
+// Every such boundary starts a segment, even when the values held across it
+// stay the same, while the gaps where nothing is held at all are dropped.
+// Should records of the same kind overlap, the one that started last wins,
+// then the one listed first. Segments are ordered latest first and tell the
+// records that won them.
+func (s *Service) sweepHistories(
+	gradings []grading.ViewEntity,
+	titlings []titling.ViewEntity,
+	memberships []liborgc.MembershipViewEntity,
+) ([]historyRecord, []historySegment, error) {
+	records := []historyRecord{}
+	add := func(kind int, id int, value string, startDate string, endDate string) error {
+		interval, err := dateinterval.NewFromStrings(startDate, endDate)
+		if err != nil {
+			return err
+		}
+		r := historyRecord{kind: kind, id: id, value: value, startDate: startDate}
+		if !interval.EndDate.IsIndeterminate() {
+			r.until = interval.EndDate.OffsetAndClone(+1).AsString()
+		}
+		records = append(records, r)
+		return nil
+	}
+	for _, g := range gradings {
+		if err := add(kindGrade, g.Id, g.Grade, g.StartDate, g.EndDate); err != nil {
+			return nil, nil, err
+		}
+	}
+	for _, t := range titlings {
+		if err := add(kindTitle, t.Id, t.Title, t.StartDate, t.EndDate); err != nil {
+			return nil, nil, err
+		}
+	}
+	for _, m := range memberships {
+		if err := add(kindNode, m.Id, m.NodeId, m.StartDate, m.EndDate); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	events := []historyEvent{}
+	for i, r := range records {
+		events = append(events, historyEvent{date: r.startDate, record: i, starts: true})
+		if r.until != "" {
+			events = append(events, historyEvent{date: r.until, record: i})
+		}
+	}
+	sort.Slice(events, func(i, j int) bool {
+		return events[i].date < events[j].date
+	})
+
+	// points holds the records winning from each boundary on.
+	points := []historySegment{}
+	active := [kindCount][]int{}
+	for i := 0; i < len(events); {
+		date := events[i].date
+		for ; i < len(events) && events[i].date == date; i++ {
+			e := events[i]
+			kind := records[e.record].kind
+			if e.starts {
+				active[kind] = append(active[kind], e.record)
+			} else {
+				active[kind] = slices.DeleteFunc(active[kind], func(r int) bool {
+					return r == e.record
+				})
 			}
 		}
+
+		point := historySegment{Aggregate: Aggregate{StartDate: date}}
+		for kind := range point.records {
+			point.records[kind] = latestRecord(records, active[kind])
+		}
+		point.Grade = recordValue(records, point.records[kindGrade])
+		point.Title = recordValue(records, point.records[kindTitle])
+		point.OrganizationNode = recordValue(records, point.records[kindNode])
+		points = append(points, point)
+	}
+
+	segments := []historySegment{}
+	for i := len(points) - 1; i >= 0; i-- {
+		if sameValues(points[i].Aggregate, Aggregate{}) {
+			continue
+		}
+		segment := points[i]
+		if i+1 < len(points) {
+			next, err := datestr.NewFromString(points[i+1].StartDate)
+			if err != nil {
+				return nil, nil, err
+			}
+			segment.EndDate = next.OffsetAndClone(-1).AsString()
+		}
+		segments = append(segments, segment)
+	}
+	return records, segments, nil
+}
+
+func segmentAggregates(segments []historySegment) []Aggregate {
+	aggs := []Aggregate{}
+	for _, seg := range segments {
+		aggs = append(aggs, seg.Aggregate)
 	}
+	return aggs
+}
+
+const (
+	kindGrade = iota
+	kindTitle
+	kindNode
+	kindCount
+)
+
+// historyRecord is a grading, titling or membership held from startDate up
+// to the day before until, or for good when until is empty.
+type historyRecord struct {
+	kind      int
+	id        int
+	value     string
+	startDate string
+	until     string
+}
+
+// historySegment is a segment of a career along with the records holding
+// its grade, title and node, indexed by kind, or -1 where nothing is held.
+type historySegment struct {
+	Aggregate
+	records [kindCount]int
+}
+
+// historyEvent is the start of a record or the day after its end.
+type historyEvent struct {
+	date   string
+	record int
+	starts bool
+}
+
+// latestRecord picks the active record that started last, or -1 when none
+// is active. Ties go to the record listed first.
+func latestRecord(records []historyRecord, active []int) int {
+	latest := -1
+	for _, r := range active {
+		if latest == -1 ||
+			records[r].startDate > records[latest].startDate ||
+			(records[r].startDate == records[latest].startDate && r < latest) {
+			latest = r
+		}
+	}
+	return latest
+}
+
+func recordValue(records []historyRecord, r int) string {
+	if r == -1 {
+		return ""
+	}
+	return records[r].value
+}
 
-	return aggs, nil
+func sameValues(a Aggregate, b Aggregate) bool {
+	return a.Grade == b.Grade && a.Title == b.Title && a.OrganizationNode == b.OrganizationNode
 }
 
 func (s *Service) maxDate(dates []datestr.Class) *datestr.Class {
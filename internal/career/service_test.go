@@ -0,0 +1,789 @@
+package career
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mrexmelle/connect-emp/internal/config"
+	"github.com/mrexmelle/connect-emp/internal/datestr"
+	"github.com/mrexmelle/connect-emp/internal/grading"
+	"github.com/mrexmelle/connect-emp/internal/localerror"
+	"github.com/mrexmelle/connect-emp/internal/orgclient"
+	"github.com/mrexmelle/connect-emp/internal/titling"
+	"github.com/mrexmelle/connect-emp/internal/txtime"
+	"github.com/mrexmelle/connect-org/pkg/liborgc"
+	"gorm.io/gorm"
+)
+
+// latency stands for a round trip. It returns early with the context error
+// when the context is canceled.
+func latency(ctx context.Context, d time.Duration) error {
+	if d == 0 {
+		return nil
+	}
+	select {
+	case <-time.After(d):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+type gradingRepositoryStub struct {
+	grading.Repository
+	Entities []grading.Entity
+	Delay    time.Duration
+}
+
+func (r *gradingRepositoryStub) FindCurrentByEhid(ctx context.Context, ehid string) (*grading.Entity, error) {
+	if err := latency(ctx, r.Delay); err != nil {
+		return nil, err
+	}
+	for _, e := range r.Entities {
+		if !e.EndDate.Valid {
+			return &e, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *gradingRepositoryStub) FindByEhidOrderByStartDate(ctx context.Context, ehid string, orderDir string) ([]grading.Entity, error) {
+	if err := latency(ctx, r.Delay); err != nil {
+		return nil, err
+	}
+	return r.Entities, nil
+}
+
+func (r *gradingRepositoryStub) FindByEhidsOrderByStartDate(ctx context.Context, ehids []string, orderDir string) ([]grading.Entity, error) {
+	if err := latency(ctx, r.Delay); err != nil {
+		return nil, err
+	}
+	entities := []grading.Entity{}
+	for _, e := range r.Entities {
+		if slices.Contains(ehids, e.Ehid) {
+			entities = append(entities, e)
+		}
+	}
+	return entities, nil
+}
+
+type titlingRepositoryStub struct {
+	titling.Repository
+	Entities []titling.Entity
+	Delay    time.Duration
+	Err      error
+}
+
+func (r *titlingRepositoryStub) FindCurrentByEhid(ctx context.Context, ehid string) (*titling.Entity, error) {
+	if err := latency(ctx, r.Delay); err != nil {
+		return nil, err
+	}
+	if r.Err != nil {
+		return nil, r.Err
+	}
+	for _, e := range r.Entities {
+		if !e.EndDate.Valid {
+			return &e, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *titlingRepositoryStub) FindByEhidOrderByStartDate(ctx context.Context, ehid string, orderDir string) ([]titling.Entity, error) {
+	if err := latency(ctx, r.Delay); err != nil {
+		return nil, err
+	}
+	if r.Err != nil {
+		return nil, r.Err
+	}
+	return r.Entities, nil
+}
+
+func (r *titlingRepositoryStub) FindByEhidsOrderByStartDate(ctx context.Context, ehids []string, orderDir string) ([]titling.Entity, error) {
+	if err := latency(ctx, r.Delay); err != nil {
+		return nil, err
+	}
+	if r.Err != nil {
+		return nil, r.Err
+	}
+	entities := []titling.Entity{}
+	for _, e := range r.Entities {
+		if slices.Contains(ehids, e.Ehid) {
+			entities = append(entities, e)
+		}
+	}
+	return entities, nil
+}
+
+type orgClientStub struct {
+	orgclient.Client
+	Memberships  []liborgc.MembershipViewEntity
+	Nodes        []orgclient.NodeViewEntity
+	Err          error
+	LineageErr   error
+	LineageCalls atomic.Int64
+	Delay        time.Duration
+	Canceled     bool
+}
+
+func (c *orgClientStub) GetMemberHistoryByEhidOrderByStartDateDesc(
+	ctx context.Context,
+	ehid string,
+) (*liborgc.GetMemberHistoryResponseDto, error) {
+	if err := latency(ctx, c.Delay); err != nil {
+		c.Canceled = true
+		return nil, err
+	}
+	if c.Err != nil {
+		return nil, c.Err
+	}
+	history := []liborgc.MembershipViewEntity{}
+	for _, m := range c.Memberships {
+		if m.Ehid == ehid {
+			history = append(history, m)
+		}
+	}
+	return &liborgc.GetMemberHistoryResponseDto{Data: &history}, nil
+}
+
+func (c *orgClientStub) GetMemberNodesByEhid(
+	ctx context.Context,
+	ehid string,
+) (*liborgc.GetMemberNodesResponseDto, error) {
+	if err := latency(ctx, c.Delay); err != nil {
+		c.Canceled = true
+		return nil, err
+	}
+	if c.Err != nil {
+		return nil, c.Err
+	}
+	current := []liborgc.MembershipViewEntity{}
+	for _, m := range c.Memberships {
+		if m.Ehid == ehid && m.EndDate == "" {
+			current = append(current, m)
+		}
+	}
+	return &liborgc.GetMemberNodesResponseDto{Data: &current}, nil
+}
+
+// GetNodeLineageById nests the ancestors of a node listed in Nodes from the
+// root down, the way connect-org does.
+func (c *orgClientStub) GetNodeLineageById(
+	ctx context.Context,
+	nodeId string,
+) (*orgclient.GetNodeLineageResponseDto, error) {
+	c.LineageCalls.Add(1)
+	if c.LineageErr != nil {
+		return nil, c.LineageErr
+	}
+	byId := map[string]orgclient.NodeViewEntity{}
+	for _, n := range c.Nodes {
+		byId[n.Id] = n
+	}
+	node, exists := byId[nodeId]
+	if !exists {
+		return nil, localerror.NewRemoteError("org", localerror.ErrSvcCodeRecordNotFound, "no node "+nodeId)
+	}
+	ids := strings.Split(node.Hierarchy, ".")
+	var lineage *orgclient.NodeTreeViewEntity
+	for i := len(ids) - 1; i >= 0; i-- {
+		n := byId[ids[i]]
+		parent := &orgclient.NodeTreeViewEntity{Data: &n, Children: []orgclient.NodeTreeViewEntity{}}
+		if lineage != nil {
+			parent.Children = append(parent.Children, *lineage)
+		}
+		lineage = parent
+	}
+	return &orgclient.GetNodeLineageResponseDto{Data: lineage}, nil
+}
+
+func date(s string) time.Time {
+	t, _ := time.Parse("2006-01-02", s)
+	return t
+}
+
+func endDate(s string) sql.NullTime {
+	if s == "" {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: date(s), Valid: true}
+}
+
+func newTestService(org *orgClientStub) *Service {
+	return newTestServiceWithRepositories(&gradingRepositoryStub{}, &titlingRepositoryStub{}, org)
+}
+
+// newTestServiceWithRepositories fills the given repository stubs with the
+// career of u001 and builds a service on top of them.
+func newTestServiceWithRepositories(
+	gr *gradingRepositoryStub,
+	tr *titlingRepositoryStub,
+	org *orgClientStub,
+) *Service {
+	gr.Entities = []grading.Entity{
+		{Id: 2, Ehid: "u001", StartDate: date("2023-01-01"), Grade: "E5"},
+		{Id: 1, Ehid: "u001", StartDate: date("2021-01-01"), EndDate: endDate("2022-12-31"), Grade: "E4"},
+	}
+	tr.Entities = []titling.Entity{
+		{Id: 1, Ehid: "u001", StartDate: date("2021-01-01"), Title: "Engineer"},
+	}
+	return NewService(
+		nil,
+		grading.NewService(nil, gr),
+		titling.NewService(nil, tr),
+		org,
+	)
+}
+
+func TestRetrieveCurrentByEhid(t *testing.T) {
+	s := newTestService(&orgClientStub{Memberships: []liborgc.MembershipViewEntity{
+		{Id: 1, Ehid: "u001", StartDate: "2021-01-01", NodeId: "ENG"},
+	}})
+
+	agg, warnings, err := s.RetrieveCurrentByEhid(context.Background(), "u001", false)
+	if err != nil || len(warnings) != 0 {
+		t.Fatal(err)
+	}
+	if agg.Grade != "E5" || agg.Title != "Engineer" || agg.OrganizationNode != "ENG" || agg.StartDate != "2023-01-01" {
+		t.Errorf("unexpected aggregate %+v", agg)
+	}
+}
+
+func TestRetrieveCurrentByEhidWithoutMembership(t *testing.T) {
+	s := newTestService(&orgClientStub{})
+
+	agg, _, err := s.RetrieveCurrentByEhid(context.Background(), "u001", true)
+	if agg != nil || !errors.Is(err, localerror.ErrMembershipNotFound) {
+		t.Errorf("expected ErrMembershipNotFound, got %+v, %v", agg, err)
+	}
+}
+
+func TestRetrieveByEhidOrderByStartDateDescPropagatesRemoteErrors(t *testing.T) {
+	for _, expected := range []error{
+		localerror.NewRemoteError("org", localerror.ErrSvcCodeRecordNotFound, "no such member"),
+		localerror.NewRemoteError("org", "internal_error", "boom"),
+		localerror.ErrHttpClient,
+	} {
+		s := newTestService(&orgClientStub{Err: expected})
+
+		_, err := s.RetrieveByEhidOrderByStartDateDesc(context.Background(), "u001")
+		if !errors.Is(err, expected) {
+			t.Errorf("expected %v, got %v", expected, err)
+		}
+	}
+}
+
+func TestRetrieveCurrentByEhidDegraded(t *testing.T) {
+	s := newTestService(&orgClientStub{Err: fmt.Errorf("%w: org down", localerror.ErrHttpClient)})
+
+	_, _, err := s.RetrieveCurrentByEhid(context.Background(), "u001", false)
+	if !errors.Is(err, localerror.ErrHttpClient) {
+		t.Fatalf("expected strict mode to fail, got %v", err)
+	}
+
+	agg, warnings, err := s.RetrieveCurrentByEhid(context.Background(), "u001", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if agg.Grade != "E5" || agg.Title != "Engineer" || agg.OrganizationNode != "" {
+		t.Errorf("unexpected aggregate %+v", agg)
+	}
+	if len(warnings) != 1 || warnings[0].Source != localerror.WarningSourceOrg || warnings[0].Code != localerror.ErrHttpClient.Error() {
+		t.Errorf("unexpected warnings %+v", warnings)
+	}
+}
+
+func TestRetrieveByEhidAsKnownAtOrderByStartDateDescDegraded(t *testing.T) {
+	s := newTestService(&orgClientStub{Err: localerror.NewRemoteError("org", "internal_error", "boom")})
+
+	aggs, warnings, err := s.RetrieveByEhidAsKnownAtOrderByStartDateDesc(context.Background(), "u001", txtime.NewCurrent(), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(aggs) != 2 || aggs[0].Grade != "E5" || aggs[1].Grade != "E4" {
+		t.Errorf("unexpected career %+v", aggs)
+	}
+	if len(warnings) != 1 || warnings[0].Code != localerror.ErrRemoteError.Error() {
+		t.Errorf("unexpected warnings %+v", warnings)
+	}
+
+	s = newTestService(&orgClientStub{Err: localerror.NewRemoteError("org", localerror.ErrSvcCodeRecordNotFound, "no member")})
+	_, _, err = s.RetrieveByEhidAsKnownAtOrderByStartDateDesc(context.Background(), "u001", txtime.NewCurrent(), true)
+	if !errors.Is(err, localerror.ErrRemoteNotFound) {
+		t.Errorf("expected not found to stay an error in degraded mode, got %v", err)
+	}
+}
+
+func TestRetrieveCurrentByEhidCancelsOnFirstError(t *testing.T) {
+	failure := errors.New("titlings unavailable")
+	org := &orgClientStub{Delay: time.Second}
+	s := newTestServiceWithRepositories(
+		&gradingRepositoryStub{},
+		&titlingRepositoryStub{Err: failure},
+		org,
+	)
+
+	start := time.Now()
+	_, _, err := s.RetrieveCurrentByEhid(context.Background(), "u001", false)
+	if !errors.Is(err, failure) {
+		t.Fatalf("expected %v, got %v", failure, err)
+	}
+	if !org.Canceled || time.Since(start) >= time.Second {
+		t.Errorf("expected the org lookup to be canceled, took %s", time.Since(start))
+	}
+}
+
+func TestRetrieveByEhidsOrderByStartDateDesc(t *testing.T) {
+	s := newTestService(&orgClientStub{Memberships: []liborgc.MembershipViewEntity{
+		{Id: 1, Ehid: "u001", StartDate: "2021-01-01", NodeId: "ENG"},
+	}})
+
+	results, err := s.RetrieveByEhidsOrderByStartDateDesc(context.Background(), []string{"u001", "u002"}, 2, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 2 || results[0].Ehid != "u001" || results[1].Ehid != "u002" {
+		t.Fatalf("expected results in request order, got %+v", results)
+	}
+	if results[0].Err != nil || len(*results[0].Data) != 2 || (*results[0].Data)[0].OrganizationNode != "ENG" {
+		t.Errorf("unexpected career of u001 %+v", results[0])
+	}
+	if results[1].Err != nil || len(*results[1].Data) != 0 {
+		t.Errorf("expected an empty career for u002, got %+v", results[1])
+	}
+
+	_, err = newTestServiceWithRepositories(
+		&gradingRepositoryStub{},
+		&titlingRepositoryStub{Err: errors.New("titlings unavailable")},
+		&orgClientStub{},
+	).RetrieveByEhidsOrderByStartDateDesc(context.Background(), []string{"u001"}, 2, false)
+	if err == nil {
+		t.Error("expected a failing query to fail the whole batch")
+	}
+}
+
+func TestRetrieveTenureByEhid(t *testing.T) {
+	s := newTestService(&orgClientStub{Memberships: []liborgc.MembershipViewEntity{
+		{Id: 2, Ehid: "u001", StartDate: "2022-07-01", NodeId: "ENG"},
+		{Id: 1, Ehid: "u001", StartDate: "2021-01-01", EndDate: "2022-06-30", NodeId: "HR"},
+	}})
+	asOf, _ := datestr.NewFromString("2024-01-01")
+
+	tenure, warnings, err := s.RetrieveTenureByEhid(context.Background(), "u001", asOf, false)
+	if err != nil || len(warnings) != 0 {
+		t.Fatal(err)
+	}
+	if tenure.Total != (TenureSpan{Since: "2021-01-01", Days: 1096}) {
+		t.Errorf("unexpected total tenure %+v", tenure.Total)
+	}
+	if *tenure.Grade != (TenureSpan{Value: "E5", Since: "2023-01-01", Days: 366}) {
+		t.Errorf("unexpected time in grade %+v", tenure.Grade)
+	}
+	if *tenure.Title != (TenureSpan{Value: "Engineer", Since: "2021-01-01", Days: 1096}) {
+		t.Errorf("unexpected time in title %+v", tenure.Title)
+	}
+	if *tenure.OrganizationNode != (TenureSpan{Value: "ENG", Since: "2022-07-01", Days: 550}) {
+		t.Errorf("unexpected time in node %+v", tenure.OrganizationNode)
+	}
+
+	beforeHire, _ := datestr.NewFromString("2020-12-31")
+	_, _, err = s.RetrieveTenureByEhid(context.Background(), "u001", beforeHire, false)
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("expected ErrRecordNotFound before the first grading, got %v", err)
+	}
+}
+
+func TestRetrieveTenureByEhidDegraded(t *testing.T) {
+	s := newTestService(&orgClientStub{Err: fmt.Errorf("%w: org down", localerror.ErrHttpClient)})
+	asOf, _ := datestr.NewFromString("2024-01-01")
+
+	tenure, warnings, err := s.RetrieveTenureByEhid(context.Background(), "u001", asOf, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tenure.Grade == nil || tenure.OrganizationNode != nil {
+		t.Errorf("expected a tenure without node, got %+v", tenure)
+	}
+	if len(warnings) != 1 || warnings[0].Source != localerror.WarningSourceOrg {
+		t.Errorf("unexpected warnings %+v", warnings)
+	}
+}
+
+// newChangesTestService builds a service on top of a career of u001 holding
+// every kind of change.
+func newChangesTestService() *Service {
+	return NewService(
+		&config.Service{ConfigRepository: &config.RepositoryImpl{CareerGradeOrder: []string{"E3", "E4", "E5"}}},
+		grading.NewService(nil, &gradingRepositoryStub{Entities: []grading.Entity{
+			{Id: 4, Ehid: "u001", StartDate: date("2022-07-01"), Grade: "M1"},
+			{Id: 3, Ehid: "u001", StartDate: date("2022-01-01"), EndDate: endDate("2022-06-30"), Grade: "E3"},
+			{Id: 2, Ehid: "u001", StartDate: date("2021-01-01"), EndDate: endDate("2021-12-31"), Grade: "E4"},
+			{Id: 1, Ehid: "u001", StartDate: date("2019-01-01"), EndDate: endDate("2019-12-31"), Grade: "E3"},
+		}}),
+		titling.NewService(nil, &titlingRepositoryStub{Entities: []titling.Entity{
+			{Id: 3, Ehid: "u001", StartDate: date("2021-07-01"), Title: "Senior Engineer"},
+			{Id: 2, Ehid: "u001", StartDate: date("2021-01-01"), EndDate: endDate("2021-06-30"), Title: "Engineer"},
+			{Id: 1, Ehid: "u001", StartDate: date("2019-01-01"), EndDate: endDate("2019-12-31"), Title: "Engineer"},
+		}}),
+		&orgClientStub{Memberships: []liborgc.MembershipViewEntity{
+			{Id: 3, Ehid: "u001", StartDate: "2021-10-01", NodeId: "BE"},
+			{Id: 2, Ehid: "u001", StartDate: "2021-01-01", EndDate: "2021-09-30", NodeId: "ENG"},
+			{Id: 1, Ehid: "u001", StartDate: "2019-01-01", EndDate: "2019-12-31", NodeId: "ENG"},
+		}},
+	)
+}
+
+func formatPosition(p *Position) string {
+	if p == nil {
+		return ""
+	}
+	return p.Grade + "/" + p.Title + "/" + p.OrganizationNode
+}
+
+func TestRetrieveChangesByEhidAsKnownAt(t *testing.T) {
+	s := newChangesTestService()
+
+	changes, warnings, err := s.RetrieveChangesByEhidAsKnownAt(context.Background(), "u001", txtime.NewCurrent(), false)
+	if err != nil || len(warnings) != 0 {
+		t.Fatal(err)
+	}
+
+	expected := []struct {
+		date   string
+		kind   string
+		before string
+		after  string
+	}{
+		{"2019-01-01", ChangeHire, "", "E3/Engineer/ENG"},
+		{"2020-01-01", ChangeGapStart, "E3/Engineer/ENG", ""},
+		{"2021-01-01", ChangeGapEnd, "E3/Engineer/ENG", "E4/Engineer/ENG"},
+		{"2021-07-01", ChangeLateralTitle, "E4/Engineer/ENG", "E4/Senior Engineer/ENG"},
+		{"2021-10-01", ChangeTransfer, "E4/Senior Engineer/ENG", "E4/Senior Engineer/BE"},
+		{"2022-01-01", ChangeDemotion, "E4/Senior Engineer/BE", "E3/Senior Engineer/BE"},
+		{"2022-07-01", ChangeGrade, "E3/Senior Engineer/BE", "M1/Senior Engineer/BE"},
+	}
+	if len(changes) != len(expected) {
+		t.Fatalf("expected %d changes, got %+v", len(expected), changes)
+	}
+	for i, e := range expected {
+		c := changes[i]
+		if c.Date != e.date || c.Type != e.kind || formatPosition(c.Before) != e.before || formatPosition(c.After) != e.after {
+			t.Errorf("expected %+v, got %s %s %s -> %s", e, c.Date, c.Type, formatPosition(c.Before), formatPosition(c.After))
+		}
+	}
+}
+
+func TestRetrieveDiffByEhid(t *testing.T) {
+	s := newChangesTestService()
+	from, _ := datestr.NewFromString("2021-08-01")
+	to, _ := datestr.NewFromString("2022-03-01")
+
+	diff, _, err := s.RetrieveDiffByEhid(context.Background(), "u001", from, to, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff.Grade != (DimensionDiff{Before: "E4", After: "E3", Changed: true}) ||
+		diff.Title != (DimensionDiff{Before: "Senior Engineer", After: "Senior Engineer", Changed: false}) ||
+		diff.OrganizationNode != (DimensionDiff{Before: "ENG", After: "BE", Changed: true}) {
+		t.Errorf("unexpected snapshots %+v %+v %+v", diff.Grade, diff.Title, diff.OrganizationNode)
+	}
+	if len(diff.Changes) != 2 || diff.Changes[0].Type != ChangeTransfer || diff.Changes[1].Type != ChangeDemotion {
+		t.Errorf("expected a transfer and a demotion, got %+v", diff.Changes)
+	}
+	expected := []Aggregate{
+		{StartDate: "2021-08-01", EndDate: "2021-09-30", Grade: "E4", Title: "Senior Engineer", OrganizationNode: "ENG"},
+		{StartDate: "2021-10-01", EndDate: "2021-12-31", Grade: "E4", Title: "Senior Engineer", OrganizationNode: "BE"},
+		{StartDate: "2022-01-01", EndDate: "2022-03-01", Grade: "E3", Title: "Senior Engineer", OrganizationNode: "BE"},
+	}
+	if !slices.Equal(diff.Segments, expected) {
+		t.Errorf("expected segments %+v, got %+v", expected, diff.Segments)
+	}
+
+	_, _, err = s.RetrieveDiffByEhid(context.Background(), "u001", to, from, false)
+	if !errors.Is(err, localerror.ErrBadDateSequence) {
+		t.Errorf("expected %v, got %v", localerror.ErrBadDateSequence, err)
+	}
+}
+
+func TestShapeTimeline(t *testing.T) {
+	s := &Service{}
+	aggs := []Aggregate{
+		{StartDate: "2024-02-20", EndDate: "", Grade: "E5"},
+		{StartDate: "2024-01-01", EndDate: "2024-02-19", Grade: "E4"},
+	}
+	now := date("2024-04-10")
+
+	months := s.bucketSegments(aggs, GranularityMonth, now)
+	expected := []Aggregate{
+		{StartDate: "2024-04-01", EndDate: "", Grade: "E5"},
+		{StartDate: "2024-03-01", EndDate: "2024-03-31", Grade: "E5"},
+		{StartDate: "2024-02-01", EndDate: "2024-02-29", Grade: "E4"},
+		{StartDate: "2024-01-01", EndDate: "2024-01-31", Grade: "E4"},
+	}
+	if !slices.Equal(months, expected) {
+		t.Errorf("expected months %+v, got %+v", expected, months)
+	}
+
+	compacted, err := s.compactSegments(months)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected = []Aggregate{
+		{StartDate: "2024-03-01", EndDate: "", Grade: "E5"},
+		{StartDate: "2024-01-01", EndDate: "2024-02-29", Grade: "E4"},
+	}
+	if !slices.Equal(compacted, expected) {
+		t.Errorf("expected compacted %+v, got %+v", expected, compacted)
+	}
+
+	years := s.bucketSegments(aggs, GranularityYear, now)
+	expected = []Aggregate{
+		{StartDate: "2024-01-01", EndDate: "", Grade: "E5"},
+	}
+	if !slices.Equal(years, expected) {
+		t.Errorf("expected years %+v, got %+v", expected, years)
+	}
+
+	clipped := s.clipSegments(aggs, "2024-02-01", "2024-03-15")
+	expected = []Aggregate{
+		{StartDate: "2024-02-20", EndDate: "2024-03-15", Grade: "E5"},
+		{StartDate: "2024-02-01", EndDate: "2024-02-19", Grade: "E4"},
+	}
+	if !slices.Equal(clipped, expected) {
+		t.Errorf("expected clipped %+v, got %+v", expected, clipped)
+	}
+}
+
+func TestRetrieveTimelineByEhidAsKnownAtBadQuery(t *testing.T) {
+	s := newTestService(&orgClientStub{})
+
+	for _, c := range []struct {
+		q        TimelineQuery
+		expected error
+	}{
+		{TimelineQuery{Granularity: "week"}, localerror.ErrBadQueryParam},
+		{TimelineQuery{From: "2024-02-30"}, localerror.ErrBadQueryParam},
+		{TimelineQuery{From: "2024-03-01", To: "2024-02-01"}, localerror.ErrBadDateSequence},
+	} {
+		_, _, err := s.RetrieveTimelineByEhidAsKnownAt(context.Background(), "u001", txtime.NewCurrent(), c.q, false)
+		if !errors.Is(err, c.expected) {
+			t.Errorf("expected %v for %+v, got %v", c.expected, c.q, err)
+		}
+	}
+}
+
+func TestRetrieveTimelineByEhidAsKnownAtCompacting(t *testing.T) {
+	gr := &gradingRepositoryStub{}
+	s := newTestServiceWithRepositories(gr, &titlingRepositoryStub{}, &orgClientStub{})
+	gr.Entities = []grading.Entity{
+		{Id: 3, Ehid: "u001", StartDate: date("2023-07-01"), Grade: "E5"},
+		{Id: 2, Ehid: "u001", StartDate: date("2023-01-01"), EndDate: endDate("2023-06-30"), Grade: "E5"},
+		{Id: 1, Ehid: "u001", StartDate: date("2021-01-01"), EndDate: endDate("2022-12-31"), Grade: "E4"},
+	}
+
+	for _, c := range []struct {
+		compact  bool
+		expected []Aggregate
+	}{
+		{false, []Aggregate{
+			{StartDate: "2023-07-01", EndDate: "", Grade: "E5", Title: "Engineer"},
+			{StartDate: "2023-01-01", EndDate: "2023-06-30", Grade: "E5", Title: "Engineer"},
+			{StartDate: "2021-01-01", EndDate: "2022-12-31", Grade: "E4", Title: "Engineer"},
+		}},
+		{true, []Aggregate{
+			{StartDate: "2023-01-01", EndDate: "", Grade: "E5", Title: "Engineer"},
+			{StartDate: "2021-01-01", EndDate: "2022-12-31", Grade: "E4", Title: "Engineer"},
+		}},
+	} {
+		aggs, _, err := s.RetrieveTimelineByEhidAsKnownAt(
+			context.Background(),
+			"u001",
+			txtime.NewCurrent(),
+			TimelineQuery{Compact: c.compact},
+			false,
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !slices.Equal(aggs, c.expected) {
+			t.Errorf("compact=%t: expected %+v, got %+v", c.compact, c.expected, aggs)
+		}
+	}
+}
+
+func TestRetrieveTimelineByEhidAsKnownAtWithSources(t *testing.T) {
+	gr := &gradingRepositoryStub{}
+	s := newTestServiceWithRepositories(gr, &titlingRepositoryStub{}, &orgClientStub{Memberships: []liborgc.MembershipViewEntity{
+		{Id: 7, Ehid: "u001", StartDate: "2021-01-01", NodeId: "ENG"},
+	}})
+	gr.Entities = []grading.Entity{
+		{Id: 3, Ehid: "u001", StartDate: date("2023-07-01"), Grade: "E5"},
+		{Id: 2, Ehid: "u001", StartDate: date("2023-01-01"), EndDate: endDate("2023-06-30"), Grade: "E5"},
+		{Id: 1, Ehid: "u001", StartDate: date("2021-01-01"), EndDate: endDate("2022-12-31"), Grade: "E4"},
+	}
+
+	aggs, _, err := s.RetrieveTimelineByEhidAsKnownAt(
+		context.Background(),
+		"u001",
+		txtime.NewCurrent(),
+		TimelineQuery{Compact: true, IncludeSources: true},
+		false,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(aggs) != 2 {
+		t.Fatalf("expected the split grading to be compacted into one segment, got %+v", aggs)
+	}
+	latest := aggs[0].Sources
+	if !slices.Equal(latest.Gradings, []Source{{Id: 3, Href: "/gradings/3"}, {Id: 2, Href: "/gradings/2"}}) ||
+		!slices.Equal(latest.Titlings, []Source{{Id: 1, Href: "/titlings/1"}}) ||
+		!slices.Equal(latest.Memberships, []Source{{Id: 7}}) {
+		t.Errorf("unexpected sources %+v", latest)
+	}
+	if !slices.Equal(aggs[1].Sources.Gradings, []Source{{Id: 1, Href: "/gradings/1"}}) {
+		t.Errorf("unexpected sources %+v", aggs[1].Sources)
+	}
+
+	aggs, _, err = s.RetrieveTimelineByEhidAsKnownAt(context.Background(), "u001", txtime.NewCurrent(), TimelineQuery{}, false)
+	if err != nil || aggs[0].Sources != nil {
+		t.Errorf("expected no sources unless asked, got %+v, %v", aggs, err)
+	}
+}
+
+func TestRetrieveTimelineByEhidAsKnownAtCitesWinningSources(t *testing.T) {
+	gr := &gradingRepositoryStub{}
+	s := newTestServiceWithRepositories(gr, &titlingRepositoryStub{}, &orgClientStub{})
+	gr.Entities = []grading.Entity{
+		{Id: 2, Ehid: "u001", StartDate: date("2023-01-01"), Grade: "E5"},
+		{Id: 1, Ehid: "u001", StartDate: date("2021-01-01"), Grade: "E5"},
+	}
+
+	aggs, _, err := s.RetrieveTimelineByEhidAsKnownAt(
+		context.Background(),
+		"u001",
+		txtime.NewCurrent(),
+		TimelineQuery{IncludeSources: true},
+		false,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(aggs) != 2 {
+		t.Fatalf("expected 2 segments, got %+v", aggs)
+	}
+	if !slices.Equal(aggs[0].Sources.Gradings, []Source{{Id: 2, Href: "/gradings/2"}}) {
+		t.Errorf("expected the overlapped grading to be left out, got %+v", aggs[0].Sources.Gradings)
+	}
+	if !slices.Equal(aggs[1].Sources.Gradings, []Source{{Id: 1, Href: "/gradings/1"}}) {
+		t.Errorf("expected %+v, got %+v", []Source{{Id: 1, Href: "/gradings/1"}}, aggs[1].Sources.Gradings)
+	}
+}
+
+func TestRetrieveTimelineByEhidAsKnownAtExpandingOrg(t *testing.T) {
+	org := &orgClientStub{
+		Memberships: []liborgc.MembershipViewEntity{
+			{Id: 5, Ehid: "u001", StartDate: "2023-01-01", NodeId: "BE"},
+			{Id: 4, Ehid: "u001", StartDate: "2022-01-01", EndDate: "2022-12-31", NodeId: "GONE"},
+			{Id: 3, Ehid: "u001", StartDate: "2021-07-01", EndDate: "2021-12-31", NodeId: "BE"},
+			{Id: 2, Ehid: "u001", StartDate: "2021-04-01", EndDate: "2021-06-30", NodeId: "ENG"},
+			{Id: 1, Ehid: "u001", StartDate: "2021-01-01", EndDate: "2021-03-31", NodeId: "BE"},
+		},
+		Nodes: []orgclient.NodeViewEntity{
+			{Id: "ACME", Hierarchy: "ACME", Name: "Acme"},
+			{Id: "ENG", Hierarchy: "ACME.ENG", Name: "Engineering"},
+			{Id: "BE", Hierarchy: "ACME.ENG.BE", Name: "Backend"},
+		},
+	}
+	s := newTestServiceWithRepositories(&gradingRepositoryStub{}, &titlingRepositoryStub{}, org)
+	s.ConfigService = &config.Service{ConfigRepository: &config.RepositoryImpl{
+		BatchConcurrency: 2,
+		CareerNodeTypes:  []string{"company", "division"},
+	}}
+
+	aggs, warnings, err := s.RetrieveTimelineByEhidAsKnownAt(
+		context.Background(),
+		"u001",
+		txtime.NewCurrent(),
+		TimelineQuery{ExpandOrg: true},
+		false,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(warnings) != 0 {
+		t.Errorf("expected no warnings, got %+v", warnings)
+	}
+	if len(aggs) != 5 {
+		t.Fatalf("expected 5 segments, got %+v", aggs)
+	}
+	if calls := org.LineageCalls.Load(); calls != 3 {
+		t.Errorf("expected one lineage call per node, got %d", calls)
+	}
+	be := aggs[0].Org
+	if be == nil || be.NodeRef != (NodeRef{Id: "BE", Name: "Backend"}) ||
+		!slices.Equal(be.Path, []NodeRef{
+			{Id: "ACME", Name: "Acme", Type: "company"},
+			{Id: "ENG", Name: "Engineering", Type: "division"},
+		}) {
+		t.Errorf("unexpected node %+v", be)
+	}
+	if aggs[1].Org != nil {
+		t.Errorf("expected an unknown node to stay unexpanded, got %+v", aggs[1].Org)
+	}
+	if eng := aggs[3].Org; eng == nil || eng.Type != "division" || len(eng.Path) != 1 {
+		t.Errorf("unexpected node %+v", eng)
+	}
+
+	org.LineageErr = localerror.ErrRemoteError
+	_, _, err = s.RetrieveTimelineByEhidAsKnownAt(context.Background(), "u001", txtime.NewCurrent(), TimelineQuery{ExpandOrg: true}, false)
+	if !errors.Is(err, localerror.ErrRemoteError) {
+		t.Errorf("expected %v, got %v", localerror.ErrRemoteError, err)
+	}
+	aggs, warnings, err = s.RetrieveTimelineByEhidAsKnownAt(context.Background(), "u001", txtime.NewCurrent(), TimelineQuery{ExpandOrg: true}, true)
+	if err != nil || len(warnings) != 3 || aggs[0].Org != nil {
+		t.Errorf("expected unexpanded nodes with warnings, got %+v, %+v, %v", aggs, warnings, err)
+	}
+}
+
+func newBenchmarkService(delay time.Duration) *Service {
+	return newTestServiceWithRepositories(
+		&gradingRepositoryStub{Delay: delay},
+		&titlingRepositoryStub{Delay: delay},
+		&orgClientStub{
+			Delay:       delay,
+			Memberships: []liborgc.MembershipViewEntity{{Id: 1, Ehid: "u001", StartDate: "2021-01-01", NodeId: "ENG"}},
+		},
+	)
+}
+
+func BenchmarkRetrieveCurrentByEhid(b *testing.B) {
+	s := newBenchmarkService(5 * time.Millisecond)
+	ctx := context.Background()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _, err := s.RetrieveCurrentByEhid(ctx, "u001", false)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkRetrieveByEhidOrderByStartDateDesc(b *testing.B) {
+	s := newBenchmarkService(5 * time.Millisecond)
+	ctx := context.Background()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, err := s.RetrieveByEhidOrderByStartDateDesc(ctx, "u001")
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
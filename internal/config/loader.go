@@ -0,0 +1,291 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cast"
+	"github.com/spf13/viper"
+)
+
+const (
+	EnvProfile     = "APP_PROFILE"
+	DefaultProfile = "local"
+
+	SourceDefault    = "default"
+	SourceFile       = "file"
+	SourceEnv        = "env"
+	SourceSecretFile = "secret-file"
+	SourceUnset      = "unset"
+
+	secretFileSuffix = "_FILE"
+
+	Redacted = "******"
+)
+
+var (
+	DefaultPaths = []string{
+		"/etc/conf",
+		"./config",
+	}
+
+	DatasourceKeys = []string{
+		"host",
+		"port",
+		"user",
+		"password",
+		"dbname",
+		"sslmode",
+		"timezone",
+	}
+
+	defaults = map[string]any{
+		"app.server.read-timeout":            "15s",
+		"app.server.read-header-timeout":     "5s",
+		"app.server.write-timeout":           "30s",
+		"app.server.idle-timeout":            "60s",
+		"app.server.shutdown-timeout":        "20s",
+		"app.server.max-header-bytes":        1 << 20,
+		"app.server.max-body-bytes":          1 << 20,
+		"app.outbox.interval":                "5s",
+		"app.outbox.batch-size":              100,
+		"app.webhook.interval":               "2s",
+		"app.webhook.batch-size":             50,
+		"app.webhook.max-attempts":           8,
+		"app.webhook.timeout":                "10s",
+		"app.health.timeout":                 "2s",
+		"app.health.critical":                []string{"read_db", "write_db"},
+		"app.client.authx.timeout":           "3s",
+		"app.client.authx.max-retries":       2,
+		"app.client.authx.retry-backoff":     "100ms",
+		"app.client.authx.breaker-threshold": 5,
+		"app.client.authx.breaker-cooldown":  "30s",
+		"app.client.org.timeout":             "3s",
+		"app.client.org.max-retries":         2,
+		"app.client.org.retry-backoff":       "100ms",
+		"app.client.org.breaker-threshold":   5,
+		"app.client.org.breaker-cooldown":    "30s",
+		"app.degraded.enabled":               false,
+		"app.batch.max-size":                 500,
+		"app.batch.concurrency":              16,
+		"app.org-nodes.max-history-lookups":  2000,
+		"app.cache.backend":                  "lru",
+		"app.cache.lru.capacity":             10000,
+		"app.cache.redis.db":                 0,
+		"app.cache.redis.key-prefix":         "connect-emp:",
+		"app.cache.org.ttl":                  "5m",
+		"app.cache.org.stale-ttl":            "1m",
+		"app.cache.authx.ttl":                "10m",
+		"app.cache.authx.stale-ttl":          "2m",
+		"app.tracing.exporter":               "none",
+		"app.tracing.sample-ratio":           1.0,
+	}
+
+	secretKeyMarkers = []string{
+		"password",
+		"secret",
+		"token",
+	}
+)
+
+// Loader reads application-<profile>.yml and layers defaults, environment
+// variables and *_FILE secrets on top of it. Every key can be overridden by
+// the environment variable named after it, e.g. app.datasource.write.password
+// by APP_DATASOURCE_WRITE_PASSWORD, or by APP_DATASOURCE_WRITE_PASSWORD_FILE
+// pointing to a file holding the value.
+type Loader struct {
+	Profile    string
+	ConfigFile string
+	Viper      *viper.Viper
+	Sources    map[string]string
+}
+
+type Setting struct {
+	Key    string
+	Value  any
+	Source string
+	Secret bool
+}
+
+func ProfileFromEnv() string {
+	profile := os.Getenv(EnvProfile)
+	if profile == "" {
+		profile = DefaultProfile
+	}
+	return profile
+}
+
+func NewLoader(profile string, paths []string) (*Loader, error) {
+	v := viper.New()
+	for key, value := range defaults {
+		v.SetDefault(key, value)
+	}
+	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_", "-", "_"))
+	v.AutomaticEnv()
+
+	v.SetConfigName("application-" + profile)
+	v.SetConfigType("yml")
+	for _, cp := range paths {
+		v.AddConfigPath(cp)
+	}
+	err := v.ReadInConfig()
+	if err != nil {
+		return nil, fmt.Errorf(
+			"loading application-%s.yml from %s: %w",
+			profile,
+			strings.Join(paths, ", "),
+			err,
+		)
+	}
+
+	l := &Loader{
+		Profile:    profile,
+		ConfigFile: v.ConfigFileUsed(),
+		Viper:      v,
+		Sources:    map[string]string{},
+	}
+
+	for _, key := range l.Keys() {
+		envName := EnvName(key)
+		if path, ok := os.LookupEnv(envName + secretFileSuffix); ok {
+			content, err := os.ReadFile(path)
+			if err != nil {
+				return nil, fmt.Errorf("reading %s: %w", envName+secretFileSuffix, err)
+			}
+			v.Set(key, strings.TrimRight(string(content), "\r\n"))
+			l.Sources[key] = SourceSecretFile
+		} else if _, ok := os.LookupEnv(envName); ok {
+			l.Sources[key] = SourceEnv
+		} else if v.InConfig(key) {
+			l.Sources[key] = SourceFile
+		} else if _, ok := defaults[key]; ok {
+			l.Sources[key] = SourceDefault
+		} else {
+			l.Sources[key] = SourceUnset
+		}
+	}
+
+	return l, nil
+}
+
+// Keys lists every key the service reads, plus any other key present in the
+// file, in sorted order.
+func (l *Loader) Keys() []string {
+	keys := []string{}
+	for key := range defaults {
+		keys = append(keys, key)
+	}
+	keys = append(keys, requiredKeys...)
+	for _, name := range []string{"read", "write"} {
+		for _, key := range DatasourceKeys {
+			keys = append(keys, "app.datasource."+name+"."+key)
+		}
+	}
+	keys = append(keys, optionalKeys...)
+	keys = append(keys, l.Viper.AllKeys()...)
+
+	sort.Strings(keys)
+	return slices.Compact(keys)
+}
+
+func (l *Loader) Settings() []Setting {
+	settings := []Setting{}
+	for _, key := range l.Keys() {
+		settings = append(settings, Setting{
+			Key:    key,
+			Value:  l.Viper.Get(key),
+			Source: l.Sources[key],
+			Secret: IsSecretKey(key),
+		})
+	}
+	return settings
+}
+
+func (l *Loader) clientPolicy(name string) ClientPolicy {
+	prefix := "app.client." + name + "."
+	return ClientPolicy{
+		Timeout:          l.Viper.GetDuration(prefix + "timeout"),
+		MaxRetries:       l.Viper.GetInt(prefix + "max-retries"),
+		RetryBackoff:     l.Viper.GetDuration(prefix + "retry-backoff"),
+		BreakerThreshold: l.Viper.GetInt(prefix + "breaker-threshold"),
+		BreakerCooldown:  l.Viper.GetDuration(prefix + "breaker-cooldown"),
+	}
+}
+
+func (l *Loader) cachePolicy(name string) CachePolicy {
+	prefix := "app.cache." + name + "."
+	return CachePolicy{
+		Ttl:      l.Viper.GetDuration(prefix + "ttl"),
+		StaleTtl: l.Viper.GetDuration(prefix + "stale-ttl"),
+	}
+}
+
+// Dsn renders the libpq connection string of app.datasource.<name>.
+func (l *Loader) Dsn(name string) string {
+	prefix := "app.datasource." + name
+	params := map[string]string{}
+	for _, key := range l.Keys() {
+		if !strings.HasPrefix(key, prefix+".") {
+			continue
+		}
+		value := l.Viper.GetString(key)
+		if value != "" {
+			params[strings.TrimPrefix(key, prefix+".")] = value
+		}
+	}
+	return BuildDsn(params)
+}
+
+func EnvName(key string) string {
+	return strings.ToUpper(strings.NewReplacer(".", "_", "-", "_").Replace(key))
+}
+
+func IsSecretKey(key string) bool {
+	segments := strings.Split(key, ".")
+	last := segments[len(segments)-1]
+	for _, marker := range secretKeyMarkers {
+		if strings.Contains(last, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// BuildDsn renders libpq keyword/value pairs in key order. Every value is
+// single-quoted, with backslashes and quotes escaped, so passwords may hold
+// spaces, quotes or equal signs.
+func BuildDsn(params map[string]string) string {
+	keys := []string{}
+	for key := range params {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	escaper := strings.NewReplacer(`\`, `\\`, `'`, `\'`)
+	pairs := []string{}
+	for _, key := range keys {
+		pairs = append(pairs, key+"='"+escaper.Replace(params[key])+"'")
+	}
+	return strings.Join(pairs, " ")
+}
+
+// DisplayValue renders the value for humans, hiding secrets.
+func (s Setting) DisplayValue() string {
+	if s.Value == nil {
+		return ""
+	}
+	var value string
+	switch v := s.Value.(type) {
+	case []string, []any:
+		value = "[" + strings.Join(cast.ToStringSlice(v), ", ") + "]"
+	default:
+		value = fmt.Sprint(v)
+	}
+	if s.Secret && value != "" {
+		return Redacted
+	}
+	return value
+}
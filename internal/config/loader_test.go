@@ -0,0 +1,202 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const validYaml = `
+app:
+  datasource:
+    read:
+      host: 127.0.0.1
+      port: 5432
+      user: emp_r
+      password: 123
+      dbname: emp
+    write:
+      host: 127.0.0.1
+      port: 5432
+      user: emp_w
+      password: 123
+      dbname: emp
+  server:
+    port: 8082
+  client:
+    authx:
+      host: http://127.0.0.1
+      port: 8080
+    org:
+      host: http://127.0.0.1
+      port: 8081
+`
+
+func writeConfig(t *testing.T, content string) string {
+	dir := t.TempDir()
+	err := os.WriteFile(filepath.Join(dir, "application-test.yaml"), []byte(content), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func TestNewLoaderMissingFile(t *testing.T) {
+	_, err := NewLoader("test", []string{t.TempDir()})
+	if err == nil || !strings.Contains(err.Error(), "application-test.yml") {
+		t.Fatalf("expected error naming the missing file, got %v", err)
+	}
+}
+
+func TestNewLoaderEnvOverride(t *testing.T) {
+	t.Setenv("APP_DATASOURCE_WRITE_PASSWORD", "from env")
+	t.Setenv("APP_SERVER_READ_TIMEOUT", "3s")
+
+	l, err := NewLoader("test", []string{writeConfig(t, validYaml)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := l.Viper.GetString("app.datasource.write.password"); got != "from env" {
+		t.Errorf("expected env password, got %q", got)
+	}
+	if l.Sources["app.datasource.write.password"] != SourceEnv {
+		t.Errorf("expected source %q, got %q", SourceEnv, l.Sources["app.datasource.write.password"])
+	}
+	if l.Sources["app.datasource.read.password"] != SourceFile {
+		t.Errorf("expected source %q, got %q", SourceFile, l.Sources["app.datasource.read.password"])
+	}
+	if l.Sources["app.server.read-timeout"] != SourceEnv {
+		t.Errorf("expected source %q, got %q", SourceEnv, l.Sources["app.server.read-timeout"])
+	}
+	if l.Sources["app.webhook.timeout"] != SourceDefault {
+		t.Errorf("expected source %q, got %q", SourceDefault, l.Sources["app.webhook.timeout"])
+	}
+	if r := newRepositoryFromLoader(l); r.GetServerReadTimeout().String() != "3s" {
+		t.Errorf("expected read timeout 3s, got %s", r.GetServerReadTimeout())
+	}
+}
+
+func TestNewLoaderSecretFile(t *testing.T) {
+	secret := filepath.Join(t.TempDir(), "password")
+	err := os.WriteFile(secret, []byte("s3cr3t\n"), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("APP_DATASOURCE_READ_PASSWORD", "ignored")
+	t.Setenv("APP_DATASOURCE_READ_PASSWORD_FILE", secret)
+
+	l, err := NewLoader("test", []string{writeConfig(t, validYaml)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := l.Viper.GetString("app.datasource.read.password"); got != "s3cr3t" {
+		t.Errorf("expected secret file content, got %q", got)
+	}
+	if l.Sources["app.datasource.read.password"] != SourceSecretFile {
+		t.Errorf("expected source %q, got %q", SourceSecretFile, l.Sources["app.datasource.read.password"])
+	}
+}
+
+func TestNewLoaderUnreadableSecretFile(t *testing.T) {
+	t.Setenv("APP_DATASOURCE_READ_PASSWORD_FILE", filepath.Join(t.TempDir(), "missing"))
+
+	_, err := NewLoader("test", []string{writeConfig(t, validYaml)})
+	if err == nil || !strings.Contains(err.Error(), "APP_DATASOURCE_READ_PASSWORD_FILE") {
+		t.Fatalf("expected error naming the variable, got %v", err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	l, err := NewLoader("test", []string{writeConfig(t, validYaml)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Validate(); err != nil {
+		t.Fatalf("expected valid config, got %v", err)
+	}
+
+	t.Setenv("APP_SERVER_PORT", "99999")
+	t.Setenv("APP_WEBHOOK_TIMEOUT", "soon")
+	t.Setenv("APP_CLIENT_ORG_HOST", "127.0.0.1")
+	t.Setenv("APP_TRACING_EXPORTER", "jaeger")
+	t.Setenv("APP_CAREER_GRADE_ORDER", "E1 E2 E1")
+
+	withoutWriteHost := strings.Replace(validYaml, "host: 127.0.0.1\n      port: 5432\n      user: emp_w", "port: 5432\n      user: emp_w", 1)
+	l, err = NewLoader("test", []string{writeConfig(t, withoutWriteHost)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = l.Validate()
+
+	var verrs ValidationErrors
+	if !errors.As(err, &verrs) {
+		t.Fatalf("expected validation errors, got %v", err)
+	}
+	keys := map[string]bool{}
+	for _, ve := range verrs {
+		keys[ve.Key] = true
+	}
+	for _, key := range []string{
+		"app.datasource.write.host",
+		"app.server.port",
+		"app.webhook.timeout",
+		"app.client.org.host",
+		"app.tracing.exporter",
+		"app.career.grade-order",
+	} {
+		if !keys[key] {
+			t.Errorf("expected a problem with %s in %v", key, err)
+		}
+	}
+	if len(verrs) != 6 {
+		t.Errorf("expected 6 problems, got %d: %v", len(verrs), err)
+	}
+}
+
+func TestBuildDsn(t *testing.T) {
+	dsn := BuildDsn(map[string]string{
+		"host":     "db",
+		"password": `it's a \secret`,
+		"user":     "emp w",
+	})
+
+	expected := `host='db' password='it\'s a \\secret' user='emp w'`
+	if dsn != expected {
+		t.Errorf("expected %s, got %s", expected, dsn)
+	}
+}
+
+func TestIsSecretKey(t *testing.T) {
+	for key, expected := range map[string]bool{
+		"app.datasource.read.password": true,
+		"app.webhook.signing-secret":   true,
+		"app.datasource.read.user":     false,
+		"app.client.authx.host":        false,
+	} {
+		if IsSecretKey(key) != expected {
+			t.Errorf("IsSecretKey(%q) = %v, expected %v", key, !expected, expected)
+		}
+	}
+}
+
+func TestSettingDisplayValue(t *testing.T) {
+	for _, c := range []struct {
+		setting  Setting
+		expected string
+	}{
+		{Setting{Value: "123", Secret: true}, Redacted},
+		{Setting{Value: "", Secret: true}, ""},
+		{Setting{Value: nil}, ""},
+		{Setting{Value: 8082}, "8082"},
+		{Setting{Value: []string{"read_db", "write_db"}}, "[read_db, write_db]"},
+		{Setting{Value: []any{"read_db"}}, "[read_db]"},
+	} {
+		if got := c.setting.DisplayValue(); got != c.expected {
+			t.Errorf("expected %q, got %q", c.expected, got)
+		}
+	}
+}
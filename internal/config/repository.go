@@ -1,9 +1,7 @@
 package config
 
 import (
-	"os"
-
-	"github.com/spf13/viper"
+	"time"
 )
 
 type Repository interface {
@@ -11,67 +9,253 @@ type Repository interface {
 	GetReadDsn() string
 	GetWriteDsn() string
 	GetPort() int
+	GetServerReadTimeout() time.Duration
+	GetServerReadHeaderTimeout() time.Duration
+	GetServerWriteTimeout() time.Duration
+	GetServerIdleTimeout() time.Duration
+	GetServerShutdownTimeout() time.Duration
+	GetServerMaxHeaderBytes() int
+	GetServerMaxBodyBytes() int64
 	GetAuthxHost() string
 	GetAuthxPort() int
 	GetOrgHost() string
 	GetOrgPort() int
+	GetOutboxInterval() time.Duration
+	GetOutboxBatchSize() int
+	GetOutboxWebhookUrls() []string
+	GetOutboxFilePath() string
+	GetWebhookInterval() time.Duration
+	GetWebhookBatchSize() int
+	GetWebhookMaxAttempts() int
+	GetWebhookTimeout() time.Duration
+	GetHealthTimeout() time.Duration
+	GetHealthCriticalDependencies() []string
+	GetOrgClientPolicy() ClientPolicy
+	GetAuthxClientPolicy() ClientPolicy
+	GetDegradedEnabled() bool
+	GetBatchMaxSize() int
+	GetBatchConcurrency() int
+	GetOrgNodesMaxHistoryLookups() int
+	GetCareerGradeOrder() []string
+	GetCareerNodeTypes() []string
+	GetCacheBackend() string
+	GetCacheLruCapacity() int
+	GetCacheRedisAddress() string
+	GetCacheRedisPassword() string
+	GetCacheRedisDb() int
+	GetCacheRedisKeyPrefix() string
+	GetOrgCachePolicy() CachePolicy
+	GetAuthxCachePolicy() CachePolicy
+	GetTracingExporter() string
+	GetTracingEndpoint() string
+	GetTracingInsecure() bool
+	GetTracingSampleRatio() float64
+}
+
+// ClientPolicy bounds the calls made to a downstream service.
+type ClientPolicy struct {
+	Timeout          time.Duration
+	MaxRetries       int
+	RetryBackoff     time.Duration
+	BreakerThreshold int
+	BreakerCooldown  time.Duration
+}
+
+// CachePolicy tells how long lookups of a source are served from cache, and
+// for how much longer they may be served stale while being refreshed.
+type CachePolicy struct {
+	Ttl      time.Duration
+	StaleTtl time.Duration
 }
 
 type RepositoryImpl struct {
-	Profile   string
-	ReadDsn   string
-	WriteDsn  string
-	Port      int
+	Profile  string
+	ReadDsn  string
+	WriteDsn string
+	Port     int
+
+	ServerReadTimeout       time.Duration
+	ServerReadHeaderTimeout time.Duration
+	ServerWriteTimeout      time.Duration
+	ServerIdleTimeout       time.Duration
+	ServerShutdownTimeout   time.Duration
+	ServerMaxHeaderBytes    int
+	ServerMaxBodyBytes      int64
+
 	AuthxHost string
 	AuthxPort int
 	OrgHost   string
 	OrgPort   int
+
+	AuthxClientPolicy ClientPolicy
+	OrgClientPolicy   ClientPolicy
+
+	OutboxInterval    time.Duration
+	OutboxBatchSize   int
+	OutboxWebhookUrls []string
+	OutboxFilePath    string
+
+	WebhookInterval    time.Duration
+	WebhookBatchSize   int
+	WebhookMaxAttempts int
+	WebhookTimeout     time.Duration
+
+	HealthTimeout              time.Duration
+	HealthCriticalDependencies []string
+
+	DegradedEnabled bool
+
+	BatchMaxSize     int
+	BatchConcurrency int
+
+	OrgNodesMaxHistoryLookups int
+
+	CareerGradeOrder []string
+	CareerNodeTypes  []string
+
+	CacheBackend        string
+	CacheLruCapacity    int
+	CacheRedisAddress   string
+	CacheRedisPassword  string
+	CacheRedisDb        int
+	CacheRedisKeyPrefix string
+	OrgCachePolicy      CachePolicy
+	AuthxCachePolicy    CachePolicy
+
+	TracingExporter    string
+	TracingEndpoint    string
+	TracingInsecure    bool
+	TracingSampleRatio float64
 }
 
-func NewRepository() Repository {
-	profile := os.Getenv("APP_PROFILE")
-	if profile == "" {
-		profile = "local"
-	}
-	viper.SetConfigName("application-" + profile)
-	viper.SetConfigType("yml")
-	for _, cp := range []string{
-		"/etc/conf",
-		"./config",
-	} {
-		viper.AddConfigPath(cp)
+func NewRepository() (Repository, error) {
+	l, err := NewLoader(ProfileFromEnv(), DefaultPaths)
+	if err != nil {
+		return nil, err
 	}
-	err := viper.ReadInConfig()
+	err = l.Validate()
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
+	return newRepositoryFromLoader(l), nil
+}
 
-	var readDsn = ""
-	for key, value := range viper.GetStringMapString("app.datasource.read") {
-		readDsn += string(key + "=" + value + " ")
-	}
+func newRepositoryFromLoader(l *Loader) *RepositoryImpl {
+	profile := l.Profile
+	readDsn := l.Dsn("read")
+	writeDsn := l.Dsn("write")
 
-	var writeDsn = ""
-	for key, value := range viper.GetStringMapString("app.datasource.write") {
-		writeDsn += string(key + "=" + value + " ")
-	}
+	port := l.Viper.GetInt("app.server.port")
+	serverReadTimeout := l.Viper.GetDuration("app.server.read-timeout")
+	serverReadHeaderTimeout := l.Viper.GetDuration("app.server.read-header-timeout")
+	serverWriteTimeout := l.Viper.GetDuration("app.server.write-timeout")
+	serverIdleTimeout := l.Viper.GetDuration("app.server.idle-timeout")
+	serverShutdownTimeout := l.Viper.GetDuration("app.server.shutdown-timeout")
+	serverMaxHeaderBytes := l.Viper.GetInt("app.server.max-header-bytes")
+	serverMaxBodyBytes := l.Viper.GetInt64("app.server.max-body-bytes")
+
+	authxHost := l.Viper.GetString("app.client.authx.host")
+	authxPort := l.Viper.GetInt("app.client.authx.port")
+	orgHost := l.Viper.GetString("app.client.org.host")
+	orgPort := l.Viper.GetInt("app.client.org.port")
+	authxClientPolicy := l.clientPolicy("authx")
+	orgClientPolicy := l.clientPolicy("org")
+
+	outboxInterval := l.Viper.GetDuration("app.outbox.interval")
+	outboxBatchSize := l.Viper.GetInt("app.outbox.batch-size")
+	outboxWebhookUrls := l.Viper.GetStringSlice("app.outbox.sink.webhook.urls")
+	outboxFilePath := l.Viper.GetString("app.outbox.sink.file.path")
+
+	webhookInterval := l.Viper.GetDuration("app.webhook.interval")
+	webhookBatchSize := l.Viper.GetInt("app.webhook.batch-size")
+	webhookMaxAttempts := l.Viper.GetInt("app.webhook.max-attempts")
+	webhookTimeout := l.Viper.GetDuration("app.webhook.timeout")
+
+	healthTimeout := l.Viper.GetDuration("app.health.timeout")
+	healthCriticalDependencies := l.Viper.GetStringSlice("app.health.critical")
+
+	degradedEnabled := l.Viper.GetBool("app.degraded.enabled")
+
+	batchMaxSize := l.Viper.GetInt("app.batch.max-size")
+	batchConcurrency := l.Viper.GetInt("app.batch.concurrency")
+
+	orgNodesMaxHistoryLookups := l.Viper.GetInt("app.org-nodes.max-history-lookups")
 
-	port := viper.GetInt("app.server.port")
+	careerGradeOrder := l.Viper.GetStringSlice("app.career.grade-order")
+	careerNodeTypes := l.Viper.GetStringSlice("app.career.node-types")
 
-	authxHost := viper.GetString("app.client.authx.host")
-	authxPort := viper.GetInt("app.client.authx.port")
-	orgHost := viper.GetString("app.client.org.host")
-	orgPort := viper.GetInt("app.client.org.port")
+	cacheBackend := l.Viper.GetString("app.cache.backend")
+	cacheLruCapacity := l.Viper.GetInt("app.cache.lru.capacity")
+	cacheRedisAddress := l.Viper.GetString("app.cache.redis.address")
+	cacheRedisPassword := l.Viper.GetString("app.cache.redis.password")
+	cacheRedisDb := l.Viper.GetInt("app.cache.redis.db")
+	cacheRedisKeyPrefix := l.Viper.GetString("app.cache.redis.key-prefix")
+	orgCachePolicy := l.cachePolicy("org")
+	authxCachePolicy := l.cachePolicy("authx")
+
+	tracingExporter := l.Viper.GetString("app.tracing.exporter")
+	tracingEndpoint := l.Viper.GetString("app.tracing.endpoint")
+	tracingInsecure := l.Viper.GetBool("app.tracing.insecure")
+	tracingSampleRatio := l.Viper.GetFloat64("app.tracing.sample-ratio")
 
 	return &RepositoryImpl{
-		Profile:   profile,
-		ReadDsn:   readDsn,
-		WriteDsn:  writeDsn,
-		Port:      port,
+		Profile:  profile,
+		ReadDsn:  readDsn,
+		WriteDsn: writeDsn,
+		Port:     port,
+
+		ServerReadTimeout:       serverReadTimeout,
+		ServerReadHeaderTimeout: serverReadHeaderTimeout,
+		ServerWriteTimeout:      serverWriteTimeout,
+		ServerIdleTimeout:       serverIdleTimeout,
+		ServerShutdownTimeout:   serverShutdownTimeout,
+		ServerMaxHeaderBytes:    serverMaxHeaderBytes,
+		ServerMaxBodyBytes:      serverMaxBodyBytes,
+
 		AuthxHost: authxHost,
 		AuthxPort: authxPort,
 		OrgHost:   orgHost,
 		OrgPort:   orgPort,
+
+		AuthxClientPolicy: authxClientPolicy,
+		OrgClientPolicy:   orgClientPolicy,
+
+		OutboxInterval:    outboxInterval,
+		OutboxBatchSize:   outboxBatchSize,
+		OutboxWebhookUrls: outboxWebhookUrls,
+		OutboxFilePath:    outboxFilePath,
+
+		WebhookInterval:    webhookInterval,
+		WebhookBatchSize:   webhookBatchSize,
+		WebhookMaxAttempts: webhookMaxAttempts,
+		WebhookTimeout:     webhookTimeout,
+
+		HealthTimeout:              healthTimeout,
+		HealthCriticalDependencies: healthCriticalDependencies,
+
+		DegradedEnabled: degradedEnabled,
+
+		BatchMaxSize:     batchMaxSize,
+		BatchConcurrency: batchConcurrency,
+
+		OrgNodesMaxHistoryLookups: orgNodesMaxHistoryLookups,
+
+		CareerGradeOrder: careerGradeOrder,
+		CareerNodeTypes:  careerNodeTypes,
+
+		CacheBackend:        cacheBackend,
+		CacheLruCapacity:    cacheLruCapacity,
+		CacheRedisAddress:   cacheRedisAddress,
+		CacheRedisPassword:  cacheRedisPassword,
+		CacheRedisDb:        cacheRedisDb,
+		CacheRedisKeyPrefix: cacheRedisKeyPrefix,
+		OrgCachePolicy:      orgCachePolicy,
+		AuthxCachePolicy:    authxCachePolicy,
+
+		TracingExporter:    tracingExporter,
+		TracingEndpoint:    tracingEndpoint,
+		TracingInsecure:    tracingInsecure,
+		TracingSampleRatio: tracingSampleRatio,
 	}
 }
 
@@ -91,6 +275,34 @@ func (r *RepositoryImpl) GetPort() int {
 	return r.Port
 }
 
+func (r *RepositoryImpl) GetServerReadTimeout() time.Duration {
+	return r.ServerReadTimeout
+}
+
+func (r *RepositoryImpl) GetServerReadHeaderTimeout() time.Duration {
+	return r.ServerReadHeaderTimeout
+}
+
+func (r *RepositoryImpl) GetServerWriteTimeout() time.Duration {
+	return r.ServerWriteTimeout
+}
+
+func (r *RepositoryImpl) GetServerIdleTimeout() time.Duration {
+	return r.ServerIdleTimeout
+}
+
+func (r *RepositoryImpl) GetServerShutdownTimeout() time.Duration {
+	return r.ServerShutdownTimeout
+}
+
+func (r *RepositoryImpl) GetServerMaxHeaderBytes() int {
+	return r.ServerMaxHeaderBytes
+}
+
+func (r *RepositoryImpl) GetServerMaxBodyBytes() int64 {
+	return r.ServerMaxBodyBytes
+}
+
 func (r *RepositoryImpl) GetAuthxHost() string {
 	return r.AuthxHost
 }
@@ -106,3 +318,123 @@ func (r *RepositoryImpl) GetOrgHost() string {
 func (r *RepositoryImpl) GetOrgPort() int {
 	return r.OrgPort
 }
+
+func (r *RepositoryImpl) GetOutboxInterval() time.Duration {
+	return r.OutboxInterval
+}
+
+func (r *RepositoryImpl) GetOutboxBatchSize() int {
+	return r.OutboxBatchSize
+}
+
+func (r *RepositoryImpl) GetOutboxWebhookUrls() []string {
+	return r.OutboxWebhookUrls
+}
+
+func (r *RepositoryImpl) GetOutboxFilePath() string {
+	return r.OutboxFilePath
+}
+
+func (r *RepositoryImpl) GetWebhookInterval() time.Duration {
+	return r.WebhookInterval
+}
+
+func (r *RepositoryImpl) GetWebhookBatchSize() int {
+	return r.WebhookBatchSize
+}
+
+func (r *RepositoryImpl) GetWebhookMaxAttempts() int {
+	return r.WebhookMaxAttempts
+}
+
+func (r *RepositoryImpl) GetWebhookTimeout() time.Duration {
+	return r.WebhookTimeout
+}
+
+func (r *RepositoryImpl) GetHealthTimeout() time.Duration {
+	return r.HealthTimeout
+}
+
+func (r *RepositoryImpl) GetHealthCriticalDependencies() []string {
+	return r.HealthCriticalDependencies
+}
+
+func (r *RepositoryImpl) GetDegradedEnabled() bool {
+	return r.DegradedEnabled
+}
+
+func (r *RepositoryImpl) GetBatchMaxSize() int {
+	return r.BatchMaxSize
+}
+
+func (r *RepositoryImpl) GetBatchConcurrency() int {
+	return r.BatchConcurrency
+}
+
+func (r *RepositoryImpl) GetOrgNodesMaxHistoryLookups() int {
+	return r.OrgNodesMaxHistoryLookups
+}
+
+func (r *RepositoryImpl) GetCareerGradeOrder() []string {
+	return r.CareerGradeOrder
+}
+
+func (r *RepositoryImpl) GetCareerNodeTypes() []string {
+	return r.CareerNodeTypes
+}
+
+func (r *RepositoryImpl) GetCacheBackend() string {
+	return r.CacheBackend
+}
+
+func (r *RepositoryImpl) GetCacheLruCapacity() int {
+	return r.CacheLruCapacity
+}
+
+func (r *RepositoryImpl) GetCacheRedisAddress() string {
+	return r.CacheRedisAddress
+}
+
+func (r *RepositoryImpl) GetCacheRedisPassword() string {
+	return r.CacheRedisPassword
+}
+
+func (r *RepositoryImpl) GetCacheRedisDb() int {
+	return r.CacheRedisDb
+}
+
+func (r *RepositoryImpl) GetCacheRedisKeyPrefix() string {
+	return r.CacheRedisKeyPrefix
+}
+
+func (r *RepositoryImpl) GetOrgCachePolicy() CachePolicy {
+	return r.OrgCachePolicy
+}
+
+func (r *RepositoryImpl) GetAuthxCachePolicy() CachePolicy {
+	return r.AuthxCachePolicy
+}
+
+func (r *RepositoryImpl) GetTracingExporter() string {
+	return r.TracingExporter
+}
+
+func (r *RepositoryImpl) GetTracingEndpoint() string {
+	return r.TracingEndpoint
+}
+
+func (r *RepositoryImpl) GetTracingInsecure() bool {
+	return r.TracingInsecure
+}
+
+func (r *RepositoryImpl) GetTracingSampleRatio() float64 {
+	return r.TracingSampleRatio
+}
+
+func (r *RepositoryImpl) GetOrgClientPolicy() ClientPolicy {
+	return r.OrgClientPolicy
+}
+
+func (r *RepositoryImpl) GetAuthxClientPolicy() ClientPolicy {
+	return r.AuthxClientPolicy
+}
@@ -1,8 +1,11 @@
 package config
 
 import (
+	"errors"
+	"fmt"
 	"strings"
 
+	"github.com/mrexmelle/connect-emp/internal/metrics"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
@@ -16,7 +19,7 @@ type Service struct {
 
 func NewService(
 	cr Repository,
-) *Service {
+) (*Service, error) {
 	readDb, err := gorm.Open(
 		postgres.Open(strings.TrimSpace(cr.GetReadDsn())),
 		&gorm.Config{
@@ -25,7 +28,7 @@ func NewService(
 		},
 	)
 	if err != nil {
-		panic(err)
+		return nil, fmt.Errorf("opening read database: %w", err)
 	}
 
 	writeDb, err := gorm.Open(
@@ -36,14 +39,21 @@ func NewService(
 		},
 	)
 	if err != nil {
-		panic(err)
+		return nil, fmt.Errorf("opening write database: %w", err)
+	}
+
+	for name, db := range map[string]*gorm.DB{"read": readDb, "write": writeDb} {
+		err = instrument(name, db)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	return &Service{
 		ConfigRepository: cr,
 		ReadDb:           readDb,
 		WriteDb:          writeDb,
-	}
+	}, nil
 }
 
 func (s *Service) GetProfile() string {
@@ -53,3 +63,28 @@ func (s *Service) GetProfile() string {
 func (s *Service) GetPort() int {
 	return s.ConfigRepository.GetPort()
 }
+
+func (s *Service) Close() error {
+	errs := []error{}
+	for _, db := range []*gorm.DB{s.ReadDb, s.WriteDb} {
+		sqlDb, err := db.DB()
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		errs = append(errs, sqlDb.Close())
+	}
+	return errors.Join(errs...)
+}
+
+func instrument(name string, db *gorm.DB) error {
+	err := db.Use(metrics.NewGormPlugin(name))
+	if err != nil {
+		return err
+	}
+	sqlDb, err := db.DB()
+	if err != nil {
+		return err
+	}
+	return metrics.RegisterDbStats(name, sqlDb)
+}
@@ -0,0 +1,268 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cast"
+)
+
+var (
+	requiredKeys = []string{
+		"app.server.port",
+		"app.client.authx.host",
+		"app.client.authx.port",
+		"app.client.org.host",
+		"app.client.org.port",
+	}
+
+	optionalKeys = []string{
+		"app.outbox.sink.webhook.urls",
+		"app.outbox.sink.file.path",
+		"app.cache.redis.address",
+		"app.cache.redis.password",
+		"app.career.grade-order",
+		"app.career.node-types",
+		"app.tracing.endpoint",
+		"app.tracing.insecure",
+	}
+
+	durationKeys = []string{
+		"app.server.read-timeout",
+		"app.server.read-header-timeout",
+		"app.server.write-timeout",
+		"app.server.idle-timeout",
+		"app.server.shutdown-timeout",
+		"app.outbox.interval",
+		"app.webhook.interval",
+		"app.webhook.timeout",
+		"app.health.timeout",
+		"app.client.authx.timeout",
+		"app.client.authx.retry-backoff",
+		"app.client.authx.breaker-cooldown",
+		"app.client.org.timeout",
+		"app.client.org.retry-backoff",
+		"app.client.org.breaker-cooldown",
+		"app.cache.org.ttl",
+		"app.cache.authx.ttl",
+	}
+
+	nonNegativeDurationKeys = []string{
+		"app.cache.org.stale-ttl",
+		"app.cache.authx.stale-ttl",
+	}
+
+	positiveIntKeys = []string{
+		"app.server.max-header-bytes",
+		"app.server.max-body-bytes",
+		"app.outbox.batch-size",
+		"app.webhook.batch-size",
+		"app.webhook.max-attempts",
+		"app.client.authx.breaker-threshold",
+		"app.client.org.breaker-threshold",
+		"app.cache.lru.capacity",
+		"app.batch.max-size",
+		"app.batch.concurrency",
+		"app.org-nodes.max-history-lookups",
+	}
+
+	nonNegativeIntKeys = []string{
+		"app.client.authx.max-retries",
+		"app.client.org.max-retries",
+		"app.cache.redis.db",
+	}
+
+	portKeys = []string{
+		"app.server.port",
+		"app.client.authx.port",
+		"app.client.org.port",
+		"app.datasource.read.port",
+		"app.datasource.write.port",
+	}
+
+	hostUrlKeys = []string{
+		"app.client.authx.host",
+		"app.client.org.host",
+	}
+
+	requiredDatasourceKeys = []string{
+		"host",
+		"user",
+		"dbname",
+	}
+
+	HealthDependencies = []string{
+		"read_db",
+		"write_db",
+		"org",
+		"authx",
+	}
+
+	CacheBackends = []string{
+		"none",
+		"lru",
+		"redis",
+	}
+
+	TracingExporters = []string{
+		"none",
+		"stdout",
+		"otlp",
+	}
+)
+
+type ValidationError struct {
+	Key     string
+	Message string
+}
+
+func (e ValidationError) Error() string {
+	return fmt.Sprintf("%s (%s): %s", e.Key, EnvName(e.Key), e.Message)
+}
+
+type ValidationErrors []ValidationError
+
+func (e ValidationErrors) Error() string {
+	lines := []string{}
+	for _, ve := range e {
+		lines = append(lines, ve.Error())
+	}
+	return "invalid configuration:\n  " + strings.Join(lines, "\n  ")
+}
+
+// Validate checks every key the service reads and reports all problems at
+// once rather than stopping at the first one.
+func (l *Loader) Validate() error {
+	v := l.Viper
+	errs := ValidationErrors{}
+	fail := func(key string, format string, args ...any) {
+		errs = append(errs, ValidationError{Key: key, Message: fmt.Sprintf(format, args...)})
+	}
+	isSet := func(key string) bool {
+		return strings.TrimSpace(v.GetString(key)) != ""
+	}
+
+	for _, key := range requiredKeys {
+		if !isSet(key) {
+			fail(key, "is required")
+		}
+	}
+	for _, name := range []string{"read", "write"} {
+		for _, key := range requiredDatasourceKeys {
+			key = "app.datasource." + name + "." + key
+			if !isSet(key) {
+				fail(key, "is required")
+			}
+		}
+	}
+
+	for _, key := range portKeys {
+		if !isSet(key) {
+			continue
+		}
+		port, err := cast.ToIntE(v.Get(key))
+		if err != nil || port < 1 || port > 65535 {
+			fail(key, "must be a port between 1 and 65535, got %q", v.GetString(key))
+		}
+	}
+
+	for _, key := range durationKeys {
+		d, err := cast.ToDurationE(v.Get(key))
+		if err != nil || d <= 0 {
+			fail(key, "must be a positive duration such as 5s, got %q", v.GetString(key))
+		}
+	}
+
+	for _, key := range nonNegativeDurationKeys {
+		d, err := cast.ToDurationE(v.Get(key))
+		if err != nil || d < 0 {
+			fail(key, "must be zero or a positive duration such as 1m, got %q", v.GetString(key))
+		}
+	}
+
+	for _, key := range positiveIntKeys {
+		n, err := cast.ToInt64E(v.Get(key))
+		if err != nil || n <= 0 {
+			fail(key, "must be a positive integer, got %q", v.GetString(key))
+		}
+	}
+
+	for _, key := range nonNegativeIntKeys {
+		n, err := cast.ToInt64E(v.Get(key))
+		if err != nil || n < 0 {
+			fail(key, "must be zero or a positive integer, got %q", v.GetString(key))
+		}
+	}
+
+	for _, key := range hostUrlKeys {
+		if !isSet(key) {
+			continue
+		}
+		u, err := url.Parse(v.GetString(key))
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			fail(key, "must be an http(s) URL without port, got %q", v.GetString(key))
+		}
+	}
+
+	for _, raw := range v.GetStringSlice("app.outbox.sink.webhook.urls") {
+		u, err := url.Parse(raw)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			fail("app.outbox.sink.webhook.urls", "must hold http(s) URLs, got %q", raw)
+		}
+	}
+
+	for _, dep := range v.GetStringSlice("app.health.critical") {
+		if !slices.Contains(HealthDependencies, dep) {
+			fail(
+				"app.health.critical",
+				"unknown dependency %q, expected one of %s",
+				dep,
+				strings.Join(HealthDependencies, ", "),
+			)
+		}
+	}
+
+	grades := v.GetStringSlice("app.career.grade-order")
+	for i, grade := range grades {
+		if slices.Contains(grades[:i], grade) {
+			fail("app.career.grade-order", "lists %q more than once", grade)
+		}
+	}
+
+	backend := v.GetString("app.cache.backend")
+	if !slices.Contains(CacheBackends, backend) {
+		fail(
+			"app.cache.backend",
+			"must be one of %s, got %q",
+			strings.Join(CacheBackends, ", "),
+			backend,
+		)
+	}
+	if backend == "redis" && !isSet("app.cache.redis.address") {
+		fail("app.cache.redis.address", "is required when app.cache.backend is redis")
+	}
+
+	exporter := v.GetString("app.tracing.exporter")
+	if !slices.Contains(TracingExporters, exporter) {
+		fail(
+			"app.tracing.exporter",
+			"must be one of %s, got %q",
+			strings.Join(TracingExporters, ", "),
+			exporter,
+		)
+	}
+	if exporter == "otlp" && !isSet("app.tracing.endpoint") {
+		fail("app.tracing.endpoint", "is required when app.tracing.exporter is otlp")
+	}
+	ratio, err := cast.ToFloat64E(v.Get("app.tracing.sample-ratio"))
+	if err != nil || ratio < 0 || ratio > 1 {
+		fail("app.tracing.sample-ratio", "must be a number between 0 and 1, got %q", v.GetString("app.tracing.sample-ratio"))
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package dateinterval
+
+import (
+	"sort"
+
+	"github.com/mrexmelle/connect-emp/internal/datestr"
+)
+
+// Span is an interval holding a value, such as a grade, a title or an
+// organization node.
+type Span struct {
+	*Class
+	Value string
+}
+
+// IsFollowedBy tells whether other starts the day after c ends.
+func (c *Class) IsFollowedBy(other *Class) bool {
+	return !c.EndDate.IsIndeterminate() && c.EndDate.OffsetAndClone(+1).Equals(other.StartDate)
+}
+
+// DaysUntil counts the days of c up to and including d.
+func (c *Class) DaysUntil(d *datestr.Class) int {
+	if c.StartDate.IsAfter(d) {
+		return 0
+	}
+	end := d
+	if c.EndDate.IsBefore(d) {
+		end = c.EndDate
+	}
+	return int(end.AsTime().Sub(c.StartDate.AsTime()).Hours()/24) + 1
+}
+
+// MergeSpans orders the spans by start date and joins the ones that follow
+// each other and hold the same value. Spans must not overlap.
+func MergeSpans(spans []Span) []Span {
+	sorted := append([]Span{}, spans...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].StartDate.IsBefore(sorted[j].StartDate)
+	})
+
+	merged := []Span{}
+	for _, s := range sorted {
+		last := len(merged) - 1
+		if last >= 0 && merged[last].Value == s.Value && merged[last].IsFollowedBy(s.Class) {
+			merged[last] = Span{
+				Class: &Class{StartDate: merged[last].StartDate, EndDate: s.EndDate},
+				Value: s.Value,
+			}
+			continue
+		}
+		merged = append(merged, s)
+	}
+	return merged
+}
@@ -0,0 +1,142 @@
+package dateinterval
+
+import (
+	"testing"
+
+	"github.com/mrexmelle/connect-emp/internal/datestr"
+)
+
+type DaysUntilTestCase struct {
+	name      string
+	startDate string
+	endDate   string
+	d         string
+	expected  int
+}
+
+type MergeSpansTestCase struct {
+	name     string
+	spans    []Span
+	expected []Span
+}
+
+func newSpan(sd string, ed string, value string) Span {
+	c, _ := NewFromStrings(sd, ed)
+	return Span{Class: c, Value: value}
+}
+
+func TestDaysUntil(t *testing.T) {
+	tc := []DaysUntilTestCase{
+		{
+			name:      "Date within interval",
+			startDate: "2024-01-01",
+			endDate:   "2024-12-31",
+			d:         "2024-01-31",
+			expected:  31,
+		},
+		{
+			name:      "Date after interval",
+			startDate: "2024-02-01",
+			endDate:   "2024-02-29",
+			d:         "2024-12-31",
+			expected:  29,
+		},
+		{
+			name:      "Date before interval",
+			startDate: "2024-02-01",
+			endDate:   "2024-02-29",
+			d:         "2024-01-31",
+			expected:  0,
+		},
+		{
+			name:      "Open-ended interval",
+			startDate: "2023-03-01",
+			endDate:   "",
+			d:         "2024-02-29",
+			expected:  366,
+		},
+	}
+
+	for _, c := range tc {
+		interval, _ := NewFromStrings(c.startDate, c.endDate)
+		d, _ := datestr.NewFromString(c.d)
+		result := interval.DaysUntil(d)
+		if result != c.expected {
+			t.Errorf("[%s]\nresult: %d\nexpected: %d\n",
+				c.name,
+				result,
+				c.expected,
+			)
+		}
+	}
+}
+
+func TestMergeSpans(t *testing.T) {
+	tc := []MergeSpansTestCase{
+		{
+			name: "Following spans with the same value",
+			spans: []Span{
+				newSpan("2020-01-01", "2020-12-31", "E4"),
+				newSpan("2021-01-01", "2021-06-30", "E4"),
+				newSpan("2021-07-01", "", "E4"),
+			},
+			expected: []Span{
+				newSpan("2020-01-01", "", "E4"),
+			},
+		},
+		{
+			name: "Following spans with different values",
+			spans: []Span{
+				newSpan("2020-01-01", "2020-12-31", "E4"),
+				newSpan("2021-01-01", "", "E5"),
+			},
+			expected: []Span{
+				newSpan("2020-01-01", "2020-12-31", "E4"),
+				newSpan("2021-01-01", "", "E5"),
+			},
+		},
+		{
+			name: "Gap between spans with the same value",
+			spans: []Span{
+				newSpan("2020-01-01", "2020-12-31", "E4"),
+				newSpan("2021-02-01", "", "E4"),
+			},
+			expected: []Span{
+				newSpan("2020-01-01", "2020-12-31", "E4"),
+				newSpan("2021-02-01", "", "E4"),
+			},
+		},
+		{
+			name: "Spans out of order",
+			spans: []Span{
+				newSpan("2021-01-01", "", "E5"),
+				newSpan("2020-07-01", "2020-12-31", "E4"),
+				newSpan("2020-01-01", "2020-06-30", "E4"),
+			},
+			expected: []Span{
+				newSpan("2020-01-01", "2020-12-31", "E4"),
+				newSpan("2021-01-01", "", "E5"),
+			},
+		},
+		{
+			name:     "No spans",
+			spans:    []Span{},
+			expected: []Span{},
+		},
+	}
+
+	for _, c := range tc {
+		result := MergeSpans(c.spans)
+		equal := len(result) == len(c.expected)
+		for i := 0; equal && i < len(result); i++ {
+			equal = result[i].Value == c.expected[i].Value && result[i].Equals(c.expected[i].Class)
+		}
+		if !equal {
+			t.Errorf("[%s]\nresult: %v\nexpected: %v\n",
+				c.name,
+				result,
+				c.expected,
+			)
+		}
+	}
+}
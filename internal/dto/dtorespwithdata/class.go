@@ -8,8 +8,9 @@ import (
 )
 
 type Class[T any] struct {
-	Data  *T               `json:"data"`
-	Error dto.ServiceError `json:"error"`
+	Data     *T               `json:"data"`
+	Error    dto.ServiceError `json:"error"`
+	Warnings []dto.Warning    `json:"warnings,omitempty"`
 
 	PreWriteHook func(*T) `json:"-"`
 }
@@ -29,6 +30,11 @@ func NewError(errCode string, errMessage string) *Class[any] {
 	return New[any](nil, errCode, errMessage)
 }
 
+func (c *Class[T]) WithWarnings(warnings []dto.Warning) *Class[T] {
+	c.Warnings = warnings
+	return c
+}
+
 func (c *Class[T]) WithPrewriteHook(hook func(*T)) *Class[T] {
 	c.PreWriteHook = hook
 	return c
@@ -4,3 +4,10 @@ type ServiceError struct {
 	Code    string `json:"code"`
 	Message string `json:"message"`
 }
+
+// Warning reports a source that failed without failing the whole request.
+type Warning struct {
+	Source  string `json:"source"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
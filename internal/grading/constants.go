@@ -0,0 +1,10 @@
+package grading
+
+var (
+	AggregateType = "grading"
+
+	EventTypeCreated = "grading.created"
+	EventTypeUpdated = "grading.updated"
+	EventTypeEnded   = "grading.ended"
+	EventTypeDeleted = "grading.deleted"
+)
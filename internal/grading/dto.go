@@ -17,6 +17,7 @@ type PatchRequestDto struct {
 }
 
 type GetResponseDto = dtorespwithdata.Class[ViewEntity]
+type GetByEhidResponseDto = dtorespwithdata.Class[[]ViewEntity]
 type PostResponseDto = dtorespwithdata.Class[ViewEntity]
 type PatchResponseDto = dtorespwithoutdata.Class
 type DeleteResponseDto = dtorespwithoutdata.Class
@@ -6,19 +6,23 @@ import (
 )
 
 type Entity struct {
-	Id        int
-	Ehid      string
-	StartDate time.Time
-	EndDate   sql.NullTime
-	Grade     string
+	Id           int
+	Ehid         string
+	StartDate    time.Time
+	EndDate      sql.NullTime
+	Grade        string
+	RecordedFrom sql.NullTime
+	RecordedTo   sql.NullTime
 }
 
 type ViewEntity struct {
-	Id        int    `json:"id"`
-	Ehid      string `json:"ehid"`
-	StartDate string `json:"start_date"`
-	EndDate   string `json:"end_date"`
-	Grade     string `json:"grade"`
+	Id           int    `json:"id"`
+	Ehid         string `json:"ehid"`
+	StartDate    string `json:"start_date"`
+	EndDate      string `json:"end_date"`
+	Grade        string `json:"grade"`
+	RecordedFrom string `json:"recorded_from,omitempty"`
+	RecordedTo   string `json:"recorded_to,omitempty"`
 }
 
 func toViewEntity(e *Entity) *ViewEntity {
@@ -26,12 +30,22 @@ func toViewEntity(e *Entity) *ViewEntity {
 	if e.EndDate.Valid {
 		ed = e.EndDate.Time.Format("2006-01-02")
 	}
+	rf := ""
+	if e.RecordedFrom.Valid {
+		rf = e.RecordedFrom.Time.Format(time.RFC3339)
+	}
+	rt := ""
+	if e.RecordedTo.Valid {
+		rt = e.RecordedTo.Time.Format(time.RFC3339)
+	}
 	return &ViewEntity{
-		Id:        e.Id,
-		Ehid:      e.Ehid,
-		StartDate: e.StartDate.Format("2006-01-02"),
-		EndDate:   ed,
-		Grade:     e.Grade,
+		Id:           e.Id,
+		Ehid:         e.Ehid,
+		StartDate:    e.StartDate.Format("2006-01-02"),
+		EndDate:      ed,
+		Grade:        e.Grade,
+		RecordedFrom: rf,
+		RecordedTo:   rt,
 	}
 }
 
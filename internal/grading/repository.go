@@ -1,99 +1,198 @@
 package grading
 
 import (
+	"context"
+	"errors"
+	"strconv"
 	"time"
 
 	"github.com/mrexmelle/connect-emp/internal/config"
+	"github.com/mrexmelle/connect-emp/internal/outbox"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type Repository interface {
-	Create(req *Entity) (*Entity, error)
-	FindById(id int) (*Entity, error)
-	UpdateById(fields map[string]interface{}, id int) error
-	DeleteById(id int) error
-	FindByEhidOrderByStartDate(ehid string, orderDir string) ([]Entity, error)
-	FindCurrentByEhid(ehid string) (*Entity, error)
-	CountIntersectingDates(ehid string, startDate string, endDate string) (int64, error)
-	CountEndDateIsNull(ehid string) (int64, error)
+	Create(ctx context.Context, req *Entity) (*Entity, error)
+	FindById(ctx context.Context, id int) (*Entity, error)
+	UpdateById(ctx context.Context, fields map[string]interface{}, id int) error
+	DeleteById(ctx context.Context, id int) error
+	FindByEhidOrderByStartDate(ctx context.Context, ehid string, orderDir string) ([]Entity, error)
+	FindByEhidRecordedAtOrderByStartDate(ctx context.Context, ehid string, recordedAt time.Time, orderDir string) ([]Entity, error)
+	FindCurrentByEhid(ctx context.Context, ehid string) (*Entity, error)
+	FindByEhidsOrderByStartDate(ctx context.Context, ehids []string, orderDir string) ([]Entity, error)
+	FindCurrentByEhids(ctx context.Context, ehids []string) ([]Entity, error)
+	FindByEhidsActiveOn(ctx context.Context, ehids []string, date string) ([]Entity, error)
+	FindEhidsActiveOn(ctx context.Context, date string) ([]string, error)
+	FindEhidsActiveBetween(ctx context.Context, startDate string, endDate string) ([]string, error)
+	CountIntersectingDates(ctx context.Context, ehid string, startDate string, endDate string) (int64, error)
+	CountEndDateIsNull(ctx context.Context, ehid string) (int64, error)
 }
 
 type RepositoryImpl struct {
-	ConfigService *config.Service
-	TableName     string
-	Query         Query
+	ConfigService    *config.Service
+	TableName        string
+	HistoryTableName string
+	Query            Query
+	HistoryQuery     Query
+	OutboxRepository outbox.Repository
 }
 
-func NewRepository(cfg *config.Service) Repository {
+func NewRepository(cfg *config.Service, obr outbox.Repository) Repository {
 	return &RepositoryImpl{
-		ConfigService: cfg,
-		TableName:     "gradings",
-		Query:         NewQuery(cfg.ReadDb, "gradings"),
-	}
-}
-
-func (r *RepositoryImpl) Create(req *Entity) (*Entity, error) {
-	var res *gorm.DB
-	if req.EndDate.Valid {
-		res = r.ConfigService.WriteDb.Raw(
-			"INSERT INTO "+r.TableName+"(ehid, start_date, end_date, grade, "+
-				"created_at, updated_at) "+
-				"VALUES(?, ?, ?, ?, NOW(), NOW()) RETURNING id",
-			req.Ehid,
-			req.StartDate,
-			req.EndDate.Time,
-			req.Grade,
-		).Scan(&req.Id)
-	} else {
-		res = r.ConfigService.WriteDb.Raw(
-			"INSERT INTO "+r.TableName+"(ehid, start_date, grade, "+
-				"created_at, updated_at) "+
-				"VALUES(?, ?, ?, NOW(), NOW()) RETURNING id",
-			req.Ehid,
-			req.StartDate,
-			req.Grade,
-		).Scan(&req.Id)
-	}
-
-	if res.Error != nil {
-		return nil, res.Error
+		ConfigService:    cfg,
+		TableName:        "gradings",
+		HistoryTableName: "gradings_history",
+		Query:            NewQuery(cfg.ReadDb, "gradings"),
+		HistoryQuery:     NewQuery(cfg.ReadDb, "gradings_history"),
+		OutboxRepository: obr,
+	}
+}
+
+func (r *RepositoryImpl) Create(ctx context.Context, req *Entity) (*Entity, error) {
+	err := r.ConfigService.WriteDb.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var res *gorm.DB
+		if req.EndDate.Valid {
+			res = tx.Raw(
+				"INSERT INTO "+r.TableName+"(ehid, start_date, end_date, grade, "+
+					"created_at, updated_at) "+
+					"VALUES(?, ?, ?, ?, NOW(), NOW()) RETURNING id",
+				req.Ehid,
+				req.StartDate,
+				req.EndDate.Time,
+				req.Grade,
+			).Scan(&req.Id)
+		} else {
+			res = tx.Raw(
+				"INSERT INTO "+r.TableName+"(ehid, start_date, grade, "+
+					"created_at, updated_at) "+
+					"VALUES(?, ?, ?, NOW(), NOW()) RETURNING id",
+				req.Ehid,
+				req.StartDate,
+				req.Grade,
+			).Scan(&req.Id)
+		}
+		if res.Error != nil {
+			return res.Error
+		}
+
+		err := r.recordVersion(tx, req.Id)
+		if err != nil {
+			return err
+		}
+
+		return r.publish(tx, EventTypeCreated, req)
+	})
+
+	if err != nil {
+		return nil, err
 	}
 
 	return req, nil
 }
 
-func (r *RepositoryImpl) FindById(id int) (*Entity, error) {
+func (r *RepositoryImpl) FindById(ctx context.Context, id int) (*Entity, error) {
 	response := Entity{
 		Id: id,
 	}
-	result := r.Query.SelectById(FieldsAllExceptId, id).First(&response)
+	result := r.Query.SelectById(FieldsAllExceptId, id).WithContext(ctx).First(&response)
 	if result.Error != nil {
 		return nil, result.Error
 	}
 	return &response, nil
 }
 
-func (r *RepositoryImpl) FindByEhidOrderByStartDate(ehid string, orderDir string) ([]Entity, error) {
+func (r *RepositoryImpl) FindByEhidOrderByStartDate(ctx context.Context, ehid string, orderDir string) ([]Entity, error) {
 	response := []Entity{}
-	result := r.Query.SelectByEhidOrderByStartDate(FieldsAll, ehid, orderDir).Find(&response)
+	result := r.Query.SelectByEhidOrderByStartDate(FieldsAll, ehid, orderDir).WithContext(ctx).Find(&response)
 	if result.Error != nil {
 		return []Entity{}, result.Error
 	}
 	return response, nil
 }
 
-func (r *RepositoryImpl) FindCurrentByEhid(ehid string) (*Entity, error) {
+func (r *RepositoryImpl) FindByEhidRecordedAtOrderByStartDate(
+	ctx context.Context,
+	ehid string,
+	recordedAt time.Time,
+	orderDir string,
+) ([]Entity, error) {
+	response := []Entity{}
+	result := r.HistoryQuery.
+		SelectByEhidRecordedAtOrderByStartDate(FieldsHistoryAll, ehid, recordedAt, orderDir).
+		WithContext(ctx).
+		Find(&response)
+	if result.Error != nil {
+		return []Entity{}, result.Error
+	}
+	return response, nil
+}
+
+func (r *RepositoryImpl) FindCurrentByEhid(ctx context.Context, ehid string) (*Entity, error) {
 	response := Entity{
 		Ehid: ehid,
 	}
-	result := r.Query.SelectActiveByEhid(FieldsAll, ehid).First(&response)
+	result := r.Query.SelectActiveByEhid(FieldsAll, ehid).WithContext(ctx).First(&response)
 	if result.Error != nil {
 		return nil, result.Error
 	}
 	return &response, nil
 }
 
-func (r *RepositoryImpl) UpdateById(fields map[string]interface{}, id int) error {
+func (r *RepositoryImpl) FindByEhidsOrderByStartDate(
+	ctx context.Context,
+	ehids []string,
+	orderDir string,
+) ([]Entity, error) {
+	response := []Entity{}
+	result := r.Query.SelectByEhidsOrderByStartDate(FieldsAll, ehids, orderDir).WithContext(ctx).Find(&response)
+	if result.Error != nil {
+		return []Entity{}, result.Error
+	}
+	return response, nil
+}
+
+func (r *RepositoryImpl) FindCurrentByEhids(ctx context.Context, ehids []string) ([]Entity, error) {
+	response := []Entity{}
+	result := r.Query.SelectActiveByEhids(FieldsAll, ehids).WithContext(ctx).Find(&response)
+	if result.Error != nil {
+		return []Entity{}, result.Error
+	}
+	return response, nil
+}
+
+func (r *RepositoryImpl) FindByEhidsActiveOn(ctx context.Context, ehids []string, date string) ([]Entity, error) {
+	response := []Entity{}
+	result := r.Query.SelectByEhidsActiveOn(FieldsAll, ehids, date).WithContext(ctx).Find(&response)
+	if result.Error != nil {
+		return []Entity{}, result.Error
+	}
+	return response, nil
+}
+
+func (r *RepositoryImpl) FindEhidsActiveOn(ctx context.Context, date string) ([]string, error) {
+	response := []string{}
+	result := r.Query.SelectEhidsActiveOn(date).WithContext(ctx).Pluck("ehid", &response)
+	if result.Error != nil {
+		return []string{}, result.Error
+	}
+	return response, nil
+}
+
+func (r *RepositoryImpl) FindEhidsActiveBetween(
+	ctx context.Context,
+	startDate string,
+	endDate string,
+) ([]string, error) {
+	response := []string{}
+	result := r.Query.SelectEhidsActiveBetween(startDate, endDate).WithContext(ctx).Pluck("ehid", &response)
+	if result.Error != nil {
+		return []string{}, result.Error
+	}
+	return response, nil
+}
+
+func (r *RepositoryImpl) UpdateById(ctx context.Context, fields map[string]interface{}, id int) error {
 	dbFields := map[string]interface{}{}
 
 	for i := range FieldsPatchable {
@@ -104,9 +203,13 @@ func (r *RepositoryImpl) UpdateById(fields map[string]interface{}, id int) error
 		}
 	}
 
-	if len(dbFields) > 0 {
+	if len(dbFields) == 0 {
+		return nil
+	}
+
+	return r.ConfigService.WriteDb.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
 		dbFields["updated_at"] = time.Now()
-		result := r.ConfigService.WriteDb.
+		result := tx.
 			Table(r.TableName).
 			Where("id = ?", id).
 			Updates(dbFields)
@@ -118,22 +221,103 @@ func (r *RepositoryImpl) UpdateById(fields map[string]interface{}, id int) error
 		if result.RowsAffected == 0 {
 			return gorm.ErrRecordNotFound
 		}
-	}
 
-	return nil
+		err := r.closeVersion(tx, id)
+		if err != nil {
+			return err
+		}
+
+		err = r.recordVersion(tx, id)
+		if err != nil {
+			return err
+		}
+
+		e, err := r.findByIdForUpdate(tx, id)
+		if err != nil {
+			return err
+		}
+
+		eventType := EventTypeUpdated
+		if _, ok := dbFields["end_date"]; ok && e.EndDate.Valid {
+			eventType = EventTypeEnded
+		}
+		return r.publish(tx, eventType, e)
+	})
+}
+
+func (r *RepositoryImpl) DeleteById(ctx context.Context, id int) error {
+	return r.ConfigService.WriteDb.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		e, err := r.findByIdForUpdate(tx, id)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		result := tx.
+			Table(r.TableName).
+			Delete("id = ?", id)
+		if result.Error != nil {
+			return result.Error
+		}
+
+		err = r.closeVersion(tx, id)
+		if err != nil {
+			return err
+		}
+
+		return r.publish(tx, EventTypeDeleted, e)
+	})
 }
 
-func (r *RepositoryImpl) DeleteById(id int) error {
-	result := r.ConfigService.WriteDb.
+func (r *RepositoryImpl) findByIdForUpdate(tx *gorm.DB, id int) (*Entity, error) {
+	response := Entity{}
+	result := tx.
+		Select(FieldsAll).
 		Table(r.TableName).
-		Delete("id = ?", id)
+		Where("id = ?", id).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		First(&response)
 	if result.Error != nil {
-		return result.Error
+		return nil, result.Error
+	}
+	return &response, nil
+}
+
+func (r *RepositoryImpl) publish(tx *gorm.DB, eventType string, e *Entity) error {
+	event, err := outbox.NewEntity(
+		eventType,
+		AggregateType,
+		strconv.Itoa(e.Id),
+		toViewEntity(e),
+	)
+	if err != nil {
+		return err
 	}
-	return nil
+	return r.OutboxRepository.Append(tx, event)
+}
+
+func (r *RepositoryImpl) recordVersion(tx *gorm.DB, id int) error {
+	return tx.Exec(
+		"INSERT INTO "+r.HistoryTableName+"(grading_id, ehid, start_date, end_date, grade, "+
+			"recorded_from) "+
+			"SELECT id, ehid, start_date, end_date, grade, NOW() "+
+			"FROM "+r.TableName+" WHERE id = ?",
+		id,
+	).Error
+}
+
+func (r *RepositoryImpl) closeVersion(tx *gorm.DB, id int) error {
+	return tx.Exec(
+		"UPDATE "+r.HistoryTableName+" SET recorded_to = NOW() "+
+			"WHERE grading_id = ? AND recorded_to IS NULL",
+		id,
+	).Error
 }
 
 func (r *RepositoryImpl) CountIntersectingDates(
+	ctx context.Context,
 	ehid string,
 	startDate string,
 	endDate string,
@@ -141,6 +325,7 @@ func (r *RepositoryImpl) CountIntersectingDates(
 	var countResult int64
 	result := r.Query.
 		ByEhidAndIntersectingDates(ehid, startDate, endDate).
+		WithContext(ctx).
 		Count(&countResult)
 
 	if result.Error != nil {
@@ -150,10 +335,11 @@ func (r *RepositoryImpl) CountIntersectingDates(
 	return countResult, nil
 }
 
-func (r *RepositoryImpl) CountEndDateIsNull(ehid string) (int64, error) {
+func (r *RepositoryImpl) CountEndDateIsNull(ctx context.Context, ehid string) (int64, error) {
 	var countResult int64
 	result := r.Query.
 		ByEhidAndEndDateIsNull(ehid).
+		WithContext(ctx).
 		Count(&countResult)
 
 	if result.Error != nil {
@@ -0,0 +1,12 @@
+package health
+
+var (
+	StatusUp       = "up"
+	StatusDegraded = "degraded"
+	StatusDown     = "down"
+
+	DependencyReadDb  = "read_db"
+	DependencyWriteDb = "write_db"
+	DependencyOrg     = "org"
+	DependencyAuthx   = "authx"
+)
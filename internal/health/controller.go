@@ -0,0 +1,59 @@
+package health
+
+import (
+	"net/http"
+
+	"github.com/mrexmelle/connect-emp/internal/config"
+	"github.com/mrexmelle/connect-emp/internal/dto/dtorespwithdata"
+	"github.com/mrexmelle/connect-emp/internal/localerror"
+)
+
+type Controller struct {
+	ConfigService *config.Service
+	HealthService *Service
+}
+
+func NewController(cfg *config.Service, svc *Service) *Controller {
+	return &Controller{
+		ConfigService: cfg,
+		HealthService: svc,
+	}
+}
+
+// Get Liveness : HTTP endpoint to tell whether the process is alive
+// @Tags Health
+// @Description Get liveness of the process
+// @Produce json
+// @Success 200 {object} GetResponseDto "Success Response"
+// @Router /healthz [GET]
+func (c *Controller) GetLiveness(w http.ResponseWriter, r *http.Request) {
+	dtorespwithdata.New(
+		c.HealthService.RetrieveLiveness(),
+		localerror.ErrSvcCodeNone,
+		"",
+	).RenderTo(w, http.StatusOK)
+}
+
+// Get Readiness : HTTP endpoint to tell whether the service can take traffic
+// @Tags Health
+// @Description Get readiness along with the status and latency of each dependency
+// @Produce json
+// @Success 200 {object} GetResponseDto "Up or degraded"
+// @Failure 503 {object} GetResponseDto "Down"
+// @Router /readyz [GET]
+func (c *Controller) GetReadiness(w http.ResponseWriter, r *http.Request) {
+	data := c.HealthService.RetrieveReadiness(r.Context())
+	if data.Status == StatusDown {
+		dtorespwithdata.New(
+			data,
+			localerror.ErrDependencyDown.Error(),
+			"one or more critical dependencies are down",
+		).RenderTo(w, http.StatusServiceUnavailable)
+		return
+	}
+	dtorespwithdata.New(
+		data,
+		localerror.ErrSvcCodeNone,
+		"",
+	).RenderTo(w, http.StatusOK)
+}
@@ -0,0 +1,7 @@
+package health
+
+import (
+	"github.com/mrexmelle/connect-emp/internal/dto/dtorespwithdata"
+)
+
+type GetResponseDto = dtorespwithdata.Class[ViewEntity]
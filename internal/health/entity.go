@@ -0,0 +1,14 @@
+package health
+
+type CheckViewEntity struct {
+	Name      string `json:"name"`
+	Status    string `json:"status"`
+	Critical  bool   `json:"critical"`
+	LatencyMs int64  `json:"latency_ms"`
+	Error     string `json:"error,omitempty"`
+}
+
+type ViewEntity struct {
+	Status string            `json:"status"`
+	Checks []CheckViewEntity `json:"checks,omitempty"`
+}
@@ -0,0 +1,142 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/mrexmelle/connect-emp/internal/config"
+	"gorm.io/gorm"
+)
+
+type Dependency struct {
+	Name     string
+	Critical bool
+	Check    func(ctx context.Context) error
+}
+
+type Service struct {
+	ConfigService *config.Service
+	Dependencies  []Dependency
+	Timeout       time.Duration
+}
+
+func NewService(cfg *config.Service) *Service {
+	critical := cfg.ConfigRepository.GetHealthCriticalDependencies()
+	client := &http.Client{}
+
+	s := &Service{
+		ConfigService: cfg,
+		Dependencies:  []Dependency{},
+		Timeout:       cfg.ConfigRepository.GetHealthTimeout(),
+	}
+	s.AddDependency(DependencyReadDb, slices.Contains(critical, DependencyReadDb), pingDb(cfg.ReadDb))
+	s.AddDependency(DependencyWriteDb, slices.Contains(critical, DependencyWriteDb), pingDb(cfg.WriteDb))
+	s.AddDependency(DependencyOrg, slices.Contains(critical, DependencyOrg), pingHost(
+		client,
+		cfg.ConfigRepository.GetOrgHost(),
+		cfg.ConfigRepository.GetOrgPort(),
+	))
+	s.AddDependency(DependencyAuthx, slices.Contains(critical, DependencyAuthx), pingHost(
+		client,
+		cfg.ConfigRepository.GetAuthxHost(),
+		cfg.ConfigRepository.GetAuthxPort(),
+	))
+	return s
+}
+
+func (s *Service) AddDependency(name string, critical bool, check func(ctx context.Context) error) {
+	s.Dependencies = append(s.Dependencies, Dependency{
+		Name:     name,
+		Critical: critical,
+		Check:    check,
+	})
+}
+
+func (s *Service) RetrieveLiveness() *ViewEntity {
+	return &ViewEntity{
+		Status: StatusUp,
+	}
+}
+
+// RetrieveReadiness checks every dependency concurrently. The service is down
+// when a critical dependency is down, and degraded when only non-critical
+// ones are.
+func (s *Service) RetrieveReadiness(ctx context.Context) *ViewEntity {
+	ctx, cancel := context.WithTimeout(ctx, s.Timeout)
+	defer cancel()
+
+	checks := make([]CheckViewEntity, len(s.Dependencies))
+	wg := sync.WaitGroup{}
+	for i, d := range s.Dependencies {
+		wg.Add(1)
+		go func(i int, d Dependency) {
+			defer wg.Done()
+			checks[i] = runCheck(ctx, d)
+		}(i, d)
+	}
+	wg.Wait()
+
+	status := StatusUp
+	for _, c := range checks {
+		if c.Status == StatusUp {
+			continue
+		}
+		if c.Critical {
+			status = StatusDown
+			break
+		}
+		status = StatusDegraded
+	}
+
+	return &ViewEntity{
+		Status: status,
+		Checks: checks,
+	}
+}
+
+func runCheck(ctx context.Context, d Dependency) CheckViewEntity {
+	startedAt := time.Now()
+	err := d.Check(ctx)
+	check := CheckViewEntity{
+		Name:      d.Name,
+		Status:    StatusUp,
+		Critical:  d.Critical,
+		LatencyMs: time.Since(startedAt).Milliseconds(),
+	}
+	if err != nil {
+		check.Status = StatusDown
+		check.Error = err.Error()
+	}
+	return check
+}
+
+func pingDb(db *gorm.DB) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		sqlDb, err := db.DB()
+		if err != nil {
+			return err
+		}
+		return sqlDb.PingContext(ctx)
+	}
+}
+
+// pingHost treats any HTTP response as reachable; readiness of the remote
+// service itself is its own concern.
+func pingHost(client *http.Client, host string, port int) func(ctx context.Context) error {
+	url := fmt.Sprintf("%s:%d/", host, port)
+	return func(ctx context.Context) error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return err
+		}
+		response, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		return response.Body.Close()
+	}
+}
@@ -0,0 +1,85 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+type ReadinessTestCase struct {
+	name         string
+	dependencies []Dependency
+	expected     string
+}
+
+func up(ctx context.Context) error {
+	return nil
+}
+
+func down(ctx context.Context) error {
+	return errors.New("connection refused")
+}
+
+func hanging(ctx context.Context) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func TestRetrieveReadiness(t *testing.T) {
+	tc := []ReadinessTestCase{
+		{
+			name: "Everything is up",
+			dependencies: []Dependency{
+				{Name: DependencyReadDb, Critical: true, Check: up},
+				{Name: DependencyOrg, Critical: false, Check: up},
+			},
+			expected: StatusUp,
+		},
+		{
+			name: "Non-critical dependency is down",
+			dependencies: []Dependency{
+				{Name: DependencyReadDb, Critical: true, Check: up},
+				{Name: DependencyOrg, Critical: false, Check: down},
+			},
+			expected: StatusDegraded,
+		},
+		{
+			name: "Critical dependency is down",
+			dependencies: []Dependency{
+				{Name: DependencyReadDb, Critical: true, Check: down},
+				{Name: DependencyOrg, Critical: false, Check: down},
+			},
+			expected: StatusDown,
+		},
+		{
+			name: "Critical dependency times out",
+			dependencies: []Dependency{
+				{Name: DependencyWriteDb, Critical: true, Check: hanging},
+			},
+			expected: StatusDown,
+		},
+	}
+
+	for _, c := range tc {
+		svc := &Service{
+			Dependencies: c.dependencies,
+			Timeout:      50 * time.Millisecond,
+		}
+		out := svc.RetrieveReadiness(context.Background())
+		if out.Status != c.expected {
+			t.Errorf("[%s]\nresult: %s\nexpected: %s\n",
+				c.name,
+				out.Status,
+				c.expected,
+			)
+		}
+		if len(out.Checks) != len(c.dependencies) {
+			t.Errorf("[%s]\nresult: %d checks\nexpected: %d checks\n",
+				c.name,
+				len(out.Checks),
+				len(c.dependencies),
+			)
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package httpclient
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBreaker(t *testing.T) {
+	now := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	b := NewBreaker(2, time.Minute)
+	b.Now = func() time.Time { return now }
+
+	b.RecordFailure()
+	if !b.Allow() {
+		t.Fatal("expected breaker to stay closed below threshold")
+	}
+	b.RecordFailure()
+	if b.Allow() || b.State() != BreakerOpen {
+		t.Fatalf("expected breaker to open, got %s", b.State())
+	}
+
+	now = now.Add(time.Minute)
+	if !b.Allow() || b.State() != BreakerHalfOpen {
+		t.Fatalf("expected a probe after cooldown, got %s", b.State())
+	}
+	if b.Allow() {
+		t.Fatal("expected only one probe while half open")
+	}
+	b.RecordFailure()
+	if b.Allow() || b.State() != BreakerOpen {
+		t.Fatalf("expected failed probe to re-open breaker, got %s", b.State())
+	}
+
+	now = now.Add(time.Minute)
+	b.Allow()
+	b.Release()
+	if !b.Allow() || b.State() != BreakerHalfOpen {
+		t.Fatalf("expected a released probe to let another one through, got %s", b.State())
+	}
+	b.RecordSuccess()
+	if !b.Allow() || b.State() != BreakerClosed {
+		t.Fatalf("expected successful probe to close breaker, got %s", b.State())
+	}
+}
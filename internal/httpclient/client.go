@@ -0,0 +1,161 @@
+package httpclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"time"
+
+	"github.com/mrexmelle/connect-emp/internal/config"
+	"github.com/mrexmelle/connect-emp/internal/localerror"
+	"github.com/mrexmelle/connect-emp/internal/metrics"
+	"github.com/mrexmelle/connect-emp/internal/tracing"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel/codes"
+)
+
+var (
+	errCircuitOpen = errors.New("circuit open")
+)
+
+// Client issues JSON GET requests to one downstream service. Every attempt
+// is bounded by Policy.Timeout, failed attempts are retried with jittered
+// exponential backoff, and a circuit breaker stops calling a service that
+// keeps failing. Every failure wraps localerror.ErrHttpClient.
+type Client struct {
+	Name       string
+	BaseUrl    string
+	HttpClient *http.Client
+	Policy     config.ClientPolicy
+	Breaker    *Breaker
+	Sleep      func(ctx context.Context, d time.Duration) error
+}
+
+func NewClient(name string, host string, port int, policy config.ClientPolicy) *Client {
+	return &Client{
+		Name:    name,
+		BaseUrl: fmt.Sprintf("%s:%d", host, port),
+		HttpClient: &http.Client{
+			Transport: otelhttp.NewTransport(http.DefaultTransport),
+		},
+		Policy:  policy,
+		Breaker: NewBreaker(policy.BreakerThreshold, policy.BreakerCooldown),
+		Sleep:   sleep,
+	}
+}
+
+// GetJson decodes the response to GET BaseUrl+path into out. Responses with
+// a status below 500 are decoded as they are, since the downstream services
+// report "not found" and friends inside their JSON envelope.
+func (c *Client) GetJson(ctx context.Context, operation string, path string, out any) error {
+	ctx, span := tracing.Tracer().Start(ctx, c.Name+"."+operation)
+	defer span.End()
+
+	_, err := metrics.ObserveClientCall(
+		c.Name,
+		operation,
+		func() (any, error) {
+			return out, c.getWithRetries(ctx, path, out)
+		},
+	)
+	if err != nil {
+		err = fmt.Errorf("%w: %s %s: %w", localerror.ErrHttpClient, c.Name, operation, err)
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	return err
+}
+
+func (c *Client) getWithRetries(ctx context.Context, path string, out any) error {
+	var err error
+	for attempt := 0; attempt <= c.Policy.MaxRetries; attempt++ {
+		if attempt > 0 {
+			sleepErr := c.Sleep(ctx, c.backoff(attempt))
+			if sleepErr != nil {
+				return errors.Join(err, sleepErr)
+			}
+		}
+
+		if !c.Breaker.Allow() {
+			return errCircuitOpen
+		}
+
+		var answered, retryable bool
+		answered, retryable, err = c.get(ctx, path, out)
+		switch {
+		case answered:
+			c.Breaker.RecordSuccess()
+		case ctx.Err() != nil:
+			// The caller gave up, which says nothing about the service.
+			c.Breaker.Release()
+		case retryable:
+			c.Breaker.RecordFailure()
+		default:
+			c.Breaker.Release()
+		}
+		if err == nil {
+			return nil
+		}
+		if !retryable || ctx.Err() != nil {
+			return err
+		}
+	}
+	return fmt.Errorf("giving up after %d attempts: %w", c.Policy.MaxRetries+1, err)
+}
+
+// get makes one attempt. answered tells whether the service answered below
+// 500, which shows it is up even when the body cannot be decoded, and
+// retryable whether the attempt failed in a way worth retrying.
+func (c *Client) get(ctx context.Context, path string, out any) (answered bool, retryable bool, err error) {
+	if c.Policy.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.Policy.Timeout)
+		defer cancel()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.BaseUrl+path, nil)
+	if err != nil {
+		return false, false, err
+	}
+
+	response, err := c.HttpClient.Do(req)
+	if err != nil {
+		return false, true, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode >= http.StatusInternalServerError ||
+		response.StatusCode == http.StatusTooManyRequests {
+		return false, true, fmt.Errorf("GET %s responded %s", path, response.Status)
+	}
+
+	err = json.NewDecoder(response.Body).Decode(out)
+	if err != nil {
+		return true, false, fmt.Errorf("decoding GET %s: %w", path, err)
+	}
+	return true, false, nil
+}
+
+// backoff doubles RetryBackoff per attempt and jitters the result by ±50%,
+// so callers retrying together spread out.
+func (c *Client) backoff(attempt int) time.Duration {
+	d := c.Policy.RetryBackoff << (attempt - 1)
+	if d <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(d))) + d/2
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,202 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mrexmelle/connect-emp/internal/config"
+	"github.com/mrexmelle/connect-emp/internal/localerror"
+)
+
+type payload struct {
+	Name string `json:"name"`
+}
+
+func newTestClient(server *httptest.Server, policy config.ClientPolicy) *Client {
+	c := NewClient("test", server.URL, 0, policy)
+	c.BaseUrl = server.URL
+	c.Sleep = func(ctx context.Context, d time.Duration) error {
+		return ctx.Err()
+	}
+	return c
+}
+
+func testPolicy() config.ClientPolicy {
+	return config.ClientPolicy{
+		Timeout:          time.Second,
+		MaxRetries:       2,
+		RetryBackoff:     time.Millisecond,
+		BreakerThreshold: 10,
+		BreakerCooldown:  time.Minute,
+	}
+}
+
+func TestGetJsonRetriesServerErrors(t *testing.T) {
+	calls := int32(0)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) < 3 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.Write([]byte(`{"name":"ok"}`))
+	}))
+	defer server.Close()
+
+	out := payload{}
+	err := newTestClient(server, testPolicy()).GetJson(context.Background(), "Get", "/", &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.Name != "ok" || calls != 3 {
+		t.Errorf("expected success on third call, got %q after %d calls", out.Name, calls)
+	}
+}
+
+func TestGetJsonGivesUp(t *testing.T) {
+	calls := int32(0)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	err := newTestClient(server, testPolicy()).GetJson(context.Background(), "Get", "/", &payload{})
+	if !errors.Is(err, localerror.ErrHttpClient) {
+		t.Fatalf("expected ErrHttpClient, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "test Get") || !strings.Contains(err.Error(), "503") {
+		t.Errorf("expected message naming client, operation and status, got %q", err)
+	}
+	if calls != 3 {
+		t.Errorf("expected 3 attempts, got %d", calls)
+	}
+}
+
+func TestGetJsonDoesNotRetryClientErrors(t *testing.T) {
+	calls := int32(0)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"name":"missing"}`))
+	}))
+	defer server.Close()
+
+	out := payload{}
+	err := newTestClient(server, testPolicy()).GetJson(context.Background(), "Get", "/", &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.Name != "missing" || calls != 1 {
+		t.Errorf("expected envelope decoded after 1 call, got %q after %d calls", out.Name, calls)
+	}
+}
+
+func TestGetJsonTimesOutEachAttempt(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	policy := testPolicy()
+	policy.Timeout = 20 * time.Millisecond
+	policy.MaxRetries = 1
+
+	startedAt := time.Now()
+	err := newTestClient(server, policy).GetJson(context.Background(), "Get", "/", &payload{})
+	if !errors.Is(err, localerror.ErrHttpClient) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+	if elapsed := time.Since(startedAt); elapsed > time.Second {
+		t.Errorf("expected attempts to be cut short, took %s", elapsed)
+	}
+}
+
+func TestGetJsonFailsFastWhenBreakerOpens(t *testing.T) {
+	calls := int32(0)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	policy := testPolicy()
+	policy.MaxRetries = 0
+	policy.BreakerThreshold = 2
+	c := newTestClient(server, policy)
+
+	for i := 0; i < 4; i++ {
+		c.GetJson(context.Background(), "Get", "/", &payload{})
+	}
+
+	if calls != 2 {
+		t.Errorf("expected breaker to stop calls after 2 failures, got %d calls", calls)
+	}
+	err := c.GetJson(context.Background(), "Get", "/", &payload{})
+	if !errors.Is(err, localerror.ErrHttpClient) || !strings.Contains(err.Error(), "circuit open") {
+		t.Errorf("expected circuit open error, got %v", err)
+	}
+}
+
+func TestGetJsonReleasesProbeOnUndecodableAnswer(t *testing.T) {
+	failing := atomic.Bool{}
+	failing.Store(true)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if failing.Load() {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Write([]byte(`not json`))
+	}))
+	defer server.Close()
+
+	now := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	policy := testPolicy()
+	policy.MaxRetries = 0
+	policy.BreakerThreshold = 1
+	c := newTestClient(server, policy)
+	c.Breaker.Now = func() time.Time { return now }
+
+	c.GetJson(context.Background(), "Get", "/", &payload{})
+	if c.Breaker.State() != BreakerOpen {
+		t.Fatalf("expected breaker to open, got %s", c.Breaker.State())
+	}
+
+	failing.Store(false)
+	now = now.Add(policy.BreakerCooldown)
+	err := c.GetJson(context.Background(), "Get", "/", &payload{})
+	if err == nil || strings.Contains(err.Error(), "circuit open") {
+		t.Fatalf("expected the probe to fail decoding, got %v", err)
+	}
+	if c.Breaker.State() != BreakerClosed {
+		t.Errorf("expected an answer below 500 to close the breaker, got %s", c.Breaker.State())
+	}
+}
+
+func TestGetJsonDoesNotCountCanceledCalls(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	policy := testPolicy()
+	policy.BreakerThreshold = 3
+	c := newTestClient(server, policy)
+
+	for i := 0; i < 5; i++ {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		err := c.GetJson(ctx, "Get", "/", &payload{})
+		cancel()
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("expected the caller deadline, got %v", err)
+		}
+	}
+	if c.Breaker.State() != BreakerClosed {
+		t.Errorf("expected canceled calls to leave the breaker closed, got %s", c.Breaker.State())
+	}
+}
@@ -19,6 +19,14 @@ var (
 	ErrConcurrentEvent = errors.New("concurrent_event")
 	ErrBadDateSequence = errors.New("bad_date_sequence")
 	ErrBadDateString   = errors.New("bad_date_string")
+	ErrBadUrl          = errors.New("bad_url")
+	ErrDependencyDown  = errors.New("dependency_down")
+
+	ErrMembershipNotFound = errors.New("membership_not_found")
+	ErrRemoteNotFound     = errors.New("remote_record_not_found")
+	ErrRemoteError        = errors.New("remote_error")
+	ErrUnknownCacheSource = errors.New("unknown_cache_source")
+	ErrBadBatchSize       = errors.New("bad_batch_size")
 )
 
 const (
@@ -28,22 +36,36 @@ const (
 
 	ErrSvcCodeUnregistered = "unregistered"
 	ErrSvcCodeNone         = "success"
+
+	WarningSourceOrg   = "org"
+	WarningSourceAuthx = "authx"
 )
 
-var ErrorMap = map[error]CodePair{
-	gorm.ErrDuplicatedKey:      NewCodePair(http.StatusBadRequest, ErrSvcCodeDuplicatedKey),
-	gorm.ErrForeignKeyViolated: NewCodePair(http.StatusBadRequest, ErrSvcCodeForeignKeyViolated),
-	gorm.ErrRecordNotFound:     NewCodePair(http.StatusNotFound, ErrSvcCodeRecordNotFound),
-	sql.ErrNoRows:              NewCodePair(http.StatusNotFound, ErrSvcCodeRecordNotFound),
-
-	ErrAuthentication:  NewCodePair(http.StatusUnauthorized, ErrAuthentication.Error()),
-	ErrBadJson:         NewCodePair(http.StatusBadRequest, ErrBadJson.Error()),
-	ErrBadHierarchy:    NewCodePair(http.StatusBadRequest, ErrBadHierarchy.Error()),
-	ErrAlreadyMax:      NewCodePair(http.StatusForbidden, ErrAlreadyMax.Error()),
-	ErrIdNotInteger:    NewCodePair(http.StatusBadRequest, ErrIdNotInteger.Error()),
-	ErrBadQueryParam:   NewCodePair(http.StatusBadRequest, ErrBadQueryParam.Error()),
-	ErrHttpClient:      NewCodePair(http.StatusInternalServerError, ErrHttpClient.Error()),
-	ErrConcurrentEvent: NewCodePair(http.StatusBadRequest, ErrConcurrentEvent.Error()),
-	ErrBadDateSequence: NewCodePair(http.StatusBadRequest, ErrBadDateSequence.Error()),
-	ErrBadDateString:   NewCodePair(http.StatusBadRequest, ErrBadDateString.Error()),
+// ErrorCodes registers the code of every known error. An error wrapping
+// several of them takes the code of the first one listed, so the most
+// specific ones come first.
+var ErrorCodes = []ErrorCode{
+	{ErrMembershipNotFound, NewCodePair(http.StatusNotFound, ErrMembershipNotFound.Error())},
+	{ErrRemoteNotFound, NewCodePair(http.StatusNotFound, ErrRemoteNotFound.Error())},
+	{ErrRemoteError, NewCodePair(http.StatusBadGateway, ErrRemoteError.Error())},
+	{ErrUnknownCacheSource, NewCodePair(http.StatusBadRequest, ErrUnknownCacheSource.Error())},
+	{ErrBadBatchSize, NewCodePair(http.StatusBadRequest, ErrBadBatchSize.Error())},
+
+	{ErrAuthentication, NewCodePair(http.StatusUnauthorized, ErrAuthentication.Error())},
+	{ErrAlreadyMax, NewCodePair(http.StatusForbidden, ErrAlreadyMax.Error())},
+	{ErrConcurrentEvent, NewCodePair(http.StatusBadRequest, ErrConcurrentEvent.Error())},
+	{ErrBadHierarchy, NewCodePair(http.StatusBadRequest, ErrBadHierarchy.Error())},
+	{ErrBadQueryParam, NewCodePair(http.StatusBadRequest, ErrBadQueryParam.Error())},
+	{ErrBadDateSequence, NewCodePair(http.StatusBadRequest, ErrBadDateSequence.Error())},
+	{ErrBadDateString, NewCodePair(http.StatusBadRequest, ErrBadDateString.Error())},
+	{ErrIdNotInteger, NewCodePair(http.StatusBadRequest, ErrIdNotInteger.Error())},
+	{ErrBadJson, NewCodePair(http.StatusBadRequest, ErrBadJson.Error())},
+	{ErrBadUrl, NewCodePair(http.StatusBadRequest, ErrBadUrl.Error())},
+	{ErrDependencyDown, NewCodePair(http.StatusServiceUnavailable, ErrDependencyDown.Error())},
+	{ErrHttpClient, NewCodePair(http.StatusBadGateway, ErrHttpClient.Error())},
+
+	{gorm.ErrDuplicatedKey, NewCodePair(http.StatusBadRequest, ErrSvcCodeDuplicatedKey)},
+	{gorm.ErrForeignKeyViolated, NewCodePair(http.StatusBadRequest, ErrSvcCodeForeignKeyViolated)},
+	{gorm.ErrRecordNotFound, NewCodePair(http.StatusNotFound, ErrSvcCodeRecordNotFound)},
+	{sql.ErrNoRows, NewCodePair(http.StatusNotFound, ErrSvcCodeRecordNotFound)},
 }
@@ -0,0 +1,44 @@
+package localerror
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/mrexmelle/connect-emp/internal/dto"
+)
+
+// NewRemoteError turns the error envelope returned by connect-org or
+// connect-authx into a local error. "Not found" on the remote side stays a
+// not found, anything else becomes ErrRemoteError. A successful envelope
+// gives nil.
+func NewRemoteError(source string, code string, message string) error {
+	if code == ErrSvcCodeNone {
+		return nil
+	}
+
+	base := ErrRemoteError
+	if code == ErrSvcCodeRecordNotFound {
+		base = ErrRemoteNotFound
+	}
+	return fmt.Errorf("%w: %s responded %s: %s", base, source, code, message)
+}
+
+// IsRemoteFailure tells whether err means a downstream service could not
+// answer, as opposed to answering that a record does not exist.
+func IsRemoteFailure(err error) bool {
+	return errors.Is(err, ErrHttpClient) || errors.Is(err, ErrRemoteError)
+}
+
+// NewWarning describes the failure of a source that a degraded response left
+// out.
+func NewWarning(source string, err error) dto.Warning {
+	code := ErrSvcCodeUnregistered
+	if codePair, exists := lookupWrapped(err); exists {
+		code = codePair.ServiceErrorCode
+	}
+	return dto.Warning{
+		Source:  source,
+		Code:    code,
+		Message: err.Error(),
+	}
+}
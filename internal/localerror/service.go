@@ -1,9 +1,11 @@
 package localerror
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/mrexmelle/connect-emp/internal/config"
+	"github.com/mrexmelle/connect-emp/internal/metrics"
 )
 
 type Service struct {
@@ -18,8 +20,9 @@ func (s *Service) Map(err error) StatusInfo {
 		return NewStatusInfo(http.StatusOK, ErrSvcCodeNone, "")
 	}
 
-	codePair, exists := ErrorMap[err]
+	codePair, exists := lookupWrapped(err)
 	if exists {
+		metrics.ServiceErrors.WithLabelValues(codePair.ServiceErrorCode).Inc()
 		return NewStatusInfo(
 			codePair.HttpStatusCode,
 			codePair.ServiceErrorCode,
@@ -27,9 +30,22 @@ func (s *Service) Map(err error) StatusInfo {
 		)
 	}
 
+	metrics.ServiceErrors.WithLabelValues(ErrSvcCodeUnregistered).Inc()
 	return NewStatusInfo(
 		http.StatusInternalServerError,
 		ErrSvcCodeUnregistered,
 		err.Error(),
 	)
 }
+
+// lookupWrapped finds the first registered error that err is or wraps, so
+// that errors annotated with fmt.Errorf("%w: ...") keep their code and carry
+// the annotation as message.
+func lookupWrapped(err error) (CodePair, bool) {
+	for _, known := range ErrorCodes {
+		if errors.Is(err, known.Err) {
+			return known.CodePair, true
+		}
+	}
+	return CodePair{}, false
+}
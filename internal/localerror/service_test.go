@@ -0,0 +1,43 @@
+package localerror
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+func TestMap(t *testing.T) {
+	s := NewService(nil)
+	for _, c := range []struct {
+		err        error
+		httpStatus int
+		code       string
+	}{
+		{nil, http.StatusOK, ErrSvcCodeNone},
+		{gorm.ErrRecordNotFound, http.StatusNotFound, ErrSvcCodeRecordNotFound},
+		{ErrMembershipNotFound, http.StatusNotFound, ErrMembershipNotFound.Error()},
+		{fmt.Errorf("%w: org GetMemberNodesByEhid: circuit open", ErrHttpClient), http.StatusBadGateway, ErrHttpClient.Error()},
+		{NewRemoteError("authx", ErrSvcCodeRecordNotFound, "no profile"), http.StatusNotFound, ErrRemoteNotFound.Error()},
+		{NewRemoteError("authx", "unregistered", "boom"), http.StatusBadGateway, ErrRemoteError.Error()},
+		{fmt.Errorf("%w: %w", ErrMembershipNotFound, gorm.ErrRecordNotFound), http.StatusNotFound, ErrMembershipNotFound.Error()},
+		{fmt.Errorf("%w: from: %w", ErrBadQueryParam, ErrBadDateString), http.StatusBadRequest, ErrBadQueryParam.Error()},
+		{fmt.Errorf("something else"), http.StatusInternalServerError, ErrSvcCodeUnregistered},
+	} {
+		info := s.Map(c.err)
+		if info.HttpStatusCode != c.httpStatus || info.ServiceErrorCode != c.code {
+			t.Errorf("Map(%v) = %d %s, expected %d %s", c.err, info.HttpStatusCode, info.ServiceErrorCode, c.httpStatus, c.code)
+		}
+	}
+}
+
+func TestNewRemoteError(t *testing.T) {
+	if err := NewRemoteError("org", ErrSvcCodeNone, ""); err != nil {
+		t.Errorf("expected nil for a successful envelope, got %v", err)
+	}
+	err := NewRemoteError("org", "bad_hierarchy", "node loops")
+	if err.Error() != "remote_error: org responded bad_hierarchy: node loops" {
+		t.Errorf("unexpected message %q", err)
+	}
+}
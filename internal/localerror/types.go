@@ -5,6 +5,11 @@ type CodePair struct {
 	ServiceErrorCode string
 }
 
+type ErrorCode struct {
+	Err      error
+	CodePair CodePair
+}
+
 type StatusInfo struct {
 	HttpStatusCode      int
 	ServiceErrorCode    string
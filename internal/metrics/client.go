@@ -0,0 +1,24 @@
+package metrics
+
+import (
+	"time"
+)
+
+const (
+	ClientOrg   = "org"
+	ClientAuthx = "authx"
+)
+
+// ObserveClientCall times a call to a downstream service and counts it as
+// failed when it returns an error.
+func ObserveClientCall[T any](client string, operation string, call func() (T, error)) (T, error) {
+	startedAt := time.Now()
+	result, err := call()
+	ClientRequestDuration.
+		WithLabelValues(client, operation).
+		Observe(time.Since(startedAt).Seconds())
+	if err != nil {
+		ClientRequestErrors.WithLabelValues(client, operation).Inc()
+	}
+	return result, err
+}
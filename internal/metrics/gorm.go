@@ -0,0 +1,119 @@
+package metrics
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"gorm.io/gorm"
+)
+
+const (
+	gormStartedAtKey = "metrics:started_at"
+	TableUnknown     = "unknown"
+)
+
+// GormPlugin times every statement run through a *gorm.DB.
+type GormPlugin struct {
+	DbName string
+}
+
+func NewGormPlugin(dbName string) *GormPlugin {
+	return &GormPlugin{
+		DbName: dbName,
+	}
+}
+
+func (p *GormPlugin) Name() string {
+	return "metrics:" + p.DbName
+}
+
+func (p *GormPlugin) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	errs := []error{
+		cb.Create().Before("gorm:create").Register(p.Name()+":before_create", p.before),
+		cb.Create().After("gorm:create").Register(p.Name()+":after_create", p.after("create")),
+		cb.Query().Before("gorm:query").Register(p.Name()+":before_query", p.before),
+		cb.Query().After("gorm:query").Register(p.Name()+":after_query", p.after("query")),
+		cb.Update().Before("gorm:update").Register(p.Name()+":before_update", p.before),
+		cb.Update().After("gorm:update").Register(p.Name()+":after_update", p.after("update")),
+		cb.Delete().Before("gorm:delete").Register(p.Name()+":before_delete", p.before),
+		cb.Delete().After("gorm:delete").Register(p.Name()+":after_delete", p.after("delete")),
+		cb.Row().Before("gorm:row").Register(p.Name()+":before_row", p.before),
+		cb.Row().After("gorm:row").Register(p.Name()+":after_row", p.after("row")),
+		cb.Raw().Before("gorm:raw").Register(p.Name()+":before_raw", p.before),
+		cb.Raw().After("gorm:raw").Register(p.Name()+":after_raw", p.after("raw")),
+	}
+	return errors.Join(errs...)
+}
+
+func (p *GormPlugin) before(db *gorm.DB) {
+	db.InstanceSet(gormStartedAtKey, time.Now())
+}
+
+func (p *GormPlugin) after(operation string) func(db *gorm.DB) {
+	return func(db *gorm.DB) {
+		value, ok := db.InstanceGet(gormStartedAtKey)
+		if !ok {
+			return
+		}
+		startedAt := value.(time.Time)
+
+		table := db.Statement.Table
+		if table == "" {
+			operation, table = parseStatement(operation, db.Statement.SQL.String())
+		}
+
+		DbQueryDuration.
+			WithLabelValues(p.DbName, table, operation).
+			Observe(time.Since(startedAt).Seconds())
+		if db.Error != nil && db.Error != gorm.ErrRecordNotFound {
+			DbQueryErrors.WithLabelValues(p.DbName, table, operation).Inc()
+		}
+	}
+}
+
+// parseStatement recovers the operation and table of statements issued via
+// Raw or Exec, which gorm does not attribute to a table.
+func parseStatement(fallbackOperation string, sql string) (string, string) {
+	verb := ""
+	fields := strings.Fields(sql)
+	if len(fields) > 0 {
+		verb = strings.ToLower(fields[0])
+	}
+
+	keyword := ""
+	operation := fallbackOperation
+	switch verb {
+	case "select":
+		operation, keyword = "query", "from"
+	case "insert":
+		operation, keyword = "create", "into"
+	case "update":
+		operation, keyword = "update", "update"
+	case "delete":
+		operation, keyword = "delete", "from"
+	default:
+		return operation, TableUnknown
+	}
+
+	for i, f := range fields {
+		if strings.ToLower(f) == keyword && i+1 < len(fields) {
+			table := strings.Trim(fields[i+1], `"`)
+			if idx := strings.IndexAny(table, "( "); idx >= 0 {
+				table = table[:idx]
+			}
+			if table != "" {
+				return operation, table
+			}
+		}
+	}
+	return operation, TableUnknown
+}
+
+// RegisterDbStats exposes the connection pool statistics of a database.
+func RegisterDbStats(dbName string, db *sql.DB) error {
+	return Registry.Register(collectors.NewDBStatsCollector(db, dbName))
+}
@@ -0,0 +1,66 @@
+package metrics
+
+import (
+	"testing"
+)
+
+type ParseStatementTestCase struct {
+	name              string
+	sql               string
+	expectedOperation string
+	expectedTable     string
+}
+
+func TestParseStatement(t *testing.T) {
+	tc := []ParseStatementTestCase{
+		{
+			name:              "Insert with returning",
+			sql:               "INSERT INTO gradings(ehid, start_date, grade) VALUES($1, $2, $3) RETURNING id",
+			expectedOperation: "create",
+			expectedTable:     "gradings",
+		},
+		{
+			name:              "Insert from select",
+			sql:               "INSERT INTO gradings_history(grading_id, ehid) SELECT id, ehid FROM gradings WHERE id = $1",
+			expectedOperation: "create",
+			expectedTable:     "gradings_history",
+		},
+		{
+			name:              "Update",
+			sql:               "UPDATE titlings_history SET recorded_to = NOW() WHERE titling_id = $1",
+			expectedOperation: "update",
+			expectedTable:     "titlings_history",
+		},
+		{
+			name:              "Delete",
+			sql:               "DELETE FROM webhooks WHERE id = $1",
+			expectedOperation: "delete",
+			expectedTable:     "webhooks",
+		},
+		{
+			name:              "Select with quoted table",
+			sql:               `SELECT * FROM "outbox_events" WHERE delivered_at IS NULL`,
+			expectedOperation: "query",
+			expectedTable:     "outbox_events",
+		},
+		{
+			name:              "Unrecognised statement",
+			sql:               "SET TIME ZONE 'UTC'",
+			expectedOperation: "raw",
+			expectedTable:     TableUnknown,
+		},
+	}
+
+	for _, c := range tc {
+		operation, table := parseStatement("raw", c.sql)
+		if operation != c.expectedOperation || table != c.expectedTable {
+			t.Errorf("[%s]\nresult: %s %s\nexpected: %s %s\n",
+				c.name,
+				operation,
+				table,
+				c.expectedOperation,
+				c.expectedTable,
+			)
+		}
+	}
+}
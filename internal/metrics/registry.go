@@ -0,0 +1,106 @@
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const Namespace = "connect_emp"
+
+var (
+	Registry = newRegistry()
+
+	HttpRequests = promauto.With(Registry).NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: Namespace,
+			Name:      "http_requests_total",
+			Help:      "HTTP requests by method, route pattern and status code.",
+		},
+		[]string{"method", "route", "status"},
+	)
+
+	HttpRequestDuration = promauto.With(Registry).NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: Namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "HTTP request latency by method, route pattern and status code.",
+			Buckets:   prometheus.DefBuckets,
+		},
+		[]string{"method", "route", "status"},
+	)
+
+	DbQueryDuration = promauto.With(Registry).NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: Namespace,
+			Name:      "db_query_duration_seconds",
+			Help:      "Database statement latency by connection pool, table and operation.",
+			Buckets:   []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+		},
+		[]string{"db", "table", "operation"},
+	)
+
+	DbQueryErrors = promauto.With(Registry).NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: Namespace,
+			Name:      "db_query_errors_total",
+			Help:      "Failed database statements by connection pool, table and operation.",
+		},
+		[]string{"db", "table", "operation"},
+	)
+
+	ClientRequestDuration = promauto.With(Registry).NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: Namespace,
+			Name:      "client_request_duration_seconds",
+			Help:      "Latency of calls to downstream services by client and operation.",
+			Buckets:   prometheus.DefBuckets,
+		},
+		[]string{"client", "operation"},
+	)
+
+	ClientRequestErrors = promauto.With(Registry).NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: Namespace,
+			Name:      "client_request_errors_total",
+			Help:      "Failed calls to downstream services by client and operation.",
+		},
+		[]string{"client", "operation"},
+	)
+
+	CacheRequests = promauto.With(Registry).NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: Namespace,
+			Name:      "cache_requests_total",
+			Help:      "Cache lookups by source and result (hit, stale, miss or error).",
+		},
+		[]string{"source", "result"},
+	)
+
+	ServiceErrors = promauto.With(Registry).NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: Namespace,
+			Name:      "service_errors_total",
+			Help:      "Service error codes returned to callers.",
+		},
+		[]string{"code"},
+	)
+)
+
+func newRegistry() *prometheus.Registry {
+	r := prometheus.NewRegistry()
+	r.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	return r
+}
+
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{
+		Registry: Registry,
+	})
+}
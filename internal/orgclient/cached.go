@@ -0,0 +1,133 @@
+package orgclient
+
+import (
+	"context"
+
+	"github.com/mrexmelle/connect-emp/internal/cache"
+	"github.com/mrexmelle/connect-org/pkg/liborgc"
+)
+
+// CachedClient serves lookups from cache before asking connect-org.
+type CachedClient struct {
+	Client Client
+	Cache  *cache.Cache
+}
+
+func NewCachedClient(c Client, cc *cache.Cache) Client {
+	return &CachedClient{
+		Client: c,
+		Cache:  cc,
+	}
+}
+
+func (c *CachedClient) GetMemberHistoryByEhidOrderByStartDateDesc(
+	ctx context.Context,
+	ehid string,
+) (*liborgc.GetMemberHistoryResponseDto, error) {
+	return cache.GetOrLoad(
+		ctx,
+		c.Cache,
+		cache.Key(cache.SourceOrg, ehid, "history"),
+		func(ctx context.Context) (*liborgc.GetMemberHistoryResponseDto, error) {
+			return c.Client.GetMemberHistoryByEhidOrderByStartDateDesc(ctx, ehid)
+		},
+	)
+}
+
+func (c *CachedClient) GetMemberNodesByEhid(
+	ctx context.Context,
+	ehid string,
+) (*liborgc.GetMemberNodesResponseDto, error) {
+	return cache.GetOrLoad(
+		ctx,
+		c.Cache,
+		cache.Key(cache.SourceOrg, ehid, "nodes"),
+		func(ctx context.Context) (*liborgc.GetMemberNodesResponseDto, error) {
+			return c.Client.GetMemberNodesByEhid(ctx, ehid)
+		},
+	)
+}
+
+func (c *CachedClient) GetNodeById(
+	ctx context.Context,
+	nodeId string,
+) (*GetNodeResponseDto, error) {
+	return cache.GetOrLoad(
+		ctx,
+		c.Cache,
+		cache.NodeKey(cache.SourceOrg, nodeId, "node"),
+		func(ctx context.Context) (*GetNodeResponseDto, error) {
+			return c.Client.GetNodeById(ctx, nodeId)
+		},
+	)
+}
+
+func (c *CachedClient) GetNodeChildrenById(
+	ctx context.Context,
+	nodeId string,
+) (*GetNodeChildrenResponseDto, error) {
+	return cache.GetOrLoad(
+		ctx,
+		c.Cache,
+		cache.NodeKey(cache.SourceOrg, nodeId, "children"),
+		func(ctx context.Context) (*GetNodeChildrenResponseDto, error) {
+			return c.Client.GetNodeChildrenById(ctx, nodeId)
+		},
+	)
+}
+
+func (c *CachedClient) GetNodeLineageById(
+	ctx context.Context,
+	nodeId string,
+) (*GetNodeLineageResponseDto, error) {
+	return cache.GetOrLoad(
+		ctx,
+		c.Cache,
+		cache.NodeKey(cache.SourceOrg, nodeId, "lineage"),
+		func(ctx context.Context) (*GetNodeLineageResponseDto, error) {
+			return c.Client.GetNodeLineageById(ctx, nodeId)
+		},
+	)
+}
+
+func (c *CachedClient) GetNodeMembersById(
+	ctx context.Context,
+	nodeId string,
+) (*GetNodeMembersResponseDto, error) {
+	return cache.GetOrLoad(
+		ctx,
+		c.Cache,
+		cache.NodeKey(cache.SourceOrg, nodeId, "members"),
+		func(ctx context.Context) (*GetNodeMembersResponseDto, error) {
+			return c.Client.GetNodeMembersById(ctx, nodeId)
+		},
+	)
+}
+
+func (c *CachedClient) GetNodeOfficersById(
+	ctx context.Context,
+	nodeId string,
+) (*GetNodeOfficersResponseDto, error) {
+	return cache.GetOrLoad(
+		ctx,
+		c.Cache,
+		cache.NodeKey(cache.SourceOrg, nodeId, "officers"),
+		func(ctx context.Context) (*GetNodeOfficersResponseDto, error) {
+			return c.Client.GetNodeOfficersById(ctx, nodeId)
+		},
+	)
+}
+
+func (c *CachedClient) GetRoleById(
+	ctx context.Context,
+	roleId string,
+) (*GetRoleResponseDto, error) {
+	return cache.GetOrLoad(
+		ctx,
+		c.Cache,
+		cache.RoleKey(cache.SourceOrg, roleId),
+		func(ctx context.Context) (*GetRoleResponseDto, error) {
+			return c.Client.GetRoleById(ctx, roleId)
+		},
+	)
+}
@@ -0,0 +1,244 @@
+package orgclient
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"github.com/mrexmelle/connect-emp/internal/cache"
+	"github.com/mrexmelle/connect-emp/internal/config"
+	"github.com/mrexmelle/connect-emp/internal/httpclient"
+	"github.com/mrexmelle/connect-emp/internal/localerror"
+	"github.com/mrexmelle/connect-emp/internal/metrics"
+	"github.com/mrexmelle/connect-org/pkg/liborgc"
+)
+
+// Client calls connect-org. It speaks the same API as liborgc.Client but
+// carries the caller's context, so that deadlines, cancellation and the
+// trace context reach the downstream service.
+type Client interface {
+	GetMemberHistoryByEhidOrderByStartDateDesc(ctx context.Context, ehid string) (*liborgc.GetMemberHistoryResponseDto, error)
+	GetMemberNodesByEhid(ctx context.Context, ehid string) (*liborgc.GetMemberNodesResponseDto, error)
+	GetNodeById(ctx context.Context, nodeId string) (*GetNodeResponseDto, error)
+	GetNodeChildrenById(ctx context.Context, nodeId string) (*GetNodeChildrenResponseDto, error)
+	GetNodeLineageById(ctx context.Context, nodeId string) (*GetNodeLineageResponseDto, error)
+	GetNodeMembersById(ctx context.Context, nodeId string) (*GetNodeMembersResponseDto, error)
+	GetNodeOfficersById(ctx context.Context, nodeId string) (*GetNodeOfficersResponseDto, error)
+	GetRoleById(ctx context.Context, roleId string) (*GetRoleResponseDto, error)
+}
+
+type ClientImpl struct {
+	HttpClient *httpclient.Client
+}
+
+// NewClient returns a client whose lookups go through the cache of cs.
+func NewClient(cfg *config.Service, cs *cache.Service) Client {
+	return NewCachedClient(newClientImpl(cfg), cs.OrgCache)
+}
+
+func newClientImpl(cfg *config.Service) *ClientImpl {
+	return &ClientImpl{
+		HttpClient: httpclient.NewClient(
+			metrics.ClientOrg,
+			cfg.ConfigRepository.GetOrgHost(),
+			cfg.ConfigRepository.GetOrgPort(),
+			cfg.ConfigRepository.GetOrgClientPolicy(),
+		),
+	}
+}
+
+func (c *ClientImpl) GetMemberHistoryByEhidOrderByStartDateDesc(
+	ctx context.Context,
+	ehid string,
+) (*liborgc.GetMemberHistoryResponseDto, error) {
+	data := liborgc.GetMemberHistoryResponseDto{}
+	err := c.HttpClient.GetJson(
+		ctx,
+		"GetMemberHistoryByEhidOrderByStartDateDesc",
+		fmt.Sprintf("/members/%s/history?sort=desc", url.PathEscape(ehid)),
+		&data,
+	)
+	if err != nil {
+		return nil, err
+	}
+	err = checkMemberships(data.Error, &data.Data)
+	if err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
+
+func (c *ClientImpl) GetMemberNodesByEhid(
+	ctx context.Context,
+	ehid string,
+) (*liborgc.GetMemberNodesResponseDto, error) {
+	data := liborgc.GetMemberNodesResponseDto{}
+	err := c.HttpClient.GetJson(
+		ctx,
+		"GetMemberNodesByEhid",
+		fmt.Sprintf("/members/%s/nodes", url.PathEscape(ehid)),
+		&data,
+	)
+	if err != nil {
+		return nil, err
+	}
+	err = checkMemberships(data.Error, &data.Data)
+	if err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
+
+func (c *ClientImpl) GetNodeById(
+	ctx context.Context,
+	nodeId string,
+) (*GetNodeResponseDto, error) {
+	data := GetNodeResponseDto{}
+	err := c.HttpClient.GetJson(
+		ctx,
+		"GetNodeById",
+		fmt.Sprintf("/nodes/%s", url.PathEscape(nodeId)),
+		&data,
+	)
+	if err != nil {
+		return nil, err
+	}
+	err = localerror.NewRemoteError(metrics.ClientOrg, data.Error.Code, data.Error.Message)
+	if err != nil {
+		return nil, err
+	}
+	if data.Data == nil {
+		return nil, localerror.NewRemoteError(metrics.ClientOrg, localerror.ErrSvcCodeRecordNotFound, "no node "+nodeId)
+	}
+	return &data, nil
+}
+
+func (c *ClientImpl) GetNodeChildrenById(
+	ctx context.Context,
+	nodeId string,
+) (*GetNodeChildrenResponseDto, error) {
+	data := GetNodeChildrenResponseDto{}
+	err := c.HttpClient.GetJson(
+		ctx,
+		"GetNodeChildrenById",
+		fmt.Sprintf("/nodes/%s/children", url.PathEscape(nodeId)),
+		&data,
+	)
+	if err != nil {
+		return nil, err
+	}
+	err = localerror.NewRemoteError(metrics.ClientOrg, data.Error.Code, data.Error.Message)
+	if err != nil {
+		return nil, err
+	}
+	if data.Data == nil {
+		data.Data = &[]NodeViewEntity{}
+	}
+	return &data, nil
+}
+
+func (c *ClientImpl) GetNodeLineageById(
+	ctx context.Context,
+	nodeId string,
+) (*GetNodeLineageResponseDto, error) {
+	data := GetNodeLineageResponseDto{}
+	err := c.HttpClient.GetJson(
+		ctx,
+		"GetNodeLineageById",
+		fmt.Sprintf("/nodes/%s/lineage", url.PathEscape(nodeId)),
+		&data,
+	)
+	if err != nil {
+		return nil, err
+	}
+	err = localerror.NewRemoteError(metrics.ClientOrg, data.Error.Code, data.Error.Message)
+	if err != nil {
+		return nil, err
+	}
+	if data.Data == nil {
+		return nil, localerror.NewRemoteError(metrics.ClientOrg, localerror.ErrSvcCodeRecordNotFound, "no node "+nodeId)
+	}
+	return &data, nil
+}
+
+func (c *ClientImpl) GetNodeMembersById(
+	ctx context.Context,
+	nodeId string,
+) (*GetNodeMembersResponseDto, error) {
+	data := GetNodeMembersResponseDto{}
+	err := c.HttpClient.GetJson(
+		ctx,
+		"GetNodeMembersById",
+		fmt.Sprintf("/nodes/%s/members", url.PathEscape(nodeId)),
+		&data,
+	)
+	if err != nil {
+		return nil, err
+	}
+	err = checkMemberships(data.Error, &data.Data)
+	if err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
+
+func (c *ClientImpl) GetNodeOfficersById(
+	ctx context.Context,
+	nodeId string,
+) (*GetNodeOfficersResponseDto, error) {
+	data := GetNodeOfficersResponseDto{}
+	err := c.HttpClient.GetJson(
+		ctx,
+		"GetNodeOfficersById",
+		fmt.Sprintf("/nodes/%s/officers", url.PathEscape(nodeId)),
+		&data,
+	)
+	if err != nil {
+		return nil, err
+	}
+	err = localerror.NewRemoteError(metrics.ClientOrg, data.Error.Code, data.Error.Message)
+	if err != nil {
+		return nil, err
+	}
+	if data.Data == nil {
+		data.Data = &[]DesignationViewEntity{}
+	}
+	return &data, nil
+}
+
+func (c *ClientImpl) GetRoleById(
+	ctx context.Context,
+	roleId string,
+) (*GetRoleResponseDto, error) {
+	data := GetRoleResponseDto{}
+	err := c.HttpClient.GetJson(
+		ctx,
+		"GetRoleById",
+		fmt.Sprintf("/roles/%s", url.PathEscape(roleId)),
+		&data,
+	)
+	if err != nil {
+		return nil, err
+	}
+	err = localerror.NewRemoteError(metrics.ClientOrg, data.Error.Code, data.Error.Message)
+	if err != nil {
+		return nil, err
+	}
+	if data.Data == nil {
+		return nil, localerror.NewRemoteError(metrics.ClientOrg, localerror.ErrSvcCodeRecordNotFound, "no role "+roleId)
+	}
+	return &data, nil
+}
+
+// checkMemberships reports an error envelope as error and makes sure callers
+// always get a list, empty when the member has no memberships.
+func checkMemberships(e liborgc.ServiceError, data **[]liborgc.MembershipViewEntity) error {
+	err := localerror.NewRemoteError(metrics.ClientOrg, e.Code, e.Message)
+	if err != nil {
+		return err
+	}
+	if *data == nil {
+		*data = &[]liborgc.MembershipViewEntity{}
+	}
+	return nil
+}
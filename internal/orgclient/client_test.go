@@ -0,0 +1,37 @@
+package orgclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mrexmelle/connect-emp/internal/config"
+	"github.com/mrexmelle/connect-emp/internal/httpclient"
+)
+
+func TestClientEscapesEhids(t *testing.T) {
+	paths := []string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.EscapedPath())
+		w.Write([]byte(`{"data":[],"error":{"code":"success"}}`))
+	}))
+	defer server.Close()
+
+	c := &ClientImpl{HttpClient: httpclient.NewClient("org", server.URL, 0, config.ClientPolicy{})}
+	c.HttpClient.BaseUrl = server.URL
+
+	ehid := "u001/../x?y#z"
+	_, err := c.GetMemberNodesByEhid(context.Background(), ehid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = c.GetMemberHistoryByEhidOrderByStartDateDesc(context.Background(), ehid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"/members/u001%2F..%2Fx%3Fy%23z/nodes", "/members/u001%2F..%2Fx%3Fy%23z/history"}
+	if len(paths) != 2 || paths[0] != expected[0] || paths[1] != expected[1] {
+		t.Errorf("expected %v, got %v", expected, paths)
+	}
+}
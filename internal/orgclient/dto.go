@@ -0,0 +1,70 @@
+package orgclient
+
+import (
+	"github.com/mrexmelle/connect-org/pkg/liborgc"
+)
+
+// NodeViewEntity mirrors the organization nodes of connect-org, which
+// liborgc does not export. Hierarchy is the dot-separated path of node IDs
+// from the root down to the node.
+type NodeViewEntity struct {
+	Id           string `json:"id"`
+	Hierarchy    string `json:"hierarchy"`
+	Name         string `json:"name"`
+	EmailAddress string `json:"email_address"`
+}
+
+type GetNodeResponseDto struct {
+	Data  *NodeViewEntity      `json:"data"`
+	Error liborgc.ServiceError `json:"error"`
+}
+
+// NodeTreeViewEntity is a node of the trees returned by connect-org. A
+// lineage is a tree whose root is the topmost ancestor of a node, going down
+// to the node itself.
+type NodeTreeViewEntity struct {
+	Data     *NodeViewEntity      `json:"data"`
+	Children []NodeTreeViewEntity `json:"children"`
+}
+
+type GetNodeLineageResponseDto struct {
+	Data  *NodeTreeViewEntity  `json:"data"`
+	Error liborgc.ServiceError `json:"error"`
+}
+
+type GetNodeChildrenResponseDto struct {
+	Data  *[]NodeViewEntity    `json:"data"`
+	Error liborgc.ServiceError `json:"error"`
+}
+
+// DesignationViewEntity mirrors the designations of connect-org, which make
+// a member an officer of a node in a role.
+type DesignationViewEntity struct {
+	Id     string `json:"id"`
+	NodeId string `json:"node_id"`
+	RoleId string `json:"role_id"`
+	Ehid   string `json:"ehid"`
+}
+
+// RoleViewEntity mirrors the roles of connect-org. The lower the rank, the
+// more senior the role.
+type RoleViewEntity struct {
+	Id       string `json:"id"`
+	Name     string `json:"name"`
+	Rank     int    `json:"rank"`
+	MaxCount int    `json:"max_count"`
+}
+
+type GetNodeOfficersResponseDto struct {
+	Data  *[]DesignationViewEntity `json:"data"`
+	Error liborgc.ServiceError     `json:"error"`
+}
+
+type GetRoleResponseDto struct {
+	Data  *RoleViewEntity      `json:"data"`
+	Error liborgc.ServiceError `json:"error"`
+}
+
+// GetNodeMembersResponseDto has the same shape as the memberships of a
+// member.
+type GetNodeMembersResponseDto = liborgc.GetMemberNodesResponseDto
@@ -0,0 +1,112 @@
+package orgnode
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi"
+	"github.com/mrexmelle/connect-emp/internal/config"
+	"github.com/mrexmelle/connect-emp/internal/datestr"
+	"github.com/mrexmelle/connect-emp/internal/dto/dtorespwithdata"
+	"github.com/mrexmelle/connect-emp/internal/localerror"
+)
+
+type Controller struct {
+	ConfigService     *config.Service
+	LocalErrorService *localerror.Service
+	OrgNodeService    *Service
+}
+
+func NewController(cfg *config.Service, les *localerror.Service, svc *Service) *Controller {
+	return &Controller{
+		ConfigService:     cfg,
+		LocalErrorService: les,
+		OrgNodeService:    svc,
+	}
+}
+
+// Get Members : HTTP endpoint to get the members of an organization node
+// @Tags OrgNodes
+// @Description Get the members of a node with their name, grade and title, ordered by EHID. Past members are found through the membership histories of the EHIDs graded on as_of, against the current node structure. Every page of as_of looks up the histories of all of them, through the cache, and fails with bad_query_param when they outnumber app.org-nodes.max-history-lookups.
+// @Produce json
+// @Param nodeId path string true "Node ID"
+// @Param as_of query string false "Date in YYYY-MM-DD, today when absent"
+// @Param recursive query bool false "Include the members of descendant nodes"
+// @Param page query int false "Page number, starting at 1"
+// @Param page_size query int false "Members per page, 50 by default and 500 at most"
+// @Param degraded query bool false "Leave out names instead of failing when connect-authx is unavailable"
+// @Success 200 {object} GetMembersResponseDto "Success Response"
+// @Failure 400 "BadRequest"
+// @Failure 404 "NotFound"
+// @Failure 500 "InternalServerError"
+// @Failure 502 "BadGateway"
+// @Router /org-nodes/{nodeId}/members [GET]
+func (c *Controller) GetMembers(w http.ResponseWriter, r *http.Request) {
+	q, degraded, err := c.parseMembersQuery(r)
+	if err != nil {
+		dtorespwithdata.NewError(
+			localerror.ErrBadQueryParam.Error(),
+			err.Error(),
+		).RenderTo(w, http.StatusBadRequest)
+		return
+	}
+
+	data, warnings, err := c.OrgNodeService.RetrieveMembers(
+		r.Context(),
+		chi.URLParam(r, "nodeId"),
+		*q,
+		degraded,
+	)
+	info := c.LocalErrorService.Map(err)
+	dtorespwithdata.New(
+		data,
+		info.ServiceErrorCode,
+		info.ServiceErrorMessage,
+	).WithWarnings(warnings).RenderTo(w, info.HttpStatusCode)
+}
+
+func (c *Controller) parseMembersQuery(r *http.Request) (*MembersQuery, bool, error) {
+	values := r.URL.Query()
+	q := MembersQuery{
+		AsOf:     values.Get("as_of"),
+		Page:     1,
+		PageSize: DefaultPageSize,
+	}
+
+	_, err := datestr.NewFromString(q.AsOf)
+	if err != nil {
+		return nil, false, fmt.Errorf("as_of: %w", err)
+	}
+
+	if value := values.Get("recursive"); value != "" {
+		q.Recursive, err = strconv.ParseBool(value)
+		if err != nil {
+			return nil, false, fmt.Errorf("recursive: %w", err)
+		}
+	}
+
+	if value := values.Get("page"); value != "" {
+		q.Page, err = strconv.Atoi(value)
+		if err != nil || q.Page < 1 {
+			return nil, false, fmt.Errorf("page: expected a positive integer, got %q", value)
+		}
+	}
+
+	if value := values.Get("page_size"); value != "" {
+		q.PageSize, err = strconv.Atoi(value)
+		if err != nil || q.PageSize < 1 || q.PageSize > MaxPageSize {
+			return nil, false, fmt.Errorf("page_size: expected 1 to %d, got %q", MaxPageSize, value)
+		}
+	}
+
+	degraded := c.ConfigService.ConfigRepository.GetDegradedEnabled()
+	if value := values.Get("degraded"); value != "" {
+		degraded, err = strconv.ParseBool(value)
+		if err != nil {
+			return nil, false, fmt.Errorf("degraded: %w", err)
+		}
+	}
+
+	return &q, degraded, nil
+}
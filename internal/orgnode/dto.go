@@ -0,0 +1,7 @@
+package orgnode
+
+import (
+	"github.com/mrexmelle/connect-emp/internal/dto/dtorespwithdata"
+)
+
+type GetMembersResponseDto = dtorespwithdata.Class[MembersViewEntity]
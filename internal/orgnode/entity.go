@@ -0,0 +1,33 @@
+package orgnode
+
+type MemberViewEntity struct {
+	Ehid        string `json:"ehid"`
+	Name        string `json:"name,omitempty"`
+	NodeId      string `json:"node_id"`
+	MemberSince string `json:"member_since"`
+	Grade       string `json:"grade,omitempty"`
+	Title       string `json:"title,omitempty"`
+}
+
+// MembersViewEntity is one page of the members of a node, ordered by EHID.
+// Total counts the members of every page.
+type MembersViewEntity struct {
+	NodeId    string             `json:"node_id"`
+	AsOf      string             `json:"as_of,omitempty"`
+	Recursive bool               `json:"recursive"`
+	Page      int                `json:"page"`
+	PageSize  int                `json:"page_size"`
+	Total     int                `json:"total"`
+	Members   []MemberViewEntity `json:"members"`
+}
+
+// PersonViewEntity is someone in the reporting line of an employee. NodeId is
+// the node a manager leads, and the node a direct report is a member or the
+// lead of.
+type PersonViewEntity struct {
+	Ehid   string `json:"ehid"`
+	Name   string `json:"name,omitempty"`
+	NodeId string `json:"node_id"`
+	Grade  string `json:"grade,omitempty"`
+	Title  string `json:"title,omitempty"`
+}
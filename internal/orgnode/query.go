@@ -0,0 +1,15 @@
+package orgnode
+
+const (
+	DefaultPageSize = 50
+	MaxPageSize     = 500
+)
+
+// MembersQuery selects the members to list. An empty AsOf stands for the
+// current members.
+type MembersQuery struct {
+	AsOf      string
+	Recursive bool
+	Page      int
+	PageSize  int
+}
@@ -0,0 +1,528 @@
+package orgnode
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/mrexmelle/connect-authx/pkg/libauthxc"
+	"github.com/mrexmelle/connect-emp/internal/authxclient"
+	"github.com/mrexmelle/connect-emp/internal/batch"
+	"github.com/mrexmelle/connect-emp/internal/config"
+	"github.com/mrexmelle/connect-emp/internal/dateinterval"
+	"github.com/mrexmelle/connect-emp/internal/datestr"
+	"github.com/mrexmelle/connect-emp/internal/dto"
+	"github.com/mrexmelle/connect-emp/internal/grading"
+	"github.com/mrexmelle/connect-emp/internal/localerror"
+	"github.com/mrexmelle/connect-emp/internal/orgclient"
+	"github.com/mrexmelle/connect-emp/internal/titling"
+	"github.com/mrexmelle/connect-org/pkg/liborgc"
+	"golang.org/x/sync/errgroup"
+)
+
+type Service struct {
+	ConfigService  *config.Service
+	GradingService *grading.Service
+	TitlingService *titling.Service
+	OrgClient      orgclient.Client
+	AuthxClient    authxclient.Client
+}
+
+func NewService(
+	cfg *config.Service,
+	gs *grading.Service,
+	ts *titling.Service,
+	oc orgclient.Client,
+	ac authxclient.Client,
+) *Service {
+	return &Service{
+		ConfigService:  cfg,
+		GradingService: gs,
+		TitlingService: ts,
+		OrgClient:      oc,
+		AuthxClient:    ac,
+	}
+}
+
+// RetrieveMembers lists one page of the members of a node, and of its
+// descendants when q.Recursive is set, with their grade, title and name.
+//
+// Current members come from connect-org. connect-org cannot tell who was in
+// a node in the past, so members as of an earlier date are found among the
+// EHIDs graded on that date by looking up their membership histories, which
+// are cached. Either way the node structure is the current one.
+func (s *Service) RetrieveMembers(
+	ctx context.Context,
+	nodeId string,
+	q MembersQuery,
+	degraded bool,
+) (*MembersViewEntity, []dto.Warning, error) {
+	nodeIds := []string{nodeId}
+	var err error
+	if q.Recursive {
+		nodeIds, err = s.RetrieveSubtree(ctx, nodeId)
+	} else {
+		_, err = s.OrgClient.GetNodeById(ctx, nodeId)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var memberships []liborgc.MembershipViewEntity
+	if q.AsOf == "" {
+		memberships, err = s.retrieveCurrentMemberships(ctx, nodeIds)
+	} else {
+		memberships, err = s.retrieveMembershipsOn(ctx, nodeIds, q.AsOf)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	sort.Slice(memberships, func(i, j int) bool {
+		if memberships[i].Ehid != memberships[j].Ehid {
+			return memberships[i].Ehid < memberships[j].Ehid
+		}
+		return memberships[i].NodeId < memberships[j].NodeId
+	})
+	view := &MembersViewEntity{
+		NodeId:    nodeId,
+		AsOf:      q.AsOf,
+		Recursive: q.Recursive,
+		Page:      q.Page,
+		PageSize:  q.PageSize,
+		Total:     len(memberships),
+		Members:   []MemberViewEntity{},
+	}
+	// Pages past the end are checked first, for (q.Page-1)*q.PageSize to
+	// not overflow on a huge page.
+	from := len(memberships)
+	if q.Page-1 <= len(memberships)/q.PageSize {
+		from = min((q.Page-1)*q.PageSize, len(memberships))
+	}
+	to := min(from+q.PageSize, len(memberships))
+	page := memberships[from:to]
+	if len(page) == 0 {
+		return view, nil, nil
+	}
+
+	ehids := []string{}
+	for _, m := range page {
+		ehids = append(ehids, m.Ehid)
+	}
+	d, warnings, err := s.retrieveDetails(ctx, ehids, q.AsOf, degraded)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, m := range page {
+		view.Members = append(view.Members, MemberViewEntity{
+			Ehid:        m.Ehid,
+			Name:        d.names[m.Ehid],
+			NodeId:      m.NodeId,
+			MemberSince: m.StartDate,
+			Grade:       d.gradings[m.Ehid].Grade,
+			Title:       d.titlings[m.Ehid].Title,
+		})
+	}
+	return view, warnings, nil
+}
+
+// RetrieveLead finds the lead of a node: its officer in the most senior role,
+// the first by EHID on a tie. It gives an empty EHID when the node has no
+// officers. Officers whose role connect-org does not know are passed over.
+func (s *Service) RetrieveLead(ctx context.Context, nodeId string) (string, error) {
+	officers, err := s.OrgClient.GetNodeOfficersById(ctx, nodeId)
+	if err != nil {
+		return "", err
+	}
+
+	lead := ""
+	rank := 0
+	for _, o := range *officers.Data {
+		role, err := s.OrgClient.GetRoleById(ctx, o.RoleId)
+		if errors.Is(err, localerror.ErrRemoteNotFound) {
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+		if lead == "" || role.Data.Rank < rank || (role.Data.Rank == rank && o.Ehid < lead) {
+			lead = o.Ehid
+			rank = role.Data.Rank
+		}
+	}
+	return lead, nil
+}
+
+// RetrieveManagers lists the managers of ehid, a member of nodeId, starting
+// with their direct manager: the leads of the node and of its ancestors up
+// to the root. Nodes without a lead or led by ehid are passed over, and so
+// are leads already listed.
+func (s *Service) RetrieveManagers(ctx context.Context, ehid string, nodeId string) ([]PersonViewEntity, error) {
+	node, err := s.OrgClient.GetNodeById(ctx, nodeId)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := strings.Split(node.Data.Hierarchy, ".")
+	leads := make([]string, len(ids))
+	eg, ctx := errgroup.WithContext(ctx)
+	eg.SetLimit(s.concurrency())
+	for i, id := range ids {
+		i, id := i, id
+		eg.Go(func() (err error) {
+			leads[i], err = s.RetrieveLead(ctx, id)
+			return err
+		})
+	}
+	err = eg.Wait()
+	if err != nil {
+		return nil, err
+	}
+
+	managers := []PersonViewEntity{}
+	seen := map[string]bool{ehid: true}
+	for i := len(ids) - 1; i >= 0; i-- {
+		if leads[i] == "" || seen[leads[i]] {
+			continue
+		}
+		seen[leads[i]] = true
+		managers = append(managers, PersonViewEntity{Ehid: leads[i], NodeId: ids[i]})
+	}
+	return managers, nil
+}
+
+// RetrieveDirectReports lists the people whose direct manager is ehid, see
+// RetrieveManagers, ordered by EHID. Only the node of ehid is looked at, so
+// they have no direct reports unless they lead it. The members of the node
+// report to them, and so do those of its descendants down to the nodes
+// having a lead of their own, who reports to them instead.
+func (s *Service) RetrieveDirectReports(ctx context.Context, ehid string, nodeId string) ([]PersonViewEntity, error) {
+	lead, err := s.RetrieveLead(ctx, nodeId)
+	if err != nil {
+		return nil, err
+	}
+	reports := []PersonViewEntity{}
+	if lead != ehid {
+		return reports, nil
+	}
+
+	seen := map[string]bool{ehid: true}
+	add := func(ehid string, nodeId string) {
+		if !seen[ehid] {
+			seen[ehid] = true
+			reports = append(reports, PersonViewEntity{Ehid: ehid, NodeId: nodeId})
+		}
+	}
+	visited := []string{nodeId}
+	level := []string{nodeId}
+	for len(level) > 0 {
+		views := make([]reportingView, len(level))
+		eg, ctx := errgroup.WithContext(ctx)
+		eg.SetLimit(s.concurrency())
+		for i, id := range level {
+			i, id := i, id
+			eg.Go(func() (err error) {
+				views[i], err = s.retrieveReportingView(ctx, id)
+				return err
+			})
+		}
+		err := eg.Wait()
+		if err != nil {
+			return nil, err
+		}
+
+		level = []string{}
+		for _, v := range views {
+			for _, member := range v.members {
+				add(member, v.nodeId)
+			}
+			for _, child := range v.children {
+				if slices.Contains(visited, child.nodeId) {
+					continue
+				}
+				visited = append(visited, child.nodeId)
+				if child.lead == "" || child.lead == ehid {
+					level = append(level, child.nodeId)
+				} else {
+					add(child.lead, child.nodeId)
+				}
+			}
+		}
+	}
+	sort.Slice(reports, func(i, j int) bool {
+		return reports[i].Ehid < reports[j].Ehid
+	})
+	return reports, nil
+}
+
+// reportingView is a node seen from its lead: its current members and the
+// leads of its children.
+type reportingView struct {
+	nodeId   string
+	members  []string
+	children []reportingChild
+}
+
+type reportingChild struct {
+	nodeId string
+	lead   string
+}
+
+func (s *Service) retrieveReportingView(ctx context.Context, nodeId string) (reportingView, error) {
+	v := reportingView{nodeId: nodeId}
+	members, err := s.OrgClient.GetNodeMembersById(ctx, nodeId)
+	if err != nil {
+		return v, err
+	}
+	for _, m := range *members.Data {
+		v.members = append(v.members, m.Ehid)
+	}
+	children, err := s.OrgClient.GetNodeChildrenById(ctx, nodeId)
+	if err != nil {
+		return v, err
+	}
+	for _, child := range *children.Data {
+		lead, err := s.RetrieveLead(ctx, child.Id)
+		if err != nil {
+			return v, err
+		}
+		v.children = append(v.children, reportingChild{nodeId: child.Id, lead: lead})
+	}
+	return v, nil
+}
+
+// DescribePeople fills in the names, current grades and current titles of
+// people, looking each EHID up once. When degraded is set and
+// connect-authx cannot be reached, names are left out and a warning says so.
+func (s *Service) DescribePeople(
+	ctx context.Context,
+	people []PersonViewEntity,
+	degraded bool,
+) ([]dto.Warning, error) {
+	if len(people) == 0 {
+		return []dto.Warning{}, nil
+	}
+	ehids := []string{}
+	for _, p := range people {
+		ehids = append(ehids, p.Ehid)
+	}
+	d, warnings, err := s.retrieveDetails(ctx, ehids, "", degraded)
+	if err != nil {
+		return nil, err
+	}
+	for i := range people {
+		people[i].Name = d.names[people[i].Ehid]
+		people[i].Grade = d.gradings[people[i].Ehid].Grade
+		people[i].Title = d.titlings[people[i].Ehid].Title
+	}
+	return warnings, nil
+}
+
+// details holds the names, grades and titles of some EHIDs.
+type details struct {
+	names    map[string]string
+	gradings map[string]grading.ViewEntity
+	titlings map[string]titling.ViewEntity
+}
+
+// retrieveDetails looks up the names of ehids in connect-authx and their
+// gradings and titlings holding on asOf, the current ones when it is empty.
+// Unknown names are left out.
+func (s *Service) retrieveDetails(
+	ctx context.Context,
+	ehids []string,
+	asOf string,
+	degraded bool,
+) (*details, []dto.Warning, error) {
+	ehids = batch.Distinct(ehids)
+	d := &details{names: map[string]string{}}
+	var profiles []batch.Result[libauthxc.GetProfileResponseDto]
+	eg, ctx := errgroup.WithContext(ctx)
+	eg.Go(func() (err error) {
+		if asOf == "" {
+			d.gradings, err = s.GradingService.RetrieveCurrentByEhids(ctx, ehids)
+		} else {
+			d.gradings, err = s.GradingService.RetrieveByEhidsActiveOn(ctx, ehids, asOf)
+		}
+		return err
+	})
+	eg.Go(func() (err error) {
+		if asOf == "" {
+			d.titlings, err = s.TitlingService.RetrieveCurrentByEhids(ctx, ehids)
+		} else {
+			d.titlings, err = s.TitlingService.RetrieveByEhidsActiveOn(ctx, ehids, asOf)
+		}
+		return err
+	})
+	eg.Go(func() error {
+		profiles = batch.Map(ctx, ehids, s.concurrency(), func(
+			ctx context.Context,
+			ehid string,
+		) (*libauthxc.GetProfileResponseDto, []dto.Warning, error) {
+			p, err := s.AuthxClient.GetProfileByEhid(ctx, ehid)
+			return p, nil, err
+		})
+		return nil
+	})
+	err := eg.Wait()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	warnings := []dto.Warning{}
+	for _, p := range profiles {
+		switch {
+		case p.Err == nil:
+			d.names[p.Ehid] = p.Data.Data.Name
+		case errors.Is(p.Err, localerror.ErrRemoteNotFound):
+		case degraded && localerror.IsRemoteFailure(p.Err):
+			if len(warnings) == 0 {
+				warnings = append(warnings, localerror.NewWarning(localerror.WarningSourceAuthx, p.Err))
+			}
+		default:
+			return nil, nil, p.Err
+		}
+	}
+	return d, warnings, nil
+}
+
+// RetrieveSubtree lists the IDs of a node and of all of its descendants,
+// walking the tree one level at a time. An unknown node is reported as a
+// remote not found error.
+func (s *Service) RetrieveSubtree(ctx context.Context, nodeId string) ([]string, error) {
+	_, err := s.OrgClient.GetNodeById(ctx, nodeId)
+	if err != nil {
+		return nil, err
+	}
+
+	nodeIds := []string{nodeId}
+	level := []string{nodeId}
+	for len(level) > 0 {
+		children := make([][]orgclient.NodeViewEntity, len(level))
+		eg, ctx := errgroup.WithContext(ctx)
+		eg.SetLimit(s.concurrency())
+		for i, id := range level {
+			i, id := i, id
+			eg.Go(func() error {
+				c, err := s.OrgClient.GetNodeChildrenById(ctx, id)
+				if err != nil {
+					return err
+				}
+				children[i] = *c.Data
+				return nil
+			})
+		}
+		err := eg.Wait()
+		if err != nil {
+			return nil, err
+		}
+
+		level = []string{}
+		for _, c := range children {
+			for _, child := range c {
+				if !slices.Contains(nodeIds, child.Id) {
+					nodeIds = append(nodeIds, child.Id)
+					level = append(level, child.Id)
+				}
+			}
+		}
+	}
+	return nodeIds, nil
+}
+
+func (s *Service) retrieveCurrentMemberships(
+	ctx context.Context,
+	nodeIds []string,
+) ([]liborgc.MembershipViewEntity, error) {
+	members := make([][]liborgc.MembershipViewEntity, len(nodeIds))
+	eg, ctx := errgroup.WithContext(ctx)
+	eg.SetLimit(s.concurrency())
+	for i, id := range nodeIds {
+		i, id := i, id
+		eg.Go(func() error {
+			m, err := s.OrgClient.GetNodeMembersById(ctx, id)
+			if err != nil {
+				return err
+			}
+			members[i] = *m.Data
+			return nil
+		})
+	}
+	err := eg.Wait()
+	if err != nil {
+		return nil, err
+	}
+	memberships := []liborgc.MembershipViewEntity{}
+	for _, m := range members {
+		memberships = append(memberships, m...)
+	}
+	return memberships, nil
+}
+
+// retrieveMembershipsOn finds the memberships in nodeIds holding on date
+// among the EHIDs graded on that date. Every page looks up the histories of
+// all of them, so it fails when there are more than
+// app.org-nodes.max-history-lookups.
+func (s *Service) retrieveMembershipsOn(
+	ctx context.Context,
+	nodeIds []string,
+	date string,
+) ([]liborgc.MembershipViewEntity, error) {
+	d, err := datestr.NewFromString(date)
+	if err != nil {
+		return nil, err
+	}
+	ehids, err := s.GradingService.RetrieveEhidsActiveOn(ctx, date)
+	if err != nil {
+		return nil, err
+	}
+	maxLookups := s.ConfigService.ConfigRepository.GetOrgNodesMaxHistoryLookups()
+	if len(ehids) > maxLookups {
+		return nil, fmt.Errorf(
+			"%w: as_of: %d EHIDs were graded on %s, more than the %d membership histories that can be looked up",
+			localerror.ErrBadQueryParam,
+			len(ehids),
+			date,
+			maxLookups,
+		)
+	}
+
+	histories := batch.Map(ctx, ehids, s.concurrency(), func(
+		ctx context.Context,
+		ehid string,
+	) (*liborgc.GetMemberHistoryResponseDto, []dto.Warning, error) {
+		h, err := s.OrgClient.GetMemberHistoryByEhidOrderByStartDateDesc(ctx, ehid)
+		if errors.Is(err, localerror.ErrRemoteNotFound) {
+			return &liborgc.GetMemberHistoryResponseDto{Data: &[]liborgc.MembershipViewEntity{}}, nil, nil
+		}
+		return h, nil, err
+	})
+
+	memberships := []liborgc.MembershipViewEntity{}
+	for _, h := range histories {
+		if h.Err != nil {
+			return nil, h.Err
+		}
+		for _, m := range *h.Data.Data {
+			if !slices.Contains(nodeIds, m.NodeId) {
+				continue
+			}
+			interval, err := dateinterval.NewFromStrings(m.StartDate, m.EndDate)
+			if err != nil {
+				return nil, err
+			}
+			if interval.IsEncompassingDate(d) {
+				memberships = append(memberships, m)
+			}
+		}
+	}
+	return memberships, nil
+}
+
+func (s *Service) concurrency() int {
+	return s.ConfigService.ConfigRepository.GetBatchConcurrency()
+}
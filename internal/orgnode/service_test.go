@@ -0,0 +1,331 @@
+package orgnode
+
+import (
+	"context"
+	"errors"
+	"math"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/mrexmelle/connect-authx/pkg/libauthxc"
+	"github.com/mrexmelle/connect-emp/internal/config"
+	"github.com/mrexmelle/connect-emp/internal/grading"
+	"github.com/mrexmelle/connect-emp/internal/localerror"
+	"github.com/mrexmelle/connect-emp/internal/orgclient"
+	"github.com/mrexmelle/connect-emp/internal/titling"
+	"github.com/mrexmelle/connect-org/pkg/liborgc"
+)
+
+func date(s string) time.Time {
+	t, _ := time.Parse("2006-01-02", s)
+	return t
+}
+
+type gradingRepositoryStub struct {
+	grading.Repository
+	Current map[string]string
+	Past    map[string]string
+}
+
+func (r *gradingRepositoryStub) FindCurrentByEhids(ctx context.Context, ehids []string) ([]grading.Entity, error) {
+	entities := []grading.Entity{}
+	for _, ehid := range ehids {
+		if grade, ok := r.Current[ehid]; ok {
+			entities = append(entities, grading.Entity{Ehid: ehid, StartDate: date("2023-01-01"), Grade: grade})
+		}
+	}
+	return entities, nil
+}
+
+func (r *gradingRepositoryStub) FindByEhidsActiveOn(ctx context.Context, ehids []string, d string) ([]grading.Entity, error) {
+	entities := []grading.Entity{}
+	for _, ehid := range ehids {
+		if grade, ok := r.Past[ehid]; ok {
+			entities = append(entities, grading.Entity{Ehid: ehid, StartDate: date("2020-01-01"), Grade: grade})
+		}
+	}
+	return entities, nil
+}
+
+func (r *gradingRepositoryStub) FindEhidsActiveOn(ctx context.Context, d string) ([]string, error) {
+	ehids := []string{}
+	for ehid := range r.Past {
+		ehids = append(ehids, ehid)
+	}
+	slices.Sort(ehids)
+	return ehids, nil
+}
+
+type titlingRepositoryStub struct {
+	titling.Repository
+}
+
+func (r *titlingRepositoryStub) FindCurrentByEhids(ctx context.Context, ehids []string) ([]titling.Entity, error) {
+	entities := []titling.Entity{}
+	for _, ehid := range ehids {
+		entities = append(entities, titling.Entity{Ehid: ehid, StartDate: date("2023-01-01"), Title: "Engineer"})
+	}
+	return entities, nil
+}
+
+func (r *titlingRepositoryStub) FindByEhidsActiveOn(ctx context.Context, ehids []string, d string) ([]titling.Entity, error) {
+	entities := []titling.Entity{}
+	for _, ehid := range ehids {
+		entities = append(entities, titling.Entity{Ehid: ehid, StartDate: date("2020-01-01"), Title: "Junior Engineer"})
+	}
+	return entities, nil
+}
+
+// orgClientStub holds the tree ENG > BE, FE and the memberships in it.
+type orgClientStub struct {
+	orgclient.Client
+	Memberships []liborgc.MembershipViewEntity
+}
+
+var children = map[string][]string{
+	"ENG": {"BE", "FE"},
+}
+
+func (c *orgClientStub) GetNodeById(ctx context.Context, nodeId string) (*orgclient.GetNodeResponseDto, error) {
+	if !slices.Contains([]string{"ENG", "BE", "FE"}, nodeId) {
+		return nil, localerror.NewRemoteError("org", localerror.ErrSvcCodeRecordNotFound, "no node")
+	}
+	hierarchy := nodeId
+	if nodeId != "ENG" {
+		hierarchy = "ENG." + nodeId
+	}
+	return &orgclient.GetNodeResponseDto{Data: &orgclient.NodeViewEntity{Id: nodeId, Hierarchy: hierarchy}}, nil
+}
+
+// GetNodeOfficersById makes u001 head ENG and BE, and u003 and u002 share
+// the lead of FE.
+func (c *orgClientStub) GetNodeOfficersById(ctx context.Context, nodeId string) (*orgclient.GetNodeOfficersResponseDto, error) {
+	officers := map[string][]orgclient.DesignationViewEntity{
+		"ENG": {{RoleId: "head", Ehid: "u001"}},
+		"BE":  {{RoleId: "deputy", Ehid: "u002"}, {RoleId: "head", Ehid: "u001"}},
+		"FE":  {{RoleId: "head", Ehid: "u003"}, {RoleId: "head", Ehid: "u002"}, {RoleId: "retired", Ehid: "u004"}},
+	}[nodeId]
+	return &orgclient.GetNodeOfficersResponseDto{Data: &officers}, nil
+}
+
+func (c *orgClientStub) GetRoleById(ctx context.Context, roleId string) (*orgclient.GetRoleResponseDto, error) {
+	ranks := map[string]int{"head": 1, "deputy": 2}
+	rank, exists := ranks[roleId]
+	if !exists {
+		return nil, localerror.NewRemoteError("org", localerror.ErrSvcCodeRecordNotFound, "no role")
+	}
+	return &orgclient.GetRoleResponseDto{Data: &orgclient.RoleViewEntity{Id: roleId, Rank: rank}}, nil
+}
+
+func (c *orgClientStub) GetNodeChildrenById(ctx context.Context, nodeId string) (*orgclient.GetNodeChildrenResponseDto, error) {
+	nodes := []orgclient.NodeViewEntity{}
+	for _, id := range children[nodeId] {
+		nodes = append(nodes, orgclient.NodeViewEntity{Id: id})
+	}
+	return &orgclient.GetNodeChildrenResponseDto{Data: &nodes}, nil
+}
+
+func (c *orgClientStub) GetNodeMembersById(ctx context.Context, nodeId string) (*orgclient.GetNodeMembersResponseDto, error) {
+	members := []liborgc.MembershipViewEntity{}
+	for _, m := range c.Memberships {
+		if m.NodeId == nodeId && m.EndDate == "" {
+			members = append(members, m)
+		}
+	}
+	return &orgclient.GetNodeMembersResponseDto{Data: &members}, nil
+}
+
+func (c *orgClientStub) GetMemberHistoryByEhidOrderByStartDateDesc(
+	ctx context.Context,
+	ehid string,
+) (*liborgc.GetMemberHistoryResponseDto, error) {
+	history := []liborgc.MembershipViewEntity{}
+	for _, m := range c.Memberships {
+		if m.Ehid == ehid {
+			history = append(history, m)
+		}
+	}
+	return &liborgc.GetMemberHistoryResponseDto{Data: &history}, nil
+}
+
+type authxClientStub struct {
+	Err error
+}
+
+func (c *authxClientStub) GetProfileByEhid(ctx context.Context, ehid string) (*libauthxc.GetProfileResponseDto, error) {
+	if c.Err != nil {
+		return nil, c.Err
+	}
+	return &libauthxc.GetProfileResponseDto{Data: &libauthxc.ProfileEntity{Name: "Name of " + ehid}}, nil
+}
+
+func newTestService(authx *authxClientStub) *Service {
+	return NewService(
+		&config.Service{ConfigRepository: &config.RepositoryImpl{BatchConcurrency: 2, OrgNodesMaxHistoryLookups: 10}},
+		grading.NewService(nil, &gradingRepositoryStub{
+			Current: map[string]string{"u001": "E5", "u002": "E4", "u003": "E3"},
+			Past:    map[string]string{"u001": "E3", "u002": "E2", "u004": "E4"},
+		}),
+		titling.NewService(nil, &titlingRepositoryStub{}),
+		&orgClientStub{Memberships: []liborgc.MembershipViewEntity{
+			{Ehid: "u001", NodeId: "ENG", StartDate: "2021-06-01"},
+			{Ehid: "u001", NodeId: "BE", StartDate: "2019-01-01", EndDate: "2021-05-31"},
+			{Ehid: "u002", NodeId: "BE", StartDate: "2019-01-01"},
+			{Ehid: "u003", NodeId: "FE", StartDate: "2023-01-01"},
+			{Ehid: "u004", NodeId: "FE", StartDate: "2019-01-01", EndDate: "2022-12-31"},
+		}},
+		authx,
+	)
+}
+
+func ehidsOf(view *MembersViewEntity) []string {
+	ehids := []string{}
+	for _, m := range view.Members {
+		ehids = append(ehids, m.Ehid)
+	}
+	return ehids
+}
+
+func TestRetrieveMembers(t *testing.T) {
+	s := newTestService(&authxClientStub{})
+
+	view, _, err := s.RetrieveMembers(context.Background(), "ENG", MembersQuery{Page: 1, PageSize: 10}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(ehidsOf(view), []string{"u001"}) {
+		t.Errorf("expected the direct members only, got %+v", view.Members)
+	}
+	m := view.Members[0]
+	if m.Name != "Name of u001" || m.Grade != "E5" || m.Title != "Engineer" || m.MemberSince != "2021-06-01" {
+		t.Errorf("unexpected member %+v", m)
+	}
+
+	view, _, err = s.RetrieveMembers(context.Background(), "ENG", MembersQuery{Recursive: true, Page: 2, PageSize: 2}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if view.Total != 3 || !slices.Equal(ehidsOf(view), []string{"u003"}) {
+		t.Errorf("expected the second page of the subtree, got %d: %+v", view.Total, view.Members)
+	}
+
+	view, _, err = s.RetrieveMembers(context.Background(), "ENG", MembersQuery{Recursive: true, Page: math.MaxInt/2 + 2, PageSize: 2}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if view.Total != 3 || len(view.Members) != 0 {
+		t.Errorf("expected a page past the end to be empty, got %d: %+v", view.Total, view.Members)
+	}
+}
+
+func TestRetrieveMembersAsOf(t *testing.T) {
+	s := newTestService(&authxClientStub{})
+
+	view, _, err := s.RetrieveMembers(context.Background(), "ENG", MembersQuery{AsOf: "2020-06-01", Recursive: true, Page: 1, PageSize: 10}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(ehidsOf(view), []string{"u001", "u002", "u004"}) {
+		t.Fatalf("unexpected members %+v", view.Members)
+	}
+	if m := view.Members[0]; m.NodeId != "BE" || m.Grade != "E3" || m.Title != "Junior Engineer" {
+		t.Errorf("expected the past membership, grade and title, got %+v", m)
+	}
+}
+
+func TestRetrieveMembersAsOfTooManyLookups(t *testing.T) {
+	s := newTestService(&authxClientStub{})
+	s.ConfigService = &config.Service{ConfigRepository: &config.RepositoryImpl{BatchConcurrency: 2, OrgNodesMaxHistoryLookups: 2}}
+
+	_, _, err := s.RetrieveMembers(context.Background(), "ENG", MembersQuery{AsOf: "2020-06-01", Recursive: true, Page: 1, PageSize: 10}, false)
+	if !errors.Is(err, localerror.ErrBadQueryParam) {
+		t.Errorf("expected %v for 3 histories out of 2, got %v", localerror.ErrBadQueryParam, err)
+	}
+}
+
+func TestRetrieveMembersUnknownNode(t *testing.T) {
+	s := newTestService(&authxClientStub{})
+
+	_, _, err := s.RetrieveMembers(context.Background(), "HR", MembersQuery{Page: 1, PageSize: 10}, false)
+	if !errors.Is(err, localerror.ErrRemoteNotFound) {
+		t.Errorf("expected %v, got %v", localerror.ErrRemoteNotFound, err)
+	}
+}
+
+func TestRetrieveMembersDegraded(t *testing.T) {
+	s := newTestService(&authxClientStub{Err: localerror.ErrHttpClient})
+
+	_, _, err := s.RetrieveMembers(context.Background(), "ENG", MembersQuery{Recursive: true, Page: 1, PageSize: 10}, false)
+	if !errors.Is(err, localerror.ErrHttpClient) {
+		t.Fatalf("expected strict mode to fail, got %v", err)
+	}
+
+	view, warnings, err := s.RetrieveMembers(context.Background(), "ENG", MembersQuery{Recursive: true, Page: 1, PageSize: 10}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(view.Members) != 3 || view.Members[0].Name != "" || view.Members[0].Grade != "E5" {
+		t.Errorf("unexpected members %+v", view.Members)
+	}
+	if len(warnings) != 1 || warnings[0].Source != localerror.WarningSourceAuthx {
+		t.Errorf("expected one authx warning, got %+v", warnings)
+	}
+}
+
+func TestRetrieveLead(t *testing.T) {
+	s := newTestService(&authxClientStub{})
+
+	for nodeId, expected := range map[string]string{"ENG": "u001", "BE": "u001", "FE": "u002", "HR": ""} {
+		lead, err := s.RetrieveLead(context.Background(), nodeId)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if lead != expected {
+			t.Errorf("expected %q to lead %s, got %q", expected, nodeId, lead)
+		}
+	}
+}
+
+func TestRetrieveManagersAndDirectReports(t *testing.T) {
+	s := newTestService(&authxClientStub{})
+
+	managers, err := s.RetrieveManagers(context.Background(), "u003", "FE")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []PersonViewEntity{{Ehid: "u002", NodeId: "FE"}, {Ehid: "u001", NodeId: "ENG"}}
+	if !slices.Equal(managers, expected) {
+		t.Errorf("expected %+v, got %+v", expected, managers)
+	}
+	managers, err = s.RetrieveManagers(context.Background(), "u002", "BE")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(managers, []PersonViewEntity{{Ehid: "u001", NodeId: "BE"}}) {
+		t.Errorf("expected a lead of two nodes to be listed once, got %+v", managers)
+	}
+
+	reports, err := s.RetrieveDirectReports(context.Background(), "u001", "ENG")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// u002 is a member of BE, which u001 leads too, but is met first as the
+	// lead of FE, closer to ENG.
+	expected = []PersonViewEntity{{Ehid: "u002", NodeId: "FE"}}
+	if !slices.Equal(reports, expected) {
+		t.Errorf("expected %+v, got %+v", expected, reports)
+	}
+	_, err = s.DescribePeople(context.Background(), reports, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p := reports[0]; p.Name != "Name of u002" || p.Grade != "E4" || p.Title != "Engineer" {
+		t.Errorf("unexpected person %+v", p)
+	}
+
+	reports, err = s.RetrieveDirectReports(context.Background(), "u003", "FE")
+	if err != nil || len(reports) != 0 {
+		t.Errorf("expected no reports for someone not leading their node, got %+v, %v", reports, err)
+	}
+}
@@ -0,0 +1,53 @@
+package outbox
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+)
+
+type Entity struct {
+	Id            int64
+	EventType     string
+	AggregateType string
+	AggregateId   string
+	Payload       []byte
+	CreatedAt     time.Time
+	Attempts      int
+	NextAttemptAt time.Time
+	LastError     sql.NullString
+	DeliveredAt   sql.NullTime
+}
+
+type ViewEntity struct {
+	Id            int64           `json:"id"`
+	Type          string          `json:"type"`
+	AggregateType string          `json:"aggregate_type"`
+	AggregateId   string          `json:"aggregate_id"`
+	OccurredAt    string          `json:"occurred_at"`
+	Data          json.RawMessage `json:"data"`
+}
+
+func NewEntity(eventType string, aggregateType string, aggregateId string, data any) (*Entity, error) {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	return &Entity{
+		EventType:     eventType,
+		AggregateType: aggregateType,
+		AggregateId:   aggregateId,
+		Payload:       payload,
+	}, nil
+}
+
+func toViewEntity(e *Entity) *ViewEntity {
+	return &ViewEntity{
+		Id:            e.Id,
+		Type:          e.EventType,
+		AggregateType: e.AggregateType,
+		AggregateId:   e.AggregateId,
+		OccurredAt:    e.CreatedAt.Format(time.RFC3339),
+		Data:          json.RawMessage(e.Payload),
+	}
+}
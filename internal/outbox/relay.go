@@ -0,0 +1,164 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/mrexmelle/connect-emp/internal/config"
+	"gorm.io/gorm"
+)
+
+const (
+	BackoffBase = 5 * time.Second
+	BackoffMax  = 30 * time.Minute
+
+	// Lease is how long a relay holds the events it claimed.
+	Lease = 5 * time.Minute
+)
+
+// Relay delivers pending outbox events to every configured sink. An event is
+// marked delivered only after all sinks accepted it, so sinks and consumers
+// must tolerate duplicates.
+type Relay struct {
+	OutboxRepository Repository
+	Sinks            []Sink
+	Interval         time.Duration
+	BatchSize        int
+	Lease            time.Duration
+}
+
+func NewRelay(cfg *config.Service, r Repository) *Relay {
+	sinks := []Sink{}
+	for _, url := range cfg.ConfigRepository.GetOutboxWebhookUrls() {
+		sinks = append(sinks, NewHttpSink(url))
+	}
+	if path := cfg.ConfigRepository.GetOutboxFilePath(); path != "" {
+		sinks = append(sinks, NewFileSink(path))
+	}
+
+	return &Relay{
+		OutboxRepository: r,
+		Sinks:            sinks,
+		Interval:         cfg.ConfigRepository.GetOutboxInterval(),
+		BatchSize:        cfg.ConfigRepository.GetOutboxBatchSize(),
+		Lease:            Lease,
+	}
+}
+
+func (r *Relay) AddSink(s Sink) {
+	r.Sinks = append(r.Sinks, s)
+}
+
+func (r *Relay) Run(ctx context.Context) {
+	if len(r.Sinks) == 0 {
+		log.Println("outbox: no sinks configured, events are kept pending")
+		return
+	}
+
+	ticker := time.NewTicker(r.Interval)
+	defer ticker.Stop()
+
+	for {
+		for {
+			n, err := r.DispatchBatch(ctx)
+			if err != nil {
+				log.Printf("outbox: %v", err)
+			}
+			if err != nil || n < r.BatchSize || ctx.Err() != nil {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// DispatchBatch claims a batch of due events and delivers them outside of
+// any transaction, then records the outcomes in one short transaction.
+// Delivery stops when the lease runs out; the events left over are claimed
+// again once it has. It also stops when ctx is canceled, in which case a
+// delivery cut short does not count as an attempt and the events left over
+// are released at once.
+func (r *Relay) DispatchBatch(ctx context.Context) (int, error) {
+	claimedAt := time.Now()
+	entities, err := r.OutboxRepository.ClaimPending(r.BatchSize, r.Lease)
+	if err != nil {
+		return 0, err
+	}
+
+	outcomes := []error{}
+	for i := range entities {
+		if ctx.Err() != nil || time.Since(claimedAt) >= r.Lease {
+			break
+		}
+		err := r.deliver(ctx, toViewEntity(&entities[i]))
+		if err != nil && ctx.Err() != nil {
+			break
+		}
+		outcomes = append(outcomes, err)
+	}
+	released := []int64{}
+	if ctx.Err() != nil {
+		for _, e := range entities[len(outcomes):] {
+			released = append(released, e.Id)
+		}
+	}
+	if len(outcomes) == 0 && len(released) == 0 {
+		return len(entities), nil
+	}
+
+	err = r.OutboxRepository.Transaction(func(tx *gorm.DB) error {
+		if len(released) > 0 {
+			err := r.OutboxRepository.Release(tx, released)
+			if err != nil {
+				return err
+			}
+		}
+		for i, deliverErr := range outcomes {
+			var err error
+			if deliverErr != nil {
+				err = r.OutboxRepository.MarkFailed(
+					tx,
+					entities[i].Id,
+					deliverErr.Error(),
+					time.Now().Add(Backoff(entities[i].Attempts+1)),
+				)
+			} else {
+				err = r.OutboxRepository.MarkDelivered(tx, entities[i].Id)
+			}
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return len(entities), err
+}
+
+func (r *Relay) deliver(ctx context.Context, e *ViewEntity) error {
+	errs := []error{}
+	for _, s := range r.Sinks {
+		err := s.Deliver(ctx, e)
+		if err != nil {
+			errs = append(errs, errors.New(s.Name()+": "+err.Error()))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func Backoff(attempts int) time.Duration {
+	d := BackoffBase
+	for i := 1; i < attempts; i++ {
+		d *= 2
+		if d >= BackoffMax {
+			return BackoffMax
+		}
+	}
+	return d
+}
@@ -0,0 +1,243 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type BackoffTestCase struct {
+	name     string
+	attempts int
+	expected time.Duration
+}
+
+type DispatchBatchTestCase struct {
+	name              string
+	sinkErrs          []error
+	expectedDelivered []int64
+	expectedFailed    []int64
+}
+
+type fakeRepository struct {
+	pending   []Entity
+	delivered []int64
+	failed    []int64
+	released  []int64
+}
+
+func (r *fakeRepository) Append(tx *gorm.DB, e *Entity) error {
+	r.pending = append(r.pending, *e)
+	return nil
+}
+
+func (r *fakeRepository) ClaimPending(limit int, lease time.Duration) ([]Entity, error) {
+	if len(r.pending) > limit {
+		return r.pending[:limit], nil
+	}
+	return r.pending, nil
+}
+
+func (r *fakeRepository) Transaction(fn func(tx *gorm.DB) error) error {
+	return fn(nil)
+}
+
+func (r *fakeRepository) MarkDelivered(tx *gorm.DB, id int64) error {
+	r.delivered = append(r.delivered, id)
+	return nil
+}
+
+func (r *fakeRepository) MarkFailed(tx *gorm.DB, id int64, reason string, nextAttemptAt time.Time) error {
+	r.failed = append(r.failed, id)
+	return nil
+}
+
+func (r *fakeRepository) Release(tx *gorm.DB, ids []int64) error {
+	r.released = append(r.released, ids...)
+	return nil
+}
+
+type fakeSink struct {
+	errs     map[int64]error
+	received []int64
+	// cancel is called on delivering cancelOn, failing it with the
+	// context error.
+	cancel   func()
+	cancelOn int64
+}
+
+func (s *fakeSink) Name() string {
+	return "fake"
+}
+
+func (s *fakeSink) Deliver(ctx context.Context, e *ViewEntity) error {
+	s.received = append(s.received, e.Id)
+	if s.cancel != nil && e.Id == s.cancelOn {
+		s.cancel()
+		return ctx.Err()
+	}
+	return s.errs[e.Id]
+}
+
+func TestBackoff(t *testing.T) {
+	tc := []BackoffTestCase{
+		{
+			name:     "First attempt",
+			attempts: 1,
+			expected: BackoffBase,
+		},
+		{
+			name:     "Third attempt",
+			attempts: 3,
+			expected: 4 * BackoffBase,
+		},
+		{
+			name:     "Capped attempt",
+			attempts: 50,
+			expected: BackoffMax,
+		},
+	}
+
+	for _, c := range tc {
+		out := Backoff(c.attempts)
+		if out != c.expected {
+			t.Errorf("[%s]\nresult: %v\nexpected: %v\n",
+				c.name,
+				out,
+				c.expected,
+			)
+		}
+	}
+}
+
+func TestDispatchBatch(t *testing.T) {
+	tc := []DispatchBatchTestCase{
+		{
+			name:              "All sinks accept",
+			sinkErrs:          []error{nil, nil},
+			expectedDelivered: []int64{1, 2},
+			expectedFailed:    []int64{},
+		},
+		{
+			name:              "One sink rejects one event",
+			sinkErrs:          []error{nil, errors.New("unavailable")},
+			expectedDelivered: []int64{1},
+			expectedFailed:    []int64{2},
+		},
+	}
+
+	for _, c := range tc {
+		repo := &fakeRepository{
+			pending:   []Entity{{Id: 1}, {Id: 2}},
+			delivered: []int64{},
+			failed:    []int64{},
+		}
+		healthy := &fakeSink{errs: map[int64]error{}}
+		flaky := &fakeSink{errs: map[int64]error{1: c.sinkErrs[0], 2: c.sinkErrs[1]}}
+		relay := &Relay{
+			OutboxRepository: repo,
+			Sinks:            []Sink{healthy, flaky},
+			BatchSize:        10,
+			Lease:            time.Minute,
+		}
+
+		n, err := relay.DispatchBatch(context.Background())
+		if err != nil || n != 2 {
+			t.Errorf("[%s]\nresult: %d, %v\nexpected: %d, %v\n", c.name, n, err, 2, nil)
+		}
+		if len(healthy.received) != 2 {
+			t.Errorf("[%s]\nresult: %v\nexpected: every event reaches every sink\n",
+				c.name,
+				healthy.received,
+			)
+		}
+		if !equalIds(repo.delivered, c.expectedDelivered) {
+			t.Errorf("[%s]\nresult: %v\nexpected: %v\n",
+				c.name,
+				repo.delivered,
+				c.expectedDelivered,
+			)
+		}
+		if !equalIds(repo.failed, c.expectedFailed) {
+			t.Errorf("[%s]\nresult: %v\nexpected: %v\n",
+				c.name,
+				repo.failed,
+				c.expectedFailed,
+			)
+		}
+	}
+}
+
+func TestDispatchBatchStopsWhenLeaseRunsOut(t *testing.T) {
+	repo := &fakeRepository{
+		pending:   []Entity{{Id: 1}, {Id: 2}},
+		delivered: []int64{},
+		failed:    []int64{},
+	}
+	sink := &fakeSink{errs: map[int64]error{}}
+	relay := &Relay{
+		OutboxRepository: repo,
+		Sinks:            []Sink{sink},
+		BatchSize:        10,
+		Lease:            time.Nanosecond,
+	}
+
+	n, err := relay.DispatchBatch(context.Background())
+	if err != nil || n != 2 {
+		t.Fatalf("result: %d, %v\nexpected: %d, %v\n", n, err, 2, nil)
+	}
+	if len(sink.received) != 0 || len(repo.delivered) != 0 || len(repo.failed) != 0 {
+		t.Errorf("result: %v received, %v delivered, %v failed\nexpected: the events left to the next claim\n",
+			sink.received,
+			repo.delivered,
+			repo.failed,
+		)
+	}
+}
+
+func TestDispatchBatchStopsOnCancel(t *testing.T) {
+	repo := &fakeRepository{
+		pending:   []Entity{{Id: 1}, {Id: 2}, {Id: 3}},
+		delivered: []int64{},
+		failed:    []int64{},
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	sink := &fakeSink{errs: map[int64]error{}, cancel: cancel, cancelOn: 2}
+	relay := &Relay{
+		OutboxRepository: repo,
+		Sinks:            []Sink{sink},
+		BatchSize:        10,
+		Lease:            time.Minute,
+	}
+
+	_, err := relay.DispatchBatch(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !equalIds(sink.received, []int64{1, 2}) {
+		t.Errorf("result: %v\nexpected: no delivery after the cancel\n", sink.received)
+	}
+	if !equalIds(repo.delivered, []int64{1}) || len(repo.failed) != 0 || !equalIds(repo.released, []int64{2, 3}) {
+		t.Errorf("result: %v delivered, %v failed, %v released\nexpected: [1] delivered, [] failed, [2 3] released\n",
+			repo.delivered,
+			repo.failed,
+			repo.released,
+		)
+	}
+}
+
+func equalIds(a []int64, b []int64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
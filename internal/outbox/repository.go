@@ -0,0 +1,113 @@
+package outbox
+
+import (
+	"time"
+
+	"github.com/mrexmelle/connect-emp/internal/config"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type Repository interface {
+	Append(tx *gorm.DB, e *Entity) error
+	ClaimPending(limit int, lease time.Duration) ([]Entity, error)
+	Transaction(fn func(tx *gorm.DB) error) error
+	MarkDelivered(tx *gorm.DB, id int64) error
+	MarkFailed(tx *gorm.DB, id int64, reason string, nextAttemptAt time.Time) error
+	Release(tx *gorm.DB, ids []int64) error
+}
+
+type RepositoryImpl struct {
+	ConfigService *config.Service
+	TableName     string
+}
+
+func NewRepository(cfg *config.Service) Repository {
+	return &RepositoryImpl{
+		ConfigService: cfg,
+		TableName:     "outbox_events",
+	}
+}
+
+func (r *RepositoryImpl) Append(tx *gorm.DB, e *Entity) error {
+	return tx.Raw(
+		"INSERT INTO "+r.TableName+"(event_type, aggregate_type, aggregate_id, payload, "+
+			"created_at, next_attempt_at) "+
+			"VALUES(?, ?, ?, ?, NOW(), NOW()) RETURNING id, created_at",
+		e.EventType,
+		e.AggregateType,
+		e.AggregateId,
+		string(e.Payload),
+	).Row().Scan(&e.Id, &e.CreatedAt)
+}
+
+// ClaimPending leases up to limit due events by pushing their next attempt
+// lease into the future, so that other relays pass them over while they are
+// delivered. The rows are only locked while being claimed.
+func (r *RepositoryImpl) ClaimPending(limit int, lease time.Duration) ([]Entity, error) {
+	entities := []Entity{}
+	err := r.ConfigService.WriteDb.Transaction(func(tx *gorm.DB) error {
+		result := tx.
+			Table(r.TableName).
+			Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("delivered_at IS NULL").
+			Where("next_attempt_at <= NOW()").
+			Order("id ASC").
+			Limit(limit).
+			Find(&entities)
+		if result.Error != nil {
+			return result.Error
+		}
+		if len(entities) == 0 {
+			return nil
+		}
+
+		ids := []int64{}
+		for _, e := range entities {
+			ids = append(ids, e.Id)
+		}
+		return tx.
+			Table(r.TableName).
+			Where("id IN ?", ids).
+			Update("next_attempt_at", time.Now().Add(lease)).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return entities, nil
+}
+
+func (r *RepositoryImpl) Transaction(fn func(tx *gorm.DB) error) error {
+	return r.ConfigService.WriteDb.Transaction(fn)
+}
+
+func (r *RepositoryImpl) MarkDelivered(tx *gorm.DB, id int64) error {
+	return tx.
+		Table(r.TableName).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"delivered_at": gorm.Expr("NOW()"),
+			"attempts":     gorm.Expr("attempts + 1"),
+			"last_error":   nil,
+		}).Error
+}
+
+func (r *RepositoryImpl) MarkFailed(tx *gorm.DB, id int64, reason string, nextAttemptAt time.Time) error {
+	return tx.
+		Table(r.TableName).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"attempts":        gorm.Expr("attempts + 1"),
+			"last_error":      reason,
+			"next_attempt_at": nextAttemptAt,
+		}).Error
+}
+
+// Release ends the lease of claimed events that were not attempted, making
+// them due again at once.
+func (r *RepositoryImpl) Release(tx *gorm.DB, ids []int64) error {
+	return tx.
+		Table(r.TableName).
+		Where("id IN ?", ids).
+		Update("next_attempt_at", gorm.Expr("NOW()")).Error
+}
@@ -0,0 +1,99 @@
+package outbox
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+type Sink interface {
+	Name() string
+	Deliver(ctx context.Context, e *ViewEntity) error
+}
+
+type HttpSink struct {
+	Url    string
+	Client *http.Client
+}
+
+func NewHttpSink(url string) *HttpSink {
+	return &HttpSink{
+		Url: url,
+		Client: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+	}
+}
+
+func (s *HttpSink) Name() string {
+	return "webhook:" + s.Url
+}
+
+func (s *HttpSink) Deliver(ctx context.Context, e *ViewEntity) error {
+	body, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.Url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Event-Id", strconv.FormatInt(e.Id, 10))
+	req.Header.Set("X-Event-Type", e.Type)
+
+	response, err := s.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		return fmt.Errorf("%s responded with status %d", s.Url, response.StatusCode)
+	}
+	return nil
+}
+
+type FileSink struct {
+	Path string
+	mu   sync.Mutex
+}
+
+func NewFileSink(path string) *FileSink {
+	return &FileSink{
+		Path: path,
+	}
+}
+
+func (s *FileSink) Name() string {
+	return "file:" + s.Path
+}
+
+func (s *FileSink) Deliver(ctx context.Context, e *ViewEntity) error {
+	line, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, err := os.OpenFile(s.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = f.Write(append(line, '\n'))
+	if err != nil {
+		return err
+	}
+	return f.Sync()
+}
@@ -0,0 +1,92 @@
+package outbox
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+type HttpSinkTestCase struct {
+	name       string
+	statusCode int
+	isAccepted bool
+}
+
+func TestHttpSinkDeliver(t *testing.T) {
+	tc := []HttpSinkTestCase{
+		{
+			name:       "Receiver accepts",
+			statusCode: http.StatusNoContent,
+			isAccepted: true,
+		},
+		{
+			name:       "Receiver fails",
+			statusCode: http.StatusServiceUnavailable,
+			isAccepted: false,
+		},
+	}
+
+	for _, c := range tc {
+		received := ViewEntity{}
+		receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("X-Event-Type") != "grading.created" {
+				t.Errorf("[%s]\nresult: %s\nexpected: %s\n", c.name, r.Header.Get("X-Event-Type"), "grading.created")
+			}
+			json.NewDecoder(r.Body).Decode(&received)
+			w.WriteHeader(c.statusCode)
+		}))
+
+		err := NewHttpSink(receiver.URL).Deliver(context.Background(), &ViewEntity{
+			Id:   7,
+			Type: "grading.created",
+			Data: json.RawMessage(`{"grade":"E5"}`),
+		})
+		receiver.Close()
+
+		accepted := (err == nil)
+		if accepted != c.isAccepted {
+			t.Errorf("[%s]\nresult: %t\nexpected: %t\n", c.name, accepted, c.isAccepted)
+		}
+		if received.Id != 7 || string(received.Data) != `{"grade":"E5"}` {
+			t.Errorf("[%s]\nresult: %+v\nexpected: event 7 with its data\n", c.name, received)
+		}
+	}
+}
+
+func TestFileSinkDeliver(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+	sink := NewFileSink(path)
+
+	for _, id := range []int64{1, 2} {
+		err := sink.Deliver(context.Background(), &ViewEntity{
+			Id:   id,
+			Type: "titling.ended",
+			Data: json.RawMessage(`{}`),
+		})
+		if err != nil {
+			t.Fatalf("[Deliver %d]\nresult: %v\nexpected: %v\n", id, err, nil)
+		}
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	ids := []int64{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		e := ViewEntity{}
+		json.Unmarshal(scanner.Bytes(), &e)
+		ids = append(ids, e.Id)
+	}
+	if !equalIds(ids, []int64{1, 2}) {
+		t.Errorf("[Events are appended]\nresult: %v\nexpected: %v\n", ids, []int64{1, 2})
+	}
+}
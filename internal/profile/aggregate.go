@@ -1,12 +1,21 @@
 package profile
 
+import (
+	"github.com/mrexmelle/connect-emp/internal/career"
+	"github.com/mrexmelle/connect-emp/internal/orgnode"
+)
+
 type Aggregate struct {
-	Ehid             string `json:"ehid"`
-	EmployeeId       string `json:"employee_id"`
-	Name             string `json:"name"`
-	EmailAddress     string `json:"email_address"`
-	Dob              string `json:"dob"`
-	Grade            string `json:"grade"`
-	Title            string `json:"title"`
-	OrganizationNode string `json:"organization_node"`
+	Ehid             string       `json:"ehid"`
+	EmployeeId       string       `json:"employee_id,omitempty"`
+	Name             string       `json:"name,omitempty"`
+	EmailAddress     string       `json:"email_address,omitempty"`
+	Dob              string       `json:"dob,omitempty"`
+	Grade            string       `json:"grade"`
+	Title            string       `json:"title"`
+	OrganizationNode string       `json:"organization_node,omitempty"`
+	Org              *career.Node `json:"org,omitempty"`
+
+	Managers      []orgnode.PersonViewEntity `json:"managers,omitempty"`
+	DirectReports []orgnode.PersonViewEntity `json:"direct_reports,omitempty"`
 }
@@ -0,0 +1,16 @@
+package profile
+
+const (
+	IncludeManagers      = "managers"
+	IncludeDirectReports = "direct_reports"
+)
+
+// Query tells which optional parts of a profile to build. ExpandOrg adds the
+// name, type and ancestors of the organization node, IncludeManagers the
+// chain of managers up to the root and IncludeDirectReports the people
+// reporting to the employee.
+type Query struct {
+	ExpandOrg            bool
+	IncludeManagers      bool
+	IncludeDirectReports bool
+}
@@ -0,0 +1,10 @@
+package titling
+
+var (
+	AggregateType = "titling"
+
+	EventTypeCreated = "titling.created"
+	EventTypeUpdated = "titling.updated"
+	EventTypeEnded   = "titling.ended"
+	EventTypeDeleted = "titling.deleted"
+)
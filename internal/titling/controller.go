@@ -4,12 +4,14 @@ import (
 	"encoding/json"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/go-chi/chi"
 	"github.com/mrexmelle/connect-emp/internal/config"
 	"github.com/mrexmelle/connect-emp/internal/dto/dtorespwithdata"
 	"github.com/mrexmelle/connect-emp/internal/dto/dtorespwithoutdata"
 	"github.com/mrexmelle/connect-emp/internal/localerror"
+	"github.com/mrexmelle/connect-emp/internal/txtime"
 )
 
 type Controller struct {
@@ -44,7 +46,7 @@ func (c *Controller) Get(w http.ResponseWriter, r *http.Request) {
 		).RenderTo(w, http.StatusBadRequest)
 		return
 	}
-	data, err := c.TitlingService.RetrieveById(id)
+	data, err := c.TitlingService.RetrieveById(r.Context(), id)
 	info := c.LocalErrorService.Map(err)
 	dtorespwithdata.New(
 		data,
@@ -53,6 +55,41 @@ func (c *Controller) Get(w http.ResponseWriter, r *http.Request) {
 	).RenderTo(w, info.HttpStatusCode)
 }
 
+// Get Titlings by EHID : HTTP endpoint to get the titling history of an account
+// @Tags Accounts
+// @Description Get titling history of an account, optionally as known at a point in transaction time
+// @Produce json
+// @Param ehid path string true "EHID"
+// @Param sort query string false "Sort by start date (asc or desc)"
+// @Param as_known_at query string false "Transaction time in RFC3339, or YYYY-MM-DD for the end of that day in UTC"
+// @Success 200 {object} GetByEhidResponseDto "Success Response"
+// @Failure 400 "BadRequest"
+// @Failure 500 "InternalServerError"
+// @Router /accounts/{ehid}/titlings [GET]
+func (c *Controller) GetByEhid(w http.ResponseWriter, r *http.Request) {
+	knownAt, err := txtime.NewFromString(r.URL.Query().Get("as_known_at"))
+	if err != nil {
+		dtorespwithdata.NewError(
+			localerror.ErrBadQueryParam.Error(),
+			err.Error(),
+		).RenderTo(w, http.StatusBadRequest)
+		return
+	}
+
+	data, err := c.TitlingService.RetrieveByEhidAsKnownAtOrderByStartDate(
+		r.Context(),
+		chi.URLParam(r, "ehid"),
+		knownAt,
+		strings.ToUpper(r.URL.Query().Get("sort")),
+	)
+	info := c.LocalErrorService.Map(err)
+	dtorespwithdata.New(
+		&data,
+		info.ServiceErrorCode,
+		info.ServiceErrorMessage,
+	).RenderTo(w, info.HttpStatusCode)
+}
+
 // Post Titlings : HTTP endpoint to post new titlings
 // @Tags Titlings
 // @Description Post a new titlings
@@ -74,7 +111,7 @@ func (c *Controller) Post(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	data, err := c.TitlingService.Create(requestBody)
+	data, err := c.TitlingService.Create(r.Context(), requestBody)
 	info := c.LocalErrorService.Map(err)
 	dtorespwithdata.New(
 		data,
@@ -114,7 +151,7 @@ func (c *Controller) Patch(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = c.TitlingService.UpdateById(requestBody.Fields, id)
+	err = c.TitlingService.UpdateById(r.Context(), requestBody.Fields, id)
 	info := c.LocalErrorService.Map(err)
 	dtorespwithoutdata.New(
 		info.ServiceErrorCode,
@@ -141,7 +178,7 @@ func (c *Controller) Delete(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = c.TitlingService.DeleteById(id)
+	err = c.TitlingService.DeleteById(r.Context(), id)
 	info := c.LocalErrorService.Map(err)
 	dtorespwithoutdata.New(
 		info.ServiceErrorCode,
@@ -1,6 +1,8 @@
 package titling
 
 import (
+	"time"
+
 	"gorm.io/gorm"
 )
 
@@ -26,6 +28,16 @@ var (
 		"title",
 	}
 
+	FieldsHistoryAll = []string{
+		"titling_id AS id",
+		"ehid",
+		"start_date",
+		"end_date",
+		"title",
+		"recorded_from",
+		"recorded_to",
+	}
+
 	FieldsPatchable = []string{
 		"title",
 		"end_date",
@@ -41,6 +53,12 @@ type Query interface {
 	SelectByEhid(fields []string, ehid string) *gorm.DB
 	SelectByEhidOrderByStartDate(fields []string, ehid string, orderDir string) *gorm.DB
 	SelectActiveByEhid(fields []string, ehid string) *gorm.DB
+	SelectByEhidsOrderByStartDate(fields []string, ehids []string, orderDir string) *gorm.DB
+	SelectActiveByEhids(fields []string, ehids []string) *gorm.DB
+	SelectByEhidsActiveOn(fields []string, ehids []string, date string) *gorm.DB
+	SelectEhidsActiveOn(date string) *gorm.DB
+	SelectEhidsActiveBetween(startDate string, endDate string) *gorm.DB
+	SelectByEhidRecordedAtOrderByStartDate(fields []string, ehid string, recordedAt time.Time, orderDir string) *gorm.DB
 	ByEhidAndIntersectingDates(ehid string, startDate string, endDate string) *gorm.DB
 	ByEhidAndEndDateIsNull(ehid string) *gorm.DB
 }
@@ -82,6 +100,17 @@ func (q *QueryImpl) SelectByEhidOrderByStartDate(fields []string, ehid string, o
 	}
 }
 
+func (q *QueryImpl) SelectByEhidRecordedAtOrderByStartDate(
+	fields []string,
+	ehid string,
+	recordedAt time.Time,
+	orderDir string,
+) *gorm.DB {
+	return q.SelectByEhidOrderByStartDate(fields, ehid, orderDir).
+		Where("recorded_from <= ?", recordedAt).
+		Where("recorded_to IS NULL OR recorded_to > ?", recordedAt)
+}
+
 func (q *QueryImpl) SelectActiveByEhid(fields []string, ehid string) *gorm.DB {
 	return q.performSelect(fields).
 		Where("ehid = ?", ehid).
@@ -89,6 +118,47 @@ func (q *QueryImpl) SelectActiveByEhid(fields []string, ehid string) *gorm.DB {
 		Where("end_date IS NULL OR end_date > NOW()")
 }
 
+func (q *QueryImpl) SelectByEhidsOrderByStartDate(fields []string, ehids []string, orderDir string) *gorm.DB {
+	query := q.performSelect(fields).
+		Where("ehid IN ?", ehids)
+	if orderDir == OrderNone {
+		return query
+	}
+	return query.Order("ehid").Order("start_date " + orderDir)
+}
+
+func (q *QueryImpl) SelectActiveByEhids(fields []string, ehids []string) *gorm.DB {
+	return q.performSelect(fields).
+		Where("ehid IN ?", ehids).
+		Where("start_date < NOW()").
+		Where("end_date IS NULL OR end_date > NOW()")
+}
+
+func (q *QueryImpl) SelectByEhidsActiveOn(fields []string, ehids []string, date string) *gorm.DB {
+	return q.performSelect(fields).
+		Where("ehid IN ?", ehids).
+		Where("start_date <= ?", date).
+		Where("end_date IS NULL OR end_date >= ?", date)
+}
+
+func (q *QueryImpl) SelectEhidsActiveOn(date string) *gorm.DB {
+	return q.Db.
+		Table(q.TableName).
+		Distinct("ehid").
+		Where("start_date <= ?", date).
+		Where("end_date IS NULL OR end_date >= ?", date).
+		Order("ehid")
+}
+
+func (q *QueryImpl) SelectEhidsActiveBetween(startDate string, endDate string) *gorm.DB {
+	return q.Db.
+		Table(q.TableName).
+		Distinct("ehid").
+		Where("start_date <= ?", endDate).
+		Where("end_date IS NULL OR end_date >= ?", startDate).
+		Order("ehid")
+}
+
 func (q *QueryImpl) ByEhidAndIntersectingDates(ehid string, startDate string, endDate string) *gorm.DB {
 	return q.Db.
 		Table(q.TableName).
@@ -1,11 +1,13 @@
 package titling
 
 import (
+	"context"
 	"database/sql"
 	"time"
 
 	"github.com/mrexmelle/connect-emp/internal/config"
 	"github.com/mrexmelle/connect-emp/internal/localerror"
+	"github.com/mrexmelle/connect-emp/internal/txtime"
 )
 
 type Service struct {
@@ -23,7 +25,7 @@ func NewService(
 	}
 }
 
-func (s *Service) Create(req PostRequestDto) (*ViewEntity, error) {
+func (s *Service) Create(ctx context.Context, req PostRequestDto) (*ViewEntity, error) {
 	sd, err := time.Parse("2006-01-02", req.StartDate)
 	if err != nil {
 		return nil, err
@@ -45,7 +47,7 @@ func (s *Service) Create(req PostRequestDto) (*ViewEntity, error) {
 	}
 
 	if req.EndDate == "" {
-		cnt, err := s.TitlingRepository.CountEndDateIsNull(req.Ehid)
+		cnt, err := s.TitlingRepository.CountEndDateIsNull(ctx, req.Ehid)
 		if err != nil {
 			return nil, err
 		}
@@ -53,7 +55,7 @@ func (s *Service) Create(req PostRequestDto) (*ViewEntity, error) {
 			return nil, localerror.ErrConcurrentEvent
 		}
 	} else {
-		cnt, err := s.TitlingRepository.CountIntersectingDates(req.Ehid, req.StartDate, req.EndDate)
+		cnt, err := s.TitlingRepository.CountIntersectingDates(ctx, req.Ehid, req.StartDate, req.EndDate)
 		if err != nil {
 			return nil, err
 		}
@@ -62,7 +64,7 @@ func (s *Service) Create(req PostRequestDto) (*ViewEntity, error) {
 		}
 	}
 
-	result, err := s.TitlingRepository.Create(&Entity{
+	result, err := s.TitlingRepository.Create(ctx, &Entity{
 		Ehid:      req.Ehid,
 		StartDate: sd,
 		EndDate:   ed,
@@ -74,38 +76,126 @@ func (s *Service) Create(req PostRequestDto) (*ViewEntity, error) {
 	return toViewEntity(result), nil
 }
 
-func (s *Service) RetrieveById(id int) (*ViewEntity, error) {
-	result, err := s.TitlingRepository.FindById(id)
+func (s *Service) RetrieveById(ctx context.Context, id int) (*ViewEntity, error) {
+	result, err := s.TitlingRepository.FindById(ctx, id)
 	if err != nil {
 		return nil, err
 	}
 	return toViewEntity(result), nil
 }
 
-func (s *Service) UpdateById(fields map[string]interface{}, id int) error {
-	return s.TitlingRepository.UpdateById(fields, id)
+func (s *Service) UpdateById(ctx context.Context, fields map[string]interface{}, id int) error {
+	return s.TitlingRepository.UpdateById(ctx, fields, id)
 }
 
-func (s *Service) DeleteById(id int) error {
-	err := s.TitlingRepository.DeleteById(id)
+func (s *Service) DeleteById(ctx context.Context, id int) error {
+	err := s.TitlingRepository.DeleteById(ctx, id)
 	return err
 }
 
-func (s *Service) RetrieveByEhidOrderByStartDate(ehid string, orderDir string) ([]ViewEntity, error) {
+func (s *Service) RetrieveByEhidOrderByStartDate(ctx context.Context, ehid string, orderDir string) ([]ViewEntity, error) {
 	if orderDir != OrderAsc && orderDir != OrderDesc && orderDir != OrderNone {
 		return []ViewEntity{}, localerror.ErrBadQueryParam
 	}
-	result, err := s.TitlingRepository.FindByEhidOrderByStartDate(ehid, orderDir)
+	result, err := s.TitlingRepository.FindByEhidOrderByStartDate(ctx, ehid, orderDir)
 	if err != nil {
 		return []ViewEntity{}, err
 	}
 	return toViewEntities(result), nil
 }
 
-func (s *Service) RetrieveCurrentByEhid(ehid string) (*ViewEntity, error) {
-	result, err := s.TitlingRepository.FindCurrentByEhid(ehid)
+func (s *Service) RetrieveByEhidAsKnownAtOrderByStartDate(
+	ctx context.Context,
+	ehid string,
+	knownAt *txtime.Class,
+	orderDir string,
+) ([]ViewEntity, error) {
+	if knownAt.IsCurrent() {
+		return s.RetrieveByEhidOrderByStartDate(ctx, ehid, orderDir)
+	}
+	if orderDir != OrderAsc && orderDir != OrderDesc && orderDir != OrderNone {
+		return []ViewEntity{}, localerror.ErrBadQueryParam
+	}
+	result, err := s.TitlingRepository.FindByEhidRecordedAtOrderByStartDate(
+		ctx,
+		ehid,
+		knownAt.AsTime(),
+		orderDir,
+	)
+	if err != nil {
+		return []ViewEntity{}, err
+	}
+	return toViewEntities(result), nil
+}
+
+func (s *Service) RetrieveCurrentByEhid(ctx context.Context, ehid string) (*ViewEntity, error) {
+	result, err := s.TitlingRepository.FindCurrentByEhid(ctx, ehid)
 	if err != nil {
 		return nil, err
 	}
 	return toViewEntity(result), nil
 }
+
+// RetrieveByEhidsOrderByStartDate reads the histories of many EHIDs in one
+// query. EHIDs without any record are left out of the map.
+func (s *Service) RetrieveByEhidsOrderByStartDate(
+	ctx context.Context,
+	ehids []string,
+	orderDir string,
+) (map[string][]ViewEntity, error) {
+	if orderDir != OrderAsc && orderDir != OrderDesc && orderDir != OrderNone {
+		return nil, localerror.ErrBadQueryParam
+	}
+	result, err := s.TitlingRepository.FindByEhidsOrderByStartDate(ctx, ehids, orderDir)
+	if err != nil {
+		return nil, err
+	}
+	byEhid := map[string][]ViewEntity{}
+	for _, e := range toViewEntities(result) {
+		byEhid[e.Ehid] = append(byEhid[e.Ehid], e)
+	}
+	return byEhid, nil
+}
+
+// RetrieveCurrentByEhids reads the current records of many EHIDs in one
+// query. EHIDs without a current record are left out of the map.
+func (s *Service) RetrieveCurrentByEhids(ctx context.Context, ehids []string) (map[string]ViewEntity, error) {
+	result, err := s.TitlingRepository.FindCurrentByEhids(ctx, ehids)
+	if err != nil {
+		return nil, err
+	}
+	byEhid := map[string]ViewEntity{}
+	for _, e := range toViewEntities(result) {
+		byEhid[e.Ehid] = e
+	}
+	return byEhid, nil
+}
+
+// RetrieveByEhidsActiveOn reads the records of many EHIDs that hold on date
+// in one query. EHIDs without such a record are left out of the map.
+func (s *Service) RetrieveByEhidsActiveOn(
+	ctx context.Context,
+	ehids []string,
+	date string,
+) (map[string]ViewEntity, error) {
+	result, err := s.TitlingRepository.FindByEhidsActiveOn(ctx, ehids, date)
+	if err != nil {
+		return nil, err
+	}
+	byEhid := map[string]ViewEntity{}
+	for _, e := range toViewEntities(result) {
+		byEhid[e.Ehid] = e
+	}
+	return byEhid, nil
+}
+
+// RetrieveEhidsActiveOn lists the EHIDs holding a record on date.
+func (s *Service) RetrieveEhidsActiveOn(ctx context.Context, date string) ([]string, error) {
+	return s.TitlingRepository.FindEhidsActiveOn(ctx, date)
+}
+
+// RetrieveEhidsActiveBetween lists the EHIDs holding a record on any day
+// from startDate to endDate.
+func (s *Service) RetrieveEhidsActiveBetween(ctx context.Context, startDate string, endDate string) ([]string, error) {
+	return s.TitlingRepository.FindEhidsActiveBetween(ctx, startDate, endDate)
+}
@@ -0,0 +1,93 @@
+package tracing
+
+import (
+	"errors"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+const gormSpanKey = "tracing:span"
+
+// GormPlugin opens a client span around every statement run through a
+// *gorm.DB. Statements without a sampled parent span, such as the polling of
+// the background workers, are left untraced.
+type GormPlugin struct {
+	DbName string
+}
+
+func NewGormPlugin(dbName string) *GormPlugin {
+	return &GormPlugin{
+		DbName: dbName,
+	}
+}
+
+func (p *GormPlugin) Name() string {
+	return "tracing:" + p.DbName
+}
+
+func (p *GormPlugin) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	errs := []error{
+		cb.Create().Before("gorm:create").Register(p.Name()+":before_create", p.before("create")),
+		cb.Create().After("gorm:create").Register(p.Name()+":after_create", p.after),
+		cb.Query().Before("gorm:query").Register(p.Name()+":before_query", p.before("query")),
+		cb.Query().After("gorm:query").Register(p.Name()+":after_query", p.after),
+		cb.Update().Before("gorm:update").Register(p.Name()+":before_update", p.before("update")),
+		cb.Update().After("gorm:update").Register(p.Name()+":after_update", p.after),
+		cb.Delete().Before("gorm:delete").Register(p.Name()+":before_delete", p.before("delete")),
+		cb.Delete().After("gorm:delete").Register(p.Name()+":after_delete", p.after),
+		cb.Row().Before("gorm:row").Register(p.Name()+":before_row", p.before("row")),
+		cb.Row().After("gorm:row").Register(p.Name()+":after_row", p.after),
+		cb.Raw().Before("gorm:raw").Register(p.Name()+":before_raw", p.before("raw")),
+		cb.Raw().After("gorm:raw").Register(p.Name()+":after_raw", p.after),
+	}
+	return errors.Join(errs...)
+}
+
+func (p *GormPlugin) before(operation string) func(db *gorm.DB) {
+	return func(db *gorm.DB) {
+		ctx := db.Statement.Context
+		if ctx == nil || !trace.SpanFromContext(ctx).SpanContext().IsSampled() {
+			return
+		}
+
+		name := "db." + operation
+		if db.Statement.Table != "" {
+			name += " " + db.Statement.Table
+		}
+		_, span := Tracer().Start(
+			ctx,
+			name,
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(
+				semconv.DBSystemPostgreSQL,
+				attribute.String("db.instance", p.DbName),
+				semconv.DBOperation(operation),
+				semconv.DBSQLTable(db.Statement.Table),
+			),
+		)
+		db.InstanceSet(gormSpanKey, span)
+	}
+}
+
+func (p *GormPlugin) after(db *gorm.DB) {
+	value, ok := db.InstanceGet(gormSpanKey)
+	if !ok {
+		return
+	}
+	span := value.(trace.Span)
+	defer span.End()
+
+	span.SetAttributes(
+		semconv.DBStatement(db.Statement.SQL.String()),
+		attribute.Int64("db.rows_affected", db.RowsAffected),
+	)
+	if db.Error != nil && !errors.Is(db.Error, gorm.ErrRecordNotFound) {
+		span.RecordError(db.Error)
+		span.SetStatus(codes.Error, db.Error.Error())
+	}
+}
@@ -0,0 +1,106 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mrexmelle/connect-emp/internal/config"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+const (
+	ServiceName = "connect-emp"
+
+	ExporterNone   = "none"
+	ExporterStdout = "stdout"
+	ExporterOtlp   = "otlp"
+)
+
+// Provider owns the tracer provider installed as the global one, so that
+// instrumentation libraries pick it up. Creating it also instruments both
+// databases of the config service.
+type Provider struct {
+	TracerProvider *sdktrace.TracerProvider
+}
+
+func NewProvider(cfg *config.Service) (*Provider, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	for name, db := range map[string]*gorm.DB{"read": cfg.ReadDb, "write": cfg.WriteDb} {
+		err := db.Use(NewGormPlugin(name))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	cr := cfg.ConfigRepository
+	var exporter sdktrace.SpanExporter
+	var err error
+	switch cr.GetTracingExporter() {
+	case ExporterNone, "":
+		return &Provider{}, nil
+	case ExporterStdout:
+		exporter, err = stdouttrace.New(stdouttrace.WithPrettyPrint())
+	case ExporterOtlp:
+		opts := []otlptracehttp.Option{
+			otlptracehttp.WithEndpoint(cr.GetTracingEndpoint()),
+		}
+		if cr.GetTracingInsecure() {
+			opts = append(opts, otlptracehttp.WithInsecure())
+		}
+		exporter, err = otlptracehttp.New(context.Background(), opts...)
+	default:
+		return nil, fmt.Errorf("unknown tracing exporter %q", cr.GetTracingExporter())
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := resource.Merge(
+		resource.Default(),
+		resource.NewWithAttributes(
+			semconv.SchemaURL,
+			semconv.ServiceName(ServiceName),
+			semconv.DeploymentEnvironment(cr.GetProfile()),
+		),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(
+			sdktrace.TraceIDRatioBased(cr.GetTracingSampleRatio()),
+		)),
+	)
+	otel.SetTracerProvider(tp)
+
+	return &Provider{
+		TracerProvider: tp,
+	}, nil
+}
+
+// Shutdown flushes pending spans.
+func (p *Provider) Shutdown(ctx context.Context) error {
+	if p.TracerProvider == nil {
+		return nil
+	}
+	return p.TracerProvider.Shutdown(ctx)
+}
+
+func Tracer() trace.Tracer {
+	return otel.Tracer("github.com/mrexmelle/connect-emp")
+}
@@ -0,0 +1,68 @@
+package txtime
+
+import (
+	"time"
+)
+
+const (
+	FormatDate    = "2006-01-02"
+	FormatInstant = time.RFC3339
+	Current       = ""
+)
+
+type Class struct {
+	instant string
+	t       time.Time
+}
+
+func NewCurrent() *Class {
+	return &Class{
+		instant: Current,
+	}
+}
+
+func NewFromString(s string) (*Class, error) {
+	if s == Current {
+		return NewCurrent(), nil
+	}
+
+	t, err := time.Parse(FormatInstant, s)
+	if err == nil {
+		return &Class{
+			instant: s,
+			t:       t,
+		}, nil
+	}
+
+	d, err := time.ParseInLocation(FormatDate, s, time.UTC)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Class{
+		instant: s,
+		t:       d.AddDate(0, 0, 1).Add(-time.Microsecond),
+	}, nil
+}
+
+func NewFromTime(t time.Time) *Class {
+	return &Class{
+		instant: t.Format(FormatInstant),
+		t:       t,
+	}
+}
+
+func (c *Class) AsString() string {
+	return c.instant
+}
+
+func (c *Class) AsTime() time.Time {
+	if c.IsCurrent() {
+		return time.Now()
+	}
+	return c.t
+}
+
+func (c *Class) IsCurrent() bool {
+	return (c.instant == Current)
+}
@@ -0,0 +1,116 @@
+package txtime
+
+import (
+	"testing"
+	"time"
+)
+
+type IsCreatedTestCase struct {
+	name      string
+	s         string
+	isCreated bool
+}
+
+type AsTimeTestCase struct {
+	name     string
+	s        string
+	expected time.Time
+}
+
+func TestIsCreated(t *testing.T) {
+	tc := []IsCreatedTestCase{
+		{
+			name:      "Valid instant",
+			s:         "2024-04-15T10:30:00+07:00",
+			isCreated: true,
+		},
+		{
+			name:      "Valid date",
+			s:         "2024-04-15",
+			isCreated: true,
+		},
+		{
+			name:      "Empty string",
+			s:         "",
+			isCreated: true,
+		},
+		{
+			name:      "Invalid date",
+			s:         "2024-02-30",
+			isCreated: false,
+		},
+		{
+			name:      "Instant without zone",
+			s:         "2024-04-15T10:30:00",
+			isCreated: false,
+		},
+	}
+
+	for _, c := range tc {
+		_, err := NewFromString(c.s)
+		created := (err == nil)
+		if created != c.isCreated {
+			t.Errorf("[%s]\nresult: %t\nexpected: %t\n",
+				c.name,
+				created,
+				c.isCreated,
+			)
+		}
+	}
+}
+
+func TestAsTime(t *testing.T) {
+	tc := []AsTimeTestCase{
+		{
+			name:     "Instant is kept as is",
+			s:        "2024-04-15T10:30:00Z",
+			expected: time.Date(2024, 4, 15, 10, 30, 0, 0, time.UTC),
+		},
+		{
+			name:     "Date covers the whole day",
+			s:        "2024-04-15",
+			expected: time.Date(2024, 4, 15, 23, 59, 59, 999999000, time.UTC),
+		},
+		{
+			name:     "Date at the end of a month",
+			s:        "2024-02-29",
+			expected: time.Date(2024, 2, 29, 23, 59, 59, 999999000, time.UTC),
+		},
+	}
+
+	for _, c := range tc {
+		obj, _ := NewFromString(c.s)
+		out := obj.AsTime()
+		if !out.Equal(c.expected) {
+			t.Errorf("[%s]\nresult: %v\nexpected: %v\n",
+				c.name,
+				out,
+				c.expected,
+			)
+		}
+	}
+}
+
+func TestAsTimeIgnoresLocalZone(t *testing.T) {
+	local := time.Local
+	time.Local = time.FixedZone("UTC+7", 7*60*60)
+	defer func() { time.Local = local }()
+
+	obj, _ := NewFromString("2024-04-15")
+	expected := time.Date(2024, 4, 15, 23, 59, 59, 999999000, time.UTC)
+	if !obj.AsTime().Equal(expected) {
+		t.Errorf("[Date in UTC]\nresult: %v\nexpected: %v\n", obj.AsTime(), expected)
+	}
+}
+
+func TestIsCurrent(t *testing.T) {
+	obj, _ := NewFromString("")
+	if !obj.IsCurrent() {
+		t.Errorf("[Empty string]\nresult: %t\nexpected: %t\n", false, true)
+	}
+
+	obj, _ = NewFromString("2024-04-15")
+	if obj.IsCurrent() {
+		t.Errorf("[Date]\nresult: %t\nexpected: %t\n", true, false)
+	}
+}
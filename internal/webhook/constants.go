@@ -0,0 +1,15 @@
+package webhook
+
+var (
+	DeliveryStatusPending   = "pending"
+	DeliveryStatusDelivered = "delivered"
+	DeliveryStatusDead      = "dead"
+
+	EventTypeSeparator = ","
+	EventTypeWildcard  = "*"
+
+	HeaderDeliveryId = "X-Connect-Delivery-Id"
+	HeaderEventId    = "X-Connect-Event-Id"
+	HeaderEventType  = "X-Connect-Event-Type"
+	HeaderSignature  = "X-Connect-Signature"
+)
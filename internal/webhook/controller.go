@@ -0,0 +1,237 @@
+package webhook
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi"
+	"github.com/mrexmelle/connect-emp/internal/config"
+	"github.com/mrexmelle/connect-emp/internal/dto/dtorespwithdata"
+	"github.com/mrexmelle/connect-emp/internal/dto/dtorespwithoutdata"
+	"github.com/mrexmelle/connect-emp/internal/localerror"
+)
+
+type Controller struct {
+	ConfigService     *config.Service
+	WebhookService    *Service
+	LocalErrorService *localerror.Service
+}
+
+func NewController(cfg *config.Service, svc *Service, les *localerror.Service) *Controller {
+	return &Controller{
+		ConfigService:     cfg,
+		WebhookService:    svc,
+		LocalErrorService: les,
+	}
+}
+
+// Get Webhooks : HTTP endpoint to get all webhooks
+// @Tags Webhooks
+// @Description Get all webhook subscriptions
+// @Produce json
+// @Success 200 {object} GetAllResponseDto "Success Response"
+// @Failure 500 "InternalServerError"
+// @Router /webhooks [GET]
+func (c *Controller) GetAll(w http.ResponseWriter, r *http.Request) {
+	data, err := c.WebhookService.RetrieveAll()
+	info := c.LocalErrorService.Map(err)
+	dtorespwithdata.New(
+		&data,
+		info.ServiceErrorCode,
+		info.ServiceErrorMessage,
+	).RenderTo(w, info.HttpStatusCode)
+}
+
+// Get Webhooks : HTTP endpoint to get a webhook
+// @Tags Webhooks
+// @Description Get a webhook subscription
+// @Produce json
+// @Param id path string true "Webhook ID"
+// @Success 200 {object} GetResponseDto "Success Response"
+// @Failure 400 "BadRequest"
+// @Failure 500 "InternalServerError"
+// @Router /webhooks/{id} [GET]
+func (c *Controller) Get(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		dtorespwithdata.NewError(
+			localerror.ErrIdNotInteger.Error(),
+			err.Error(),
+		).RenderTo(w, http.StatusBadRequest)
+		return
+	}
+	data, err := c.WebhookService.RetrieveById(id)
+	info := c.LocalErrorService.Map(err)
+	dtorespwithdata.New(
+		data,
+		info.ServiceErrorCode,
+		info.ServiceErrorMessage,
+	).RenderTo(w, info.HttpStatusCode)
+}
+
+// Post Webhooks : HTTP endpoint to post new webhooks
+// @Tags Webhooks
+// @Description Register a webhook subscription. The signing secret is only returned here.
+// @Accept json
+// @Produce json
+// @Param data body PostRequestDto true "Webhook Request"
+// @Success 200 {object} PostResponseDto "Success Response"
+// @Failure 400 "BadRequest"
+// @Failure 500 "InternalServerError"
+// @Router /webhooks [POST]
+func (c *Controller) Post(w http.ResponseWriter, r *http.Request) {
+	var requestBody PostRequestDto
+	err := json.NewDecoder(r.Body).Decode(&requestBody)
+	if err != nil {
+		dtorespwithdata.NewError(
+			localerror.ErrBadJson.Error(),
+			err.Error(),
+		).RenderTo(w, http.StatusBadRequest)
+		return
+	}
+
+	data, err := c.WebhookService.Create(requestBody)
+	info := c.LocalErrorService.Map(err)
+	dtorespwithdata.New(
+		data,
+		info.ServiceErrorCode,
+		info.ServiceErrorMessage,
+	).RenderTo(w, info.HttpStatusCode)
+}
+
+// Patch Webhooks : HTTP endpoint to patch a webhook
+// @Tags Webhooks
+// @Description Patch a webhook subscription
+// @Accept json
+// @Produce json
+// @Param id path string true "Webhook ID"
+// @Param data body PatchRequestDto true "Webhook Patch Request"
+// @Success 200 {object} PatchResponseDto "Success Response"
+// @Failure 400 "BadRequest"
+// @Failure 500 "InternalServerError"
+// @Router /webhooks/{id} [PATCH]
+func (c *Controller) Patch(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		dtorespwithoutdata.New(
+			localerror.ErrIdNotInteger.Error(),
+			err.Error(),
+		).RenderTo(w, http.StatusBadRequest)
+		return
+	}
+
+	var requestBody PatchRequestDto
+	err = json.NewDecoder(r.Body).Decode(&requestBody)
+	if err != nil {
+		dtorespwithoutdata.New(
+			localerror.ErrBadJson.Error(),
+			err.Error(),
+		).RenderTo(w, http.StatusBadRequest)
+		return
+	}
+
+	err = c.WebhookService.UpdateById(requestBody.Fields, id)
+	info := c.LocalErrorService.Map(err)
+	dtorespwithoutdata.New(
+		info.ServiceErrorCode,
+		info.ServiceErrorMessage,
+	).RenderTo(w, info.HttpStatusCode)
+}
+
+// Delete Webhooks : HTTP endpoint to delete webhooks
+// @Tags Webhooks
+// @Description Delete a webhook subscription along with its deliveries
+// @Produce json
+// @Param id path string true "Webhook ID"
+// @Success 200 {object} DeleteResponseDto "Success Response"
+// @Failure 400 "BadRequest"
+// @Failure 500 "InternalServerError"
+// @Router /webhooks/{id} [DELETE]
+func (c *Controller) Delete(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		dtorespwithoutdata.New(
+			localerror.ErrIdNotInteger.Error(),
+			err.Error(),
+		).RenderTo(w, http.StatusBadRequest)
+		return
+	}
+
+	err = c.WebhookService.DeleteById(id)
+	info := c.LocalErrorService.Map(err)
+	dtorespwithoutdata.New(
+		info.ServiceErrorCode,
+		info.ServiceErrorMessage,
+	).RenderTo(w, info.HttpStatusCode)
+}
+
+// Get Deliveries of Webhooks : HTTP endpoint to get the deliveries of a webhook
+// @Tags Webhooks
+// @Description Get deliveries of a webhook subscription, newest first
+// @Produce json
+// @Param id path string true "Webhook ID"
+// @Param status query string false "Delivery status (pending, delivered or dead)"
+// @Success 200 {object} GetDeliveriesResponseDto "Success Response"
+// @Failure 400 "BadRequest"
+// @Failure 500 "InternalServerError"
+// @Router /webhooks/{id}/deliveries [GET]
+func (c *Controller) GetDeliveries(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		dtorespwithdata.NewError(
+			localerror.ErrIdNotInteger.Error(),
+			err.Error(),
+		).RenderTo(w, http.StatusBadRequest)
+		return
+	}
+
+	data, err := c.WebhookService.RetrieveDeliveriesByWebhookIdAndStatus(
+		id,
+		r.URL.Query().Get("status"),
+	)
+	info := c.LocalErrorService.Map(err)
+	dtorespwithdata.New(
+		&data,
+		info.ServiceErrorCode,
+		info.ServiceErrorMessage,
+	).RenderTo(w, info.HttpStatusCode)
+}
+
+// Get Attempts of Webhook Deliveries : HTTP endpoint to get the attempt log of a delivery
+// @Tags Webhooks
+// @Description Get every attempt made for a webhook delivery
+// @Produce json
+// @Param id path string true "Webhook ID"
+// @Param deliveryId path string true "Delivery ID"
+// @Success 200 {object} GetAttemptsResponseDto "Success Response"
+// @Failure 400 "BadRequest"
+// @Failure 500 "InternalServerError"
+// @Router /webhooks/{id}/deliveries/{deliveryId}/attempts [GET]
+func (c *Controller) GetAttempts(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		dtorespwithdata.NewError(
+			localerror.ErrIdNotInteger.Error(),
+			err.Error(),
+		).RenderTo(w, http.StatusBadRequest)
+		return
+	}
+
+	deliveryId, err := strconv.ParseInt(chi.URLParam(r, "deliveryId"), 10, 64)
+	if err != nil {
+		dtorespwithdata.NewError(
+			localerror.ErrIdNotInteger.Error(),
+			err.Error(),
+		).RenderTo(w, http.StatusBadRequest)
+		return
+	}
+
+	data, err := c.WebhookService.RetrieveAttemptsByDeliveryId(id, deliveryId)
+	info := c.LocalErrorService.Map(err)
+	dtorespwithdata.New(
+		&data,
+		info.ServiceErrorCode,
+		info.ServiceErrorMessage,
+	).RenderTo(w, info.HttpStatusCode)
+}
@@ -0,0 +1,192 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/mrexmelle/connect-emp/internal/config"
+	"github.com/mrexmelle/connect-emp/internal/outbox"
+	"gorm.io/gorm"
+)
+
+// Dispatcher sends due deliveries to their subscribers. A failed delivery is
+// retried with exponential backoff until MaxAttempts is reached, after which
+// it is dead-lettered.
+type Dispatcher struct {
+	WebhookRepository Repository
+	Client            *http.Client
+	Interval          time.Duration
+	BatchSize         int
+	MaxAttempts       int
+	Lease             time.Duration
+}
+
+func NewDispatcher(cfg *config.Service, r Repository) *Dispatcher {
+	return &Dispatcher{
+		WebhookRepository: r,
+		Client: &http.Client{
+			Timeout: cfg.ConfigRepository.GetWebhookTimeout(),
+		},
+		Interval:    cfg.ConfigRepository.GetWebhookInterval(),
+		BatchSize:   cfg.ConfigRepository.GetWebhookBatchSize(),
+		MaxAttempts: cfg.ConfigRepository.GetWebhookMaxAttempts(),
+		Lease:       outbox.Lease,
+	}
+}
+
+func (d *Dispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.Interval)
+	defer ticker.Stop()
+
+	for {
+		for {
+			n, err := d.DispatchBatch(ctx)
+			if err != nil {
+				log.Printf("webhook: %v", err)
+			}
+			if err != nil || n < d.BatchSize || ctx.Err() != nil {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// DispatchBatch claims a batch of due deliveries and sends them outside of
+// any transaction, then records the attempts in one short transaction.
+// Sending stops when the lease runs out; the deliveries left over are
+// claimed again once it has. It also stops when ctx is canceled, in which
+// case a send cut short is not recorded as an attempt and the deliveries
+// left over are released at once.
+func (d *Dispatcher) DispatchBatch(ctx context.Context) (int, error) {
+	claimedAt := time.Now()
+	deliveries, err := d.WebhookRepository.ClaimDueDeliveries(d.BatchSize, d.Lease)
+	if err != nil {
+		return 0, err
+	}
+
+	outcomes := []sendOutcome{}
+	for i := range deliveries {
+		if ctx.Err() != nil || time.Since(claimedAt) >= d.Lease {
+			break
+		}
+		startedAt := time.Now()
+		statusCode, sendErr := d.send(ctx, &deliveries[i])
+		if sendErr != nil && ctx.Err() != nil {
+			break
+		}
+		outcomes = append(outcomes, sendOutcome{
+			startedAt:  startedAt,
+			duration:   time.Since(startedAt),
+			statusCode: statusCode,
+			err:        sendErr,
+		})
+	}
+	released := []int64{}
+	if ctx.Err() != nil {
+		for _, delivery := range deliveries[len(outcomes):] {
+			released = append(released, delivery.Id)
+		}
+	}
+	if len(outcomes) == 0 && len(released) == 0 {
+		return len(deliveries), nil
+	}
+
+	err = d.WebhookRepository.Transaction(func(tx *gorm.DB) error {
+		if len(released) > 0 {
+			err := d.WebhookRepository.Release(tx, released)
+			if err != nil {
+				return err
+			}
+		}
+		for i, outcome := range outcomes {
+			err := d.record(tx, &deliveries[i], outcome)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return len(deliveries), err
+}
+
+// sendOutcome is how sending a delivery went.
+type sendOutcome struct {
+	startedAt  time.Time
+	duration   time.Duration
+	statusCode int
+	err        error
+}
+
+func (d *Dispatcher) record(tx *gorm.DB, delivery *DueDeliveryEntity, outcome sendOutcome) error {
+	attempt := &AttemptEntity{
+		DeliveryId:  delivery.Id,
+		AttemptedAt: outcome.startedAt,
+		DurationMs:  outcome.duration.Milliseconds(),
+	}
+	if outcome.statusCode != 0 {
+		attempt.StatusCode = sql.NullInt32{Int32: int32(outcome.statusCode), Valid: true}
+	}
+	if outcome.err != nil {
+		attempt.Error = sql.NullString{String: outcome.err.Error(), Valid: true}
+	}
+	err := d.WebhookRepository.RecordAttempt(tx, attempt)
+	if err != nil {
+		return err
+	}
+
+	if outcome.err == nil {
+		return d.WebhookRepository.MarkDelivered(tx, delivery.Id, outcome.statusCode)
+	}
+
+	attempts := delivery.Attempts + 1
+	return d.WebhookRepository.MarkFailed(
+		tx,
+		delivery.Id,
+		outcome.statusCode,
+		outcome.err.Error(),
+		time.Now().Add(outbox.Backoff(attempts)),
+		attempts >= d.MaxAttempts,
+	)
+}
+
+func (d *Dispatcher) send(ctx context.Context, delivery *DueDeliveryEntity) (int, error) {
+	req, err := http.NewRequestWithContext(
+		ctx,
+		http.MethodPost,
+		delivery.Url,
+		bytes.NewReader(delivery.Payload),
+	)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderDeliveryId, strconv.FormatInt(delivery.Id, 10))
+	req.Header.Set(HeaderEventId, strconv.FormatInt(delivery.EventId, 10))
+	req.Header.Set(HeaderEventType, delivery.EventType)
+	req.Header.Set(HeaderSignature, Sign(delivery.Secret, time.Now().Unix(), delivery.Payload))
+
+	response, err := d.Client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer response.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(response.Body, 64*1024))
+
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		return response.StatusCode, fmt.Errorf("subscriber responded with status %d", response.StatusCode)
+	}
+	return response.StatusCode, nil
+}
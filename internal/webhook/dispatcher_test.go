@@ -0,0 +1,307 @@
+package webhook
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mrexmelle/connect-emp/internal/outbox"
+	"gorm.io/gorm"
+)
+
+type memoryRepository struct {
+	webhooks   []Entity
+	deliveries []DeliveryEntity
+	attempts   []AttemptEntity
+}
+
+func (r *memoryRepository) Create(req *Entity) (*Entity, error) {
+	req.Id = len(r.webhooks) + 1
+	r.webhooks = append(r.webhooks, *req)
+	return req, nil
+}
+
+func (r *memoryRepository) FindById(id int) (*Entity, error) {
+	for i := range r.webhooks {
+		if r.webhooks[i].Id == id {
+			return &r.webhooks[i], nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *memoryRepository) FindAll() ([]Entity, error) {
+	return r.webhooks, nil
+}
+
+func (r *memoryRepository) FindActive() ([]Entity, error) {
+	active := []Entity{}
+	for _, w := range r.webhooks {
+		if w.Active {
+			active = append(active, w)
+		}
+	}
+	return active, nil
+}
+
+func (r *memoryRepository) UpdateById(fields map[string]interface{}, id int) error {
+	return nil
+}
+
+func (r *memoryRepository) DeleteById(id int) error {
+	return nil
+}
+
+func (r *memoryRepository) CreateDeliveries(deliveries []DeliveryEntity) error {
+	for _, d := range deliveries {
+		exists := false
+		for _, existing := range r.deliveries {
+			if existing.WebhookId == d.WebhookId && existing.EventId == d.EventId {
+				exists = true
+			}
+		}
+		if !exists {
+			d.Id = int64(len(r.deliveries) + 1)
+			d.Status = DeliveryStatusPending
+			r.deliveries = append(r.deliveries, d)
+		}
+	}
+	return nil
+}
+
+func (r *memoryRepository) FindDeliveriesByWebhookIdAndStatus(webhookId int, status string) ([]DeliveryEntity, error) {
+	return r.deliveries, nil
+}
+
+func (r *memoryRepository) FindDeliveryById(id int64) (*DeliveryEntity, error) {
+	return &r.deliveries[id-1], nil
+}
+
+func (r *memoryRepository) FindAttemptsByDeliveryId(deliveryId int64) ([]AttemptEntity, error) {
+	attempts := []AttemptEntity{}
+	for _, a := range r.attempts {
+		if a.DeliveryId == deliveryId {
+			attempts = append(attempts, a)
+		}
+	}
+	return attempts, nil
+}
+
+func (r *memoryRepository) ClaimDueDeliveries(limit int, lease time.Duration) ([]DueDeliveryEntity, error) {
+	due := []DueDeliveryEntity{}
+	for _, d := range r.deliveries {
+		if d.Status != DeliveryStatusPending || d.NextAttemptAt.After(time.Now()) {
+			continue
+		}
+		w, _ := r.FindById(d.WebhookId)
+		due = append(due, DueDeliveryEntity{DeliveryEntity: d, Url: w.Url, Secret: w.Secret})
+	}
+	return due, nil
+}
+
+func (r *memoryRepository) Transaction(fn func(tx *gorm.DB) error) error {
+	return fn(nil)
+}
+
+func (r *memoryRepository) RecordAttempt(tx *gorm.DB, attempt *AttemptEntity) error {
+	attempt.Id = int64(len(r.attempts) + 1)
+	r.attempts = append(r.attempts, *attempt)
+	return nil
+}
+
+func (r *memoryRepository) MarkDelivered(tx *gorm.DB, id int64, statusCode int) error {
+	d := &r.deliveries[id-1]
+	d.Status = DeliveryStatusDelivered
+	d.Attempts++
+	d.LastStatusCode = sql.NullInt32{Int32: int32(statusCode), Valid: true}
+	return nil
+}
+
+func (r *memoryRepository) MarkFailed(tx *gorm.DB, id int64, statusCode int, reason string, nextAttemptAt time.Time, isDead bool) error {
+	d := &r.deliveries[id-1]
+	d.Attempts++
+	d.NextAttemptAt = nextAttemptAt
+	d.LastError = sql.NullString{String: reason, Valid: true}
+	if isDead {
+		d.Status = DeliveryStatusDead
+	}
+	return nil
+}
+
+func (r *memoryRepository) Release(tx *gorm.DB, ids []int64) error {
+	return nil
+}
+
+// fastForward makes every pending delivery due again, standing in for the
+// backoff delay.
+func (r *memoryRepository) fastForward() {
+	for i := range r.deliveries {
+		r.deliveries[i].NextAttemptAt = time.Time{}
+	}
+}
+
+type receiver struct {
+	mu        sync.Mutex
+	failUntil int
+	calls     int
+	verified  int
+	eventIds  []string
+}
+
+func (rc *receiver) handler(secret string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		rc.mu.Lock()
+		defer rc.mu.Unlock()
+		rc.calls++
+
+		body, _ := io.ReadAll(r.Body)
+		err := Verify(secret, r.Header.Get(HeaderSignature), body, time.Now(), time.Minute)
+		if err == nil {
+			rc.verified++
+		}
+
+		rc.eventIds = append(rc.eventIds, r.Header.Get(HeaderEventId))
+
+		if rc.calls <= rc.failUntil {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+func newHarness(t *testing.T, failUntil int, maxAttempts int) (*memoryRepository, *Service, *Dispatcher, *receiver) {
+	rc := &receiver{failUntil: failUntil}
+	server := httptest.NewServer(rc.handler("s3cret"))
+	t.Cleanup(server.Close)
+
+	repo := &memoryRepository{}
+	svc := &Service{WebhookRepository: repo}
+	repo.Create(&Entity{Url: server.URL, Secret: "s3cret", EventTypes: "grading.*", Active: true})
+	repo.Create(&Entity{Url: server.URL, Secret: "s3cret", EventTypes: "titling.*", Active: true})
+
+	dispatcher := &Dispatcher{
+		WebhookRepository: repo,
+		Client:            server.Client(),
+		BatchSize:         10,
+		MaxAttempts:       maxAttempts,
+		Lease:             time.Minute,
+	}
+	return repo, svc, dispatcher, rc
+}
+
+func TestDispatchDelivers(t *testing.T) {
+	repo, svc, dispatcher, rc := newHarness(t, 0, 3)
+
+	sink := NewSink(svc)
+	event := &outbox.ViewEntity{Id: 42, Type: "grading.created", Data: json.RawMessage(`{}`)}
+	sink.Deliver(context.Background(), event)
+	sink.Deliver(context.Background(), event)
+
+	n, err := dispatcher.DispatchBatch(context.Background())
+	if err != nil || n != 1 {
+		t.Fatalf("[Dispatch]\nresult: %d, %v\nexpected: %d, %v\n", n, err, 1, nil)
+	}
+	if rc.calls != 1 || rc.verified != 1 || rc.eventIds[0] != "42" {
+		t.Errorf("[Receiver]\nresult: %d calls, %d verified, %v\nexpected: one signed call for event 42\n",
+			rc.calls,
+			rc.verified,
+			rc.eventIds,
+		)
+	}
+	if repo.deliveries[0].Status != DeliveryStatusDelivered {
+		t.Errorf("[Status]\nresult: %s\nexpected: %s\n", repo.deliveries[0].Status, DeliveryStatusDelivered)
+	}
+}
+
+func TestDispatchRetriesThenDelivers(t *testing.T) {
+	repo, svc, dispatcher, rc := newHarness(t, 2, 5)
+	svc.Enqueue(&outbox.ViewEntity{Id: 7, Type: "titling.ended", Data: json.RawMessage(`{}`)})
+
+	for i := 0; i < 3; i++ {
+		dispatcher.DispatchBatch(context.Background())
+		if i < 2 && !repo.deliveries[0].NextAttemptAt.After(time.Now()) {
+			t.Errorf("[Backoff %d]\nresult: %v\nexpected: a retry in the future\n", i, repo.deliveries[0].NextAttemptAt)
+		}
+		repo.fastForward()
+	}
+
+	d := repo.deliveries[0]
+	if d.Status != DeliveryStatusDelivered || d.Attempts != 3 || rc.calls != 3 {
+		t.Errorf("[Retries]\nresult: %s after %d attempts, %d calls\nexpected: %s after 3 attempts, 3 calls\n",
+			d.Status,
+			d.Attempts,
+			rc.calls,
+			DeliveryStatusDelivered,
+		)
+	}
+
+	attempts, _ := repo.FindAttemptsByDeliveryId(d.Id)
+	if len(attempts) != 3 || attempts[0].StatusCode.Int32 != http.StatusServiceUnavailable || attempts[2].StatusCode.Int32 != http.StatusOK {
+		t.Errorf("[Attempt log]\nresult: %+v\nexpected: two failures followed by a success\n", attempts)
+	}
+}
+
+func TestDispatchDeadLetters(t *testing.T) {
+	repo, svc, dispatcher, rc := newHarness(t, 100, 3)
+	svc.Enqueue(&outbox.ViewEntity{Id: 9, Type: "grading.deleted", Data: json.RawMessage(`{}`)})
+
+	for i := 0; i < 5; i++ {
+		dispatcher.DispatchBatch(context.Background())
+		repo.fastForward()
+	}
+
+	d := repo.deliveries[0]
+	if d.Status != DeliveryStatusDead || d.Attempts != 3 || rc.calls != 3 {
+		t.Errorf("[Dead letter]\nresult: %s after %d attempts, %d calls\nexpected: %s after 3 attempts, 3 calls\n",
+			d.Status,
+			d.Attempts,
+			rc.calls,
+			DeliveryStatusDead,
+		)
+	}
+}
+
+func TestDispatchStopsWhenLeaseRunsOut(t *testing.T) {
+	repo, svc, dispatcher, rc := newHarness(t, 0, 3)
+	svc.Enqueue(&outbox.ViewEntity{Id: 11, Type: "grading.created", Data: json.RawMessage(`{}`)})
+	dispatcher.Lease = time.Nanosecond
+
+	n, err := dispatcher.DispatchBatch(context.Background())
+	if err != nil || n != 1 {
+		t.Fatalf("[Dispatch]\nresult: %d, %v\nexpected: %d, %v\n", n, err, 1, nil)
+	}
+	if d := repo.deliveries[0]; rc.calls != 0 || d.Status != DeliveryStatusPending || d.Attempts != 0 {
+		t.Errorf("[Lease]\nresult: %d calls, %s after %d attempts\nexpected: the delivery left to the next claim\n",
+			rc.calls,
+			d.Status,
+			d.Attempts,
+		)
+	}
+}
+
+func TestDispatchLeavesCanceledSendsUnrecorded(t *testing.T) {
+	repo, svc, dispatcher, rc := newHarness(t, 0, 3)
+	svc.Enqueue(&outbox.ViewEntity{Id: 12, Type: "grading.created", Data: json.RawMessage(`{}`)})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := dispatcher.DispatchBatch(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	attempts, _ := repo.FindAttemptsByDeliveryId(repo.deliveries[0].Id)
+	if d := repo.deliveries[0]; rc.calls != 0 || d.Attempts != 0 || len(attempts) != 0 {
+		t.Errorf("[Shutdown]\nresult: %d calls, %d attempts, %d logged\nexpected: the delivery left untouched\n",
+			rc.calls,
+			d.Attempts,
+			len(attempts),
+		)
+	}
+}
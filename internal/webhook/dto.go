@@ -0,0 +1,24 @@
+package webhook
+
+import (
+	"github.com/mrexmelle/connect-emp/internal/dto/dtorespwithdata"
+	"github.com/mrexmelle/connect-emp/internal/dto/dtorespwithoutdata"
+)
+
+type PostRequestDto struct {
+	Url        string   `json:"url"`
+	Secret     string   `json:"secret"`
+	EventTypes []string `json:"event_types"`
+}
+
+type PatchRequestDto struct {
+	Fields map[string]interface{} `json:"fields"`
+}
+
+type GetResponseDto = dtorespwithdata.Class[ViewEntity]
+type GetAllResponseDto = dtorespwithdata.Class[[]ViewEntity]
+type PostResponseDto = dtorespwithdata.Class[ViewEntity]
+type PatchResponseDto = dtorespwithoutdata.Class
+type DeleteResponseDto = dtorespwithoutdata.Class
+type GetDeliveriesResponseDto = dtorespwithdata.Class[[]DeliveryViewEntity]
+type GetAttemptsResponseDto = dtorespwithdata.Class[[]AttemptViewEntity]
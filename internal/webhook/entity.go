@@ -0,0 +1,175 @@
+package webhook
+
+import (
+	"database/sql"
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+type Entity struct {
+	Id         int
+	Url        string
+	Secret     string
+	EventTypes string
+	Active     bool
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+type ViewEntity struct {
+	Id         int      `json:"id"`
+	Url        string   `json:"url"`
+	Secret     string   `json:"secret,omitempty"`
+	EventTypes []string `json:"event_types"`
+	Active     bool     `json:"active"`
+	CreatedAt  string   `json:"created_at"`
+	UpdatedAt  string   `json:"updated_at"`
+}
+
+type DeliveryEntity struct {
+	Id             int64
+	WebhookId      int
+	EventId        int64
+	EventType      string
+	Payload        []byte
+	Status         string
+	Attempts       int
+	NextAttemptAt  time.Time
+	LastStatusCode sql.NullInt32
+	LastError      sql.NullString
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+type DeliveryViewEntity struct {
+	Id             int64           `json:"id"`
+	WebhookId      int             `json:"webhook_id"`
+	EventId        int64           `json:"event_id"`
+	EventType      string          `json:"event_type"`
+	Payload        json.RawMessage `json:"payload"`
+	Status         string          `json:"status"`
+	Attempts       int             `json:"attempts"`
+	NextAttemptAt  string          `json:"next_attempt_at"`
+	LastStatusCode int             `json:"last_status_code,omitempty"`
+	LastError      string          `json:"last_error,omitempty"`
+	CreatedAt      string          `json:"created_at"`
+	UpdatedAt      string          `json:"updated_at"`
+}
+
+// DueDeliveryEntity is a pending delivery joined with the subscription it is
+// addressed to.
+type DueDeliveryEntity struct {
+	DeliveryEntity
+	Url    string
+	Secret string
+}
+
+type AttemptEntity struct {
+	Id          int64
+	DeliveryId  int64
+	AttemptedAt time.Time
+	StatusCode  sql.NullInt32
+	Error       sql.NullString
+	DurationMs  int64
+}
+
+type AttemptViewEntity struct {
+	Id          int64  `json:"id"`
+	DeliveryId  int64  `json:"delivery_id"`
+	AttemptedAt string `json:"attempted_at"`
+	StatusCode  int    `json:"status_code,omitempty"`
+	Error       string `json:"error,omitempty"`
+	DurationMs  int64  `json:"duration_ms"`
+}
+
+func toViewEntity(e *Entity) *ViewEntity {
+	return &ViewEntity{
+		Id:         e.Id,
+		Url:        e.Url,
+		EventTypes: splitEventTypes(e.EventTypes),
+		Active:     e.Active,
+		CreatedAt:  e.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:  e.UpdatedAt.Format(time.RFC3339),
+	}
+}
+
+func toViewEntities(s []Entity) []ViewEntity {
+	viewEntities := []ViewEntity{}
+	for _, e := range s {
+		viewEntities = append(viewEntities, *toViewEntity(&e))
+	}
+	return viewEntities
+}
+
+func toDeliveryViewEntities(s []DeliveryEntity) []DeliveryViewEntity {
+	viewEntities := []DeliveryViewEntity{}
+	for _, e := range s {
+		viewEntities = append(viewEntities, DeliveryViewEntity{
+			Id:             e.Id,
+			WebhookId:      e.WebhookId,
+			EventId:        e.EventId,
+			EventType:      e.EventType,
+			Payload:        json.RawMessage(e.Payload),
+			Status:         e.Status,
+			Attempts:       e.Attempts,
+			NextAttemptAt:  e.NextAttemptAt.Format(time.RFC3339),
+			LastStatusCode: int(e.LastStatusCode.Int32),
+			LastError:      e.LastError.String,
+			CreatedAt:      e.CreatedAt.Format(time.RFC3339),
+			UpdatedAt:      e.UpdatedAt.Format(time.RFC3339),
+		})
+	}
+	return viewEntities
+}
+
+func toAttemptViewEntities(s []AttemptEntity) []AttemptViewEntity {
+	viewEntities := []AttemptViewEntity{}
+	for _, e := range s {
+		viewEntities = append(viewEntities, AttemptViewEntity{
+			Id:          e.Id,
+			DeliveryId:  e.DeliveryId,
+			AttemptedAt: e.AttemptedAt.Format(time.RFC3339),
+			StatusCode:  int(e.StatusCode.Int32),
+			Error:       e.Error.String,
+			DurationMs:  e.DurationMs,
+		})
+	}
+	return viewEntities
+}
+
+func joinEventTypes(eventTypes []string) string {
+	trimmed := []string{}
+	for _, t := range eventTypes {
+		t = strings.TrimSpace(t)
+		if t != "" {
+			trimmed = append(trimmed, t)
+		}
+	}
+	return strings.Join(trimmed, EventTypeSeparator)
+}
+
+func splitEventTypes(s string) []string {
+	if s == "" {
+		return []string{}
+	}
+	return strings.Split(s, EventTypeSeparator)
+}
+
+// MatchesEventType tells whether an event type passes a subscription filter.
+// An empty filter matches everything; a pattern may be an exact type, "*",
+// or a prefix ending in ".*" such as "grading.*".
+func MatchesEventType(patterns []string, eventType string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	for _, p := range patterns {
+		if p == EventTypeWildcard || p == eventType {
+			return true
+		}
+		if strings.HasSuffix(p, ".*") && strings.HasPrefix(eventType, strings.TrimSuffix(p, "*")) {
+			return true
+		}
+	}
+	return false
+}
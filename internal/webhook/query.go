@@ -0,0 +1,109 @@
+package webhook
+
+import (
+	"gorm.io/gorm"
+)
+
+var (
+	FieldsAll = []string{
+		"id",
+		"url",
+		"secret",
+		"event_types",
+		"active",
+		"created_at",
+		"updated_at",
+	}
+
+	FieldsPatchable = []string{
+		"url",
+		"event_types",
+		"active",
+	}
+
+	FieldsDeliveryAll = []string{
+		"id",
+		"webhook_id",
+		"event_id",
+		"event_type",
+		"payload",
+		"status",
+		"attempts",
+		"next_attempt_at",
+		"last_status_code",
+		"last_error",
+		"created_at",
+		"updated_at",
+	}
+
+	FieldsAttemptAll = []string{
+		"id",
+		"delivery_id",
+		"attempted_at",
+		"status_code",
+		"error",
+		"duration_ms",
+	}
+)
+
+type Query interface {
+	SelectById(fields []string, id int) *gorm.DB
+	SelectAll(fields []string) *gorm.DB
+	SelectActive(fields []string) *gorm.DB
+	SelectByWebhookId(fields []string, webhookId int) *gorm.DB
+	SelectByWebhookIdAndStatus(fields []string, webhookId int, status string) *gorm.DB
+	SelectByDeliveryId(fields []string, deliveryId int64) *gorm.DB
+}
+
+type QueryImpl struct {
+	Db        *gorm.DB
+	TableName string
+}
+
+func NewQuery(db *gorm.DB, tableName string) Query {
+	return &QueryImpl{
+		Db:        db,
+		TableName: tableName,
+	}
+}
+
+func (q *QueryImpl) performSelect(fields []string) *gorm.DB {
+	return q.Db.
+		Select(fields).
+		Table(q.TableName)
+}
+
+func (q *QueryImpl) SelectById(fields []string, id int) *gorm.DB {
+	return q.performSelect(fields).
+		Where("id = ?", id)
+}
+
+func (q *QueryImpl) SelectAll(fields []string) *gorm.DB {
+	return q.performSelect(fields).
+		Order("id ASC")
+}
+
+func (q *QueryImpl) SelectActive(fields []string) *gorm.DB {
+	return q.performSelect(fields).
+		Where("active = TRUE")
+}
+
+func (q *QueryImpl) SelectByWebhookId(fields []string, webhookId int) *gorm.DB {
+	return q.performSelect(fields).
+		Where("webhook_id = ?", webhookId).
+		Order("id DESC")
+}
+
+func (q *QueryImpl) SelectByWebhookIdAndStatus(fields []string, webhookId int, status string) *gorm.DB {
+	if status == "" {
+		return q.SelectByWebhookId(fields, webhookId)
+	}
+	return q.SelectByWebhookId(fields, webhookId).
+		Where("status = ?", status)
+}
+
+func (q *QueryImpl) SelectByDeliveryId(fields []string, deliveryId int64) *gorm.DB {
+	return q.performSelect(fields).
+		Where("delivery_id = ?", deliveryId).
+		Order("attempted_at ASC")
+}
@@ -0,0 +1,301 @@
+package webhook
+
+import (
+	"time"
+
+	"github.com/mrexmelle/connect-emp/internal/config"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type Repository interface {
+	Create(req *Entity) (*Entity, error)
+	FindById(id int) (*Entity, error)
+	FindAll() ([]Entity, error)
+	FindActive() ([]Entity, error)
+	UpdateById(fields map[string]interface{}, id int) error
+	DeleteById(id int) error
+	CreateDeliveries(deliveries []DeliveryEntity) error
+	FindDeliveriesByWebhookIdAndStatus(webhookId int, status string) ([]DeliveryEntity, error)
+	FindDeliveryById(id int64) (*DeliveryEntity, error)
+	FindAttemptsByDeliveryId(deliveryId int64) ([]AttemptEntity, error)
+	ClaimDueDeliveries(limit int, lease time.Duration) ([]DueDeliveryEntity, error)
+	Transaction(fn func(tx *gorm.DB) error) error
+	RecordAttempt(tx *gorm.DB, attempt *AttemptEntity) error
+	MarkDelivered(tx *gorm.DB, id int64, statusCode int) error
+	MarkFailed(tx *gorm.DB, id int64, statusCode int, reason string, nextAttemptAt time.Time, isDead bool) error
+	Release(tx *gorm.DB, ids []int64) error
+}
+
+type RepositoryImpl struct {
+	ConfigService     *config.Service
+	TableName         string
+	DeliveryTableName string
+	AttemptTableName  string
+	Query             Query
+	DeliveryQuery     Query
+	AttemptQuery      Query
+}
+
+func NewRepository(cfg *config.Service) Repository {
+	return &RepositoryImpl{
+		ConfigService:     cfg,
+		TableName:         "webhooks",
+		DeliveryTableName: "webhook_deliveries",
+		AttemptTableName:  "webhook_delivery_attempts",
+		Query:             NewQuery(cfg.ReadDb, "webhooks"),
+		DeliveryQuery:     NewQuery(cfg.ReadDb, "webhook_deliveries"),
+		AttemptQuery:      NewQuery(cfg.ReadDb, "webhook_delivery_attempts"),
+	}
+}
+
+func (r *RepositoryImpl) Create(req *Entity) (*Entity, error) {
+	res := r.ConfigService.WriteDb.Raw(
+		"INSERT INTO "+r.TableName+"(url, secret, event_types, active, "+
+			"created_at, updated_at) "+
+			"VALUES(?, ?, ?, ?, NOW(), NOW()) RETURNING id, created_at, updated_at",
+		req.Url,
+		req.Secret,
+		req.EventTypes,
+		req.Active,
+	).Row()
+	err := res.Scan(&req.Id, &req.CreatedAt, &req.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+func (r *RepositoryImpl) FindById(id int) (*Entity, error) {
+	response := Entity{}
+	result := r.Query.SelectById(FieldsAll, id).First(&response)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &response, nil
+}
+
+func (r *RepositoryImpl) FindAll() ([]Entity, error) {
+	response := []Entity{}
+	result := r.Query.SelectAll(FieldsAll).Find(&response)
+	if result.Error != nil {
+		return []Entity{}, result.Error
+	}
+	return response, nil
+}
+
+func (r *RepositoryImpl) FindActive() ([]Entity, error) {
+	response := []Entity{}
+	result := r.Query.SelectActive(FieldsAll).Find(&response)
+	if result.Error != nil {
+		return []Entity{}, result.Error
+	}
+	return response, nil
+}
+
+func (r *RepositoryImpl) UpdateById(fields map[string]interface{}, id int) error {
+	dbFields := map[string]interface{}{}
+
+	for i := range FieldsPatchable {
+		introspectedKey := FieldsPatchable[i]
+		value, ok := fields[introspectedKey]
+		if ok {
+			dbFields[introspectedKey] = value
+		}
+	}
+
+	if len(dbFields) > 0 {
+		dbFields["updated_at"] = time.Now()
+		result := r.ConfigService.WriteDb.
+			Table(r.TableName).
+			Where("id = ?", id).
+			Updates(dbFields)
+
+		if result.Error != nil {
+			return result.Error
+		}
+
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+	}
+
+	return nil
+}
+
+func (r *RepositoryImpl) DeleteById(id int) error {
+	result := r.ConfigService.WriteDb.Exec(
+		"DELETE FROM "+r.TableName+" WHERE id = ?",
+		id,
+	)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *RepositoryImpl) CreateDeliveries(deliveries []DeliveryEntity) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+	return r.ConfigService.WriteDb.Transaction(func(tx *gorm.DB) error {
+		for _, d := range deliveries {
+			err := tx.Exec(
+				"INSERT INTO "+r.DeliveryTableName+"(webhook_id, event_id, event_type, payload, "+
+					"status, next_attempt_at, created_at, updated_at) "+
+					"VALUES(?, ?, ?, ?, ?, NOW(), NOW(), NOW()) "+
+					"ON CONFLICT (webhook_id, event_id) DO NOTHING",
+				d.WebhookId,
+				d.EventId,
+				d.EventType,
+				string(d.Payload),
+				DeliveryStatusPending,
+			).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (r *RepositoryImpl) FindDeliveriesByWebhookIdAndStatus(webhookId int, status string) ([]DeliveryEntity, error) {
+	response := []DeliveryEntity{}
+	result := r.DeliveryQuery.
+		SelectByWebhookIdAndStatus(FieldsDeliveryAll, webhookId, status).
+		Find(&response)
+	if result.Error != nil {
+		return []DeliveryEntity{}, result.Error
+	}
+	return response, nil
+}
+
+func (r *RepositoryImpl) FindDeliveryById(id int64) (*DeliveryEntity, error) {
+	response := DeliveryEntity{}
+	result := r.DeliveryQuery.SelectById(FieldsDeliveryAll, int(id)).First(&response)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &response, nil
+}
+
+func (r *RepositoryImpl) FindAttemptsByDeliveryId(deliveryId int64) ([]AttemptEntity, error) {
+	response := []AttemptEntity{}
+	result := r.AttemptQuery.SelectByDeliveryId(FieldsAttemptAll, deliveryId).Find(&response)
+	if result.Error != nil {
+		return []AttemptEntity{}, result.Error
+	}
+	return response, nil
+}
+
+// ClaimDueDeliveries leases up to limit due deliveries by pushing their next
+// attempt lease into the future, so that other dispatchers pass them over
+// while they are sent. The rows are only locked while being claimed.
+func (r *RepositoryImpl) ClaimDueDeliveries(limit int, lease time.Duration) ([]DueDeliveryEntity, error) {
+	deliveries := []DueDeliveryEntity{}
+	err := r.ConfigService.WriteDb.Transaction(func(tx *gorm.DB) error {
+		result := tx.
+			Table(r.DeliveryTableName+" AS d").
+			Select("d.*, w.url, w.secret").
+			Joins("JOIN "+r.TableName+" AS w ON w.id = d.webhook_id AND w.active = TRUE").
+			Clauses(clause.Locking{
+				Strength: "UPDATE",
+				Table:    clause.Table{Name: "d"},
+				Options:  "SKIP LOCKED",
+			}).
+			Where("d.status = ?", DeliveryStatusPending).
+			Where("d.next_attempt_at <= NOW()").
+			Order("d.id ASC").
+			Limit(limit).
+			Find(&deliveries)
+		if result.Error != nil {
+			return result.Error
+		}
+		if len(deliveries) == 0 {
+			return nil
+		}
+
+		ids := []int64{}
+		for _, d := range deliveries {
+			ids = append(ids, d.Id)
+		}
+		return tx.
+			Table(r.DeliveryTableName).
+			Where("id IN ?", ids).
+			Update("next_attempt_at", time.Now().Add(lease)).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+func (r *RepositoryImpl) Transaction(fn func(tx *gorm.DB) error) error {
+	return r.ConfigService.WriteDb.Transaction(fn)
+}
+
+func (r *RepositoryImpl) RecordAttempt(tx *gorm.DB, attempt *AttemptEntity) error {
+	return tx.Raw(
+		"INSERT INTO "+r.AttemptTableName+"(delivery_id, attempted_at, status_code, error, duration_ms) "+
+			"VALUES(?, ?, ?, ?, ?) RETURNING id",
+		attempt.DeliveryId,
+		attempt.AttemptedAt,
+		attempt.StatusCode,
+		attempt.Error,
+		attempt.DurationMs,
+	).Scan(&attempt.Id).Error
+}
+
+func (r *RepositoryImpl) MarkDelivered(tx *gorm.DB, id int64, statusCode int) error {
+	return tx.
+		Table(r.DeliveryTableName).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"status":           DeliveryStatusDelivered,
+			"attempts":         gorm.Expr("attempts + 1"),
+			"last_status_code": statusCode,
+			"last_error":       nil,
+			"updated_at":       time.Now(),
+		}).Error
+}
+
+func (r *RepositoryImpl) MarkFailed(
+	tx *gorm.DB,
+	id int64,
+	statusCode int,
+	reason string,
+	nextAttemptAt time.Time,
+	isDead bool,
+) error {
+	status := DeliveryStatusPending
+	if isDead {
+		status = DeliveryStatusDead
+	}
+	lastStatusCode := interface{}(nil)
+	if statusCode != 0 {
+		lastStatusCode = statusCode
+	}
+	return tx.
+		Table(r.DeliveryTableName).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"status":           status,
+			"attempts":         gorm.Expr("attempts + 1"),
+			"next_attempt_at":  nextAttemptAt,
+			"last_status_code": lastStatusCode,
+			"last_error":       reason,
+			"updated_at":       time.Now(),
+		}).Error
+}
+
+// Release ends the lease of claimed deliveries that were not attempted,
+// making them due again at once.
+func (r *RepositoryImpl) Release(tx *gorm.DB, ids []int64) error {
+	return tx.
+		Table(r.DeliveryTableName).
+		Where("id IN ?", ids).
+		Update("next_attempt_at", gorm.Expr("NOW()")).Error
+}
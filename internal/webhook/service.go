@@ -0,0 +1,202 @@
+package webhook
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"net/url"
+
+	"github.com/mrexmelle/connect-emp/internal/config"
+	"github.com/mrexmelle/connect-emp/internal/localerror"
+	"github.com/mrexmelle/connect-emp/internal/outbox"
+	"gorm.io/gorm"
+)
+
+type Service struct {
+	ConfigService     *config.Service
+	WebhookRepository Repository
+}
+
+func NewService(
+	cfg *config.Service,
+	r Repository,
+) *Service {
+	return &Service{
+		ConfigService:     cfg,
+		WebhookRepository: r,
+	}
+}
+
+func (s *Service) Create(req PostRequestDto) (*ViewEntity, error) {
+	err := validateUrl(req.Url)
+	if err != nil {
+		return nil, err
+	}
+
+	secret := req.Secret
+	if secret == "" {
+		secret, err = generateSecret()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	result, err := s.WebhookRepository.Create(&Entity{
+		Url:        req.Url,
+		Secret:     secret,
+		EventTypes: joinEventTypes(req.EventTypes),
+		Active:     true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	view := toViewEntity(result)
+	view.Secret = result.Secret
+	return view, nil
+}
+
+func (s *Service) RetrieveById(id int) (*ViewEntity, error) {
+	result, err := s.WebhookRepository.FindById(id)
+	if err != nil {
+		return nil, err
+	}
+	return toViewEntity(result), nil
+}
+
+func (s *Service) RetrieveAll() ([]ViewEntity, error) {
+	result, err := s.WebhookRepository.FindAll()
+	if err != nil {
+		return []ViewEntity{}, err
+	}
+	return toViewEntities(result), nil
+}
+
+func (s *Service) UpdateById(fields map[string]interface{}, id int) error {
+	if value, ok := fields["url"]; ok {
+		u, isString := value.(string)
+		if !isString {
+			return localerror.ErrBadUrl
+		}
+		err := validateUrl(u)
+		if err != nil {
+			return err
+		}
+	}
+
+	if value, ok := fields["event_types"]; ok {
+		items, isList := value.([]interface{})
+		if !isList {
+			return localerror.ErrBadJson
+		}
+		eventTypes := []string{}
+		for _, item := range items {
+			t, isString := item.(string)
+			if !isString {
+				return localerror.ErrBadJson
+			}
+			eventTypes = append(eventTypes, t)
+		}
+		fields["event_types"] = joinEventTypes(eventTypes)
+	}
+
+	if value, ok := fields["active"]; ok {
+		if _, isBool := value.(bool); !isBool {
+			return localerror.ErrBadJson
+		}
+	}
+
+	return s.WebhookRepository.UpdateById(fields, id)
+}
+
+func (s *Service) DeleteById(id int) error {
+	return s.WebhookRepository.DeleteById(id)
+}
+
+func (s *Service) RetrieveDeliveriesByWebhookIdAndStatus(
+	webhookId int,
+	status string,
+) ([]DeliveryViewEntity, error) {
+	if status != "" &&
+		status != DeliveryStatusPending &&
+		status != DeliveryStatusDelivered &&
+		status != DeliveryStatusDead {
+		return []DeliveryViewEntity{}, localerror.ErrBadQueryParam
+	}
+
+	_, err := s.WebhookRepository.FindById(webhookId)
+	if err != nil {
+		return []DeliveryViewEntity{}, err
+	}
+
+	result, err := s.WebhookRepository.FindDeliveriesByWebhookIdAndStatus(webhookId, status)
+	if err != nil {
+		return []DeliveryViewEntity{}, err
+	}
+	return toDeliveryViewEntities(result), nil
+}
+
+func (s *Service) RetrieveAttemptsByDeliveryId(
+	webhookId int,
+	deliveryId int64,
+) ([]AttemptViewEntity, error) {
+	delivery, err := s.WebhookRepository.FindDeliveryById(deliveryId)
+	if err != nil {
+		return []AttemptViewEntity{}, err
+	}
+	if delivery.WebhookId != webhookId {
+		return []AttemptViewEntity{}, gorm.ErrRecordNotFound
+	}
+
+	result, err := s.WebhookRepository.FindAttemptsByDeliveryId(deliveryId)
+	if err != nil {
+		return []AttemptViewEntity{}, err
+	}
+	return toAttemptViewEntities(result), nil
+}
+
+// Enqueue creates one pending delivery of an event per matching active
+// subscription. Enqueuing the same event twice is harmless.
+func (s *Service) Enqueue(e *outbox.ViewEntity) error {
+	webhooks, err := s.WebhookRepository.FindActive()
+	if err != nil {
+		return err
+	}
+
+	payload, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	deliveries := []DeliveryEntity{}
+	for _, w := range webhooks {
+		if !MatchesEventType(splitEventTypes(w.EventTypes), e.Type) {
+			continue
+		}
+		deliveries = append(deliveries, DeliveryEntity{
+			WebhookId: w.Id,
+			EventId:   e.Id,
+			EventType: e.Type,
+			Payload:   payload,
+		})
+	}
+
+	return s.WebhookRepository.CreateDeliveries(deliveries)
+}
+
+func validateUrl(s string) error {
+	u, err := url.Parse(s)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return localerror.ErrBadUrl
+	}
+	return nil
+}
+
+func generateSecret() (string, error) {
+	b := make([]byte, 32)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,68 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrSignatureMalformed = errors.New("signature is malformed")
+	ErrSignatureMismatch  = errors.New("signature does not match")
+	ErrSignatureExpired   = errors.New("signature timestamp is outside tolerance")
+)
+
+// Sign produces the value of the signature header: the unix timestamp and an
+// HMAC-SHA256 over "<timestamp>.<body>" keyed by the subscription secret.
+func Sign(secret string, timestamp int64, body []byte) string {
+	t := strconv.FormatInt(timestamp, 10)
+	return "t=" + t + ",v1=" + computeMac(secret, t, body)
+}
+
+// Verify checks a signature header the way a receiver is expected to.
+func Verify(secret string, header string, body []byte, now time.Time, tolerance time.Duration) error {
+	t := ""
+	v1 := ""
+	for _, part := range strings.Split(header, ",") {
+		key, value, found := strings.Cut(part, "=")
+		if !found {
+			return ErrSignatureMalformed
+		}
+		switch key {
+		case "t":
+			t = value
+		case "v1":
+			v1 = value
+		}
+	}
+	if t == "" || v1 == "" {
+		return ErrSignatureMalformed
+	}
+
+	timestamp, err := strconv.ParseInt(t, 10, 64)
+	if err != nil {
+		return ErrSignatureMalformed
+	}
+	age := now.Sub(time.Unix(timestamp, 0))
+	if age > tolerance || age < -tolerance {
+		return ErrSignatureExpired
+	}
+
+	expected := computeMac(secret, t, body)
+	if !hmac.Equal([]byte(expected), []byte(v1)) {
+		return ErrSignatureMismatch
+	}
+	return nil
+}
+
+func computeMac(secret string, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,121 @@
+package webhook
+
+import (
+	"testing"
+	"time"
+)
+
+type VerifyTestCase struct {
+	name     string
+	secret   string
+	header   string
+	body     string
+	expected error
+}
+
+type MatchesEventTypeTestCase struct {
+	name      string
+	patterns  []string
+	eventType string
+	expected  bool
+}
+
+func TestVerify(t *testing.T) {
+	now := time.Unix(1714000000, 0)
+	body := `{"id":1,"type":"grading.created"}`
+
+	tc := []VerifyTestCase{
+		{
+			name:     "Valid signature",
+			secret:   "s3cret",
+			header:   Sign("s3cret", now.Unix(), []byte(body)),
+			body:     body,
+			expected: nil,
+		},
+		{
+			name:     "Wrong secret",
+			secret:   "other",
+			header:   Sign("s3cret", now.Unix(), []byte(body)),
+			body:     body,
+			expected: ErrSignatureMismatch,
+		},
+		{
+			name:     "Tampered body",
+			secret:   "s3cret",
+			header:   Sign("s3cret", now.Unix(), []byte(body)),
+			body:     `{"id":2,"type":"grading.created"}`,
+			expected: ErrSignatureMismatch,
+		},
+		{
+			name:     "Old timestamp",
+			secret:   "s3cret",
+			header:   Sign("s3cret", now.Add(-10*time.Minute).Unix(), []byte(body)),
+			body:     body,
+			expected: ErrSignatureExpired,
+		},
+		{
+			name:     "Missing digest",
+			secret:   "s3cret",
+			header:   "t=1714000000",
+			body:     body,
+			expected: ErrSignatureMalformed,
+		},
+	}
+
+	for _, c := range tc {
+		out := Verify(c.secret, c.header, []byte(c.body), now, 5*time.Minute)
+		if out != c.expected {
+			t.Errorf("[%s]\nresult: %v\nexpected: %v\n",
+				c.name,
+				out,
+				c.expected,
+			)
+		}
+	}
+}
+
+func TestMatchesEventType(t *testing.T) {
+	tc := []MatchesEventTypeTestCase{
+		{
+			name:      "No filter",
+			patterns:  []string{},
+			eventType: "grading.created",
+			expected:  true,
+		},
+		{
+			name:      "Exact match",
+			patterns:  []string{"titling.ended"},
+			eventType: "titling.ended",
+			expected:  true,
+		},
+		{
+			name:      "Prefix match",
+			patterns:  []string{"grading.*"},
+			eventType: "grading.deleted",
+			expected:  true,
+		},
+		{
+			name:      "Prefix does not match other aggregate",
+			patterns:  []string{"grading.*"},
+			eventType: "titling.created",
+			expected:  false,
+		},
+		{
+			name:      "Wildcard",
+			patterns:  []string{"*"},
+			eventType: "titling.created",
+			expected:  true,
+		},
+	}
+
+	for _, c := range tc {
+		out := MatchesEventType(c.patterns, c.eventType)
+		if out != c.expected {
+			t.Errorf("[%s]\nresult: %t\nexpected: %t\n",
+				c.name,
+				out,
+				c.expected,
+			)
+		}
+	}
+}
@@ -0,0 +1,27 @@
+package webhook
+
+import (
+	"context"
+
+	"github.com/mrexmelle/connect-emp/internal/outbox"
+)
+
+// Sink plugs webhook subscriptions into the outbox relay. It only records
+// deliveries; the Dispatcher performs the HTTP calls.
+type Sink struct {
+	WebhookService *Service
+}
+
+func NewSink(svc *Service) *Sink {
+	return &Sink{
+		WebhookService: svc,
+	}
+}
+
+func (s *Sink) Name() string {
+	return "webhooks"
+}
+
+func (s *Sink) Deliver(ctx context.Context, e *outbox.ViewEntity) error {
+	return s.WebhookService.Enqueue(e)
+}
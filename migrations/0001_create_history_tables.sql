@@ -0,0 +1,57 @@
+-- Transaction-time history of gradings and titlings.
+--
+-- gradings and titlings keep holding the currently known state, with
+-- start_date and end_date as valid time. Every version of a row is also
+-- written to the matching *_history table together with the interval
+-- during which the system believed it: [recorded_from, recorded_to).
+-- A NULL recorded_to marks the version that is still believed today.
+
+CREATE TABLE IF NOT EXISTS gradings_history (
+    id            BIGSERIAL PRIMARY KEY,
+    grading_id    INTEGER NOT NULL,
+    ehid          VARCHAR(255) NOT NULL,
+    start_date    DATE NOT NULL,
+    end_date      DATE,
+    grade         VARCHAR(255) NOT NULL,
+    recorded_from TIMESTAMPTZ NOT NULL,
+    recorded_to   TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS gradings_history_ehid_recorded_idx
+    ON gradings_history (ehid, recorded_from, recorded_to);
+
+CREATE UNIQUE INDEX IF NOT EXISTS gradings_history_grading_id_current_idx
+    ON gradings_history (grading_id) WHERE recorded_to IS NULL;
+
+CREATE TABLE IF NOT EXISTS titlings_history (
+    id            BIGSERIAL PRIMARY KEY,
+    titling_id    INTEGER NOT NULL,
+    ehid          VARCHAR(255) NOT NULL,
+    start_date    DATE NOT NULL,
+    end_date      DATE,
+    title         VARCHAR(255) NOT NULL,
+    recorded_from TIMESTAMPTZ NOT NULL,
+    recorded_to   TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS titlings_history_ehid_recorded_idx
+    ON titlings_history (ehid, recorded_from, recorded_to);
+
+CREATE UNIQUE INDEX IF NOT EXISTS titlings_history_titling_id_current_idx
+    ON titlings_history (titling_id) WHERE recorded_to IS NULL;
+
+-- Existing rows become the first known version, recorded when they were
+-- last touched.
+INSERT INTO gradings_history (grading_id, ehid, start_date, end_date, grade, recorded_from)
+SELECT g.id, g.ehid, g.start_date, g.end_date, g.grade, g.updated_at
+FROM gradings g
+WHERE NOT EXISTS (SELECT 1 FROM gradings_history h WHERE h.grading_id = g.id);
+
+INSERT INTO titlings_history (titling_id, ehid, start_date, end_date, title, recorded_from)
+SELECT t.id, t.ehid, t.start_date, t.end_date, t.title, t.updated_at
+FROM titlings t
+WHERE NOT EXISTS (SELECT 1 FROM titlings_history h WHERE h.titling_id = t.id);
+
+GRANT SELECT ON gradings_history, titlings_history TO emp_r;
+GRANT SELECT, INSERT, UPDATE ON gradings_history, titlings_history TO emp_w;
+GRANT USAGE ON SEQUENCE gradings_history_id_seq, titlings_history_id_seq TO emp_w;
@@ -0,0 +1,23 @@
+-- Domain events written in the same transaction as the change that caused
+-- them. The relay picks up rows whose delivered_at is NULL and whose
+-- next_attempt_at has passed, and marks them delivered once every sink
+-- accepted the event.
+
+CREATE TABLE IF NOT EXISTS outbox_events (
+    id              BIGSERIAL PRIMARY KEY,
+    event_type      VARCHAR(255) NOT NULL,
+    aggregate_type  VARCHAR(255) NOT NULL,
+    aggregate_id    VARCHAR(255) NOT NULL,
+    payload         JSONB NOT NULL,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    attempts        INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_error      TEXT,
+    delivered_at    TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS outbox_events_pending_idx
+    ON outbox_events (next_attempt_at, id) WHERE delivered_at IS NULL;
+
+GRANT SELECT, INSERT, UPDATE ON outbox_events TO emp_w;
+GRANT USAGE ON SEQUENCE outbox_events_id_seq TO emp_w;
@@ -0,0 +1,49 @@
+-- Webhook subscriptions and their deliveries. Each outbox event creates one
+-- delivery per matching subscription; every HTTP call made for a delivery
+-- is logged in webhook_delivery_attempts. Deliveries that exhaust their
+-- attempts are moved to the 'dead' status.
+
+CREATE TABLE IF NOT EXISTS webhooks (
+    id          SERIAL PRIMARY KEY,
+    url         TEXT NOT NULL,
+    secret      VARCHAR(255) NOT NULL,
+    event_types TEXT NOT NULL DEFAULT '',
+    active      BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id               BIGSERIAL PRIMARY KEY,
+    webhook_id       INTEGER NOT NULL REFERENCES webhooks (id) ON DELETE CASCADE,
+    event_id         BIGINT NOT NULL,
+    event_type       VARCHAR(255) NOT NULL,
+    payload          JSONB NOT NULL,
+    status           VARCHAR(16) NOT NULL DEFAULT 'pending',
+    attempts         INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_status_code INTEGER,
+    last_error       TEXT,
+    created_at       TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at       TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (webhook_id, event_id)
+);
+
+CREATE INDEX IF NOT EXISTS webhook_deliveries_due_idx
+    ON webhook_deliveries (next_attempt_at, id) WHERE status = 'pending';
+
+CREATE TABLE IF NOT EXISTS webhook_delivery_attempts (
+    id           BIGSERIAL PRIMARY KEY,
+    delivery_id  BIGINT NOT NULL REFERENCES webhook_deliveries (id) ON DELETE CASCADE,
+    attempted_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    status_code  INTEGER,
+    error        TEXT,
+    duration_ms  BIGINT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS webhook_delivery_attempts_delivery_idx
+    ON webhook_delivery_attempts (delivery_id, attempted_at);
+
+GRANT SELECT ON webhooks, webhook_deliveries, webhook_delivery_attempts TO emp_r;
+GRANT SELECT, INSERT, UPDATE, DELETE ON webhooks, webhook_deliveries, webhook_delivery_attempts TO emp_w;
+GRANT USAGE ON SEQUENCE webhooks_id_seq, webhook_deliveries_id_seq, webhook_delivery_attempts_id_seq TO emp_w;